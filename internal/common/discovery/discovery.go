@@ -22,7 +22,10 @@ import (
 
 const (
 	EndpointIDAttr     = "discovery.endpoint.id"
+	EventTypeAttr      = "discovery.event.type"
 	ObserverIDAttr     = "discovery.observer.id"
+	ObserverNameAttr   = "discovery.observer.name"
+	ObserverTypeAttr   = "discovery.observer.type"
 	ReceiverConfigAttr = "discovery.receiver.config"
 	ReceiverNameAttr   = "discovery.receiver.name"
 	ReceiverTypeAttr   = "discovery.receiver.type"
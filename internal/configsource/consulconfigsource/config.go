@@ -0,0 +1,38 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consulconfigsource
+
+import (
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+// Config holds the configuration for the creation of Consul config source objects.
+type Config struct {
+	configsource.SourceSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+
+	// Endpoint is the address of the Consul agent, e.g. "http://localhost:8500".
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Token is the ACL token used to read the keys. It defaults to the
+	// CONSUL_HTTP_TOKEN environment variable.
+	Token string `mapstructure:"token"`
+
+	// Datacenter is the datacenter of the keys, the datacenter of the agent
+	// if empty.
+	Datacenter string `mapstructure:"datacenter"`
+
+	// Namespace is the Consul Enterprise namespace of the keys.
+	Namespace string `mapstructure:"namespace"`
+}
@@ -0,0 +1,75 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consulconfigsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+const (
+	// The "type" of Consul config sources in configuration.
+	typeStr = "consul"
+
+	defaultEndpoint = "http://localhost:8500"
+)
+
+type (
+	errMissingEndpoint struct{ error }
+	errInvalidEndpoint struct{ error }
+)
+
+type consulFactory struct{}
+
+func (f *consulFactory) Type() component.Type {
+	return typeStr
+}
+
+func (f *consulFactory) CreateDefaultConfig() configsource.Settings {
+	return &Config{
+		SourceSettings: configsource.NewSourceSettings(component.MustNewID(typeStr)),
+		Endpoint:       defaultEndpoint,
+	}
+}
+
+func (f *consulFactory) CreateConfigSource(_ context.Context, settings configsource.Settings, logger *zap.Logger) (configsource.ConfigSource, error) {
+	consulCfg := settings.(*Config)
+
+	if consulCfg.Endpoint == "" {
+		return nil, &errMissingEndpoint{errors.New("cannot connect to consul without an endpoint")}
+	}
+
+	u, err := url.ParseRequestURI(consulCfg.Endpoint)
+	if err != nil {
+		return nil, &errInvalidEndpoint{fmt.Errorf("invalid endpoint %q: %w", consulCfg.Endpoint, err)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "unix" {
+		return nil, &errInvalidEndpoint{fmt.Errorf("invalid endpoint %q: the scheme must be http, https or unix", consulCfg.Endpoint)}
+	}
+
+	return newConfigSource(consulCfg, logger)
+}
+
+// NewFactory creates a factory for Consul ConfigSource objects.
+func NewFactory() configsource.Factory {
+	return &consulFactory{}
+}
@@ -0,0 +1,127 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consulconfigsource
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// fakeConsul is an in-process stand-in for the KV endpoints of the Consul HTTP API, including blocking queries.
+type fakeConsul struct {
+	kv      map[string]*api.KVPair
+	changed chan struct{}
+	// queries holds the query parameters and the ACL token of the requests.
+	queries []url.Values
+	index   uint64
+	mu      sync.Mutex
+}
+
+func newFakeConsul(t *testing.T, kv map[string]string) (*fakeConsul, *httptest.Server) {
+	f := &fakeConsul{kv: map[string]*api.KVPair{}, changed: make(chan struct{})}
+	for k, v := range kv {
+		f.put(k, v)
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+// put creates or updates a key, unblocking the blocking queries.
+func (f *fakeConsul) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	pair, ok := f.kv[key]
+	if !ok {
+		pair = &api.KVPair{Key: key, CreateIndex: f.index}
+		f.kv[key] = pair
+	}
+	pair.Value = []byte(value)
+	pair.ModifyIndex = f.index
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// delete deletes a key, unblocking the blocking queries.
+func (f *fakeConsul) delete(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	delete(f.kv, key)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) lastQuery() url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries[len(f.queries)-1]
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, ok := strings.CutPrefix(r.URL.Path, "/v1/kv/")
+	if !ok || r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	query.Set("token", r.Header.Get("X-Consul-Token"))
+
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	if waitIndex, _ := strconv.ParseUint(query.Get("index"), 10, 64); waitIndex > 0 && waitIndex >= f.index {
+		changed := f.changed
+		f.mu.Unlock()
+		// The wait time of the blocking queries is short to test their renewal.
+		select {
+		case <-changed:
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+
+	var pairs []*api.KVPair
+	_, recurse := query["recurse"]
+	for k, pair := range f.kv {
+		if k == key || (recurse && strings.HasPrefix(k, key)) {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pairs)
+}
@@ -0,0 +1,182 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consulconfigsource
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/consul/api"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+const maxBackoffTime = time.Second * 60
+
+type errNotFound struct{ error }
+
+// consulConfigSource implements the configsource.ConfigSource interface.
+type consulConfigSource struct {
+	logger *zap.Logger
+	kv     *api.KV
+}
+
+func newConfigSource(cfg *Config, logger *zap.Logger) (configsource.ConfigSource, error) {
+	config := api.DefaultConfig()
+	config.Address = cfg.Endpoint
+	config.Datacenter = cfg.Datacenter
+	config.Namespace = cfg.Namespace
+	if cfg.Token != "" {
+		config.Token = cfg.Token
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &consulConfigSource{
+		logger: logger,
+		kv:     client.KV(),
+	}, nil
+}
+
+// Retrieve returns the value of a key, or the values of the keys under a prefix, ending with '/', as nested maps.
+func (s *consulConfigSource) Retrieve(ctx context.Context, selector string, _ *confmap.Conf, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	value, modifyIndexes, index, err := s.query(ctx, selector, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(modifyIndexes) == 0 {
+		return nil, &errNotFound{fmt.Errorf("no value found for %q", selector)}
+	}
+	if watcher == nil {
+		return confmap.NewRetrieved(value)
+	}
+	return confmap.NewRetrieved(value, confmap.WithRetrievedClose(s.newWatcher(selector, modifyIndexes, index, watcher)))
+}
+
+// query reads the selected keys, returning their value, their modify indexes and the index of the query.
+// A non-zero waitIndex makes it a blocking query, returning once the index of the query is greater than it
+// or the wait time of the agent elapsed.
+func (s *consulConfigSource) query(ctx context.Context, selector string, waitIndex uint64) (any, map[string]uint64, uint64, error) {
+	opts := (&api.QueryOptions{WaitIndex: waitIndex}).WithContext(ctx)
+	modifyIndexes := map[string]uint64{}
+
+	if !strings.HasSuffix(selector, "/") {
+		pair, meta, err := s.kv.Get(selector, opts)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if pair == nil {
+			return nil, modifyIndexes, meta.LastIndex, nil
+		}
+		modifyIndexes[pair.Key] = pair.ModifyIndex
+		return string(pair.Value), modifyIndexes, meta.LastIndex, nil
+	}
+
+	pairs, meta, err := s.kv.List(selector, opts)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	value := map[string]any{}
+	for _, pair := range pairs {
+		relative := strings.TrimPrefix(pair.Key, selector)
+		if relative == "" || strings.HasSuffix(relative, "/") {
+			// Folders don't hold values.
+			continue
+		}
+		if err = setNested(value, strings.Split(relative, "/"), string(pair.Value)); err != nil {
+			return nil, nil, 0, fmt.Errorf("invalid keys under %q: %w", selector, err)
+		}
+		modifyIndexes[pair.Key] = pair.ModifyIndex
+	}
+	return value, modifyIndexes, meta.LastIndex, nil
+}
+
+// setNested sets a value in nested maps, one per part of its key.
+func setNested(m map[string]any, parts []string, value string) error {
+	for i, part := range parts[:len(parts)-1] {
+		child, ok := m[part]
+		if !ok {
+			child = map[string]any{}
+			m[part] = child
+		}
+		if m, ok = child.(map[string]any); !ok {
+			return fmt.Errorf("%q has both a value and nested keys", strings.Join(parts[:i+1], "/"))
+		}
+	}
+	last := parts[len(parts)-1]
+	if _, ok := m[last]; ok {
+		return fmt.Errorf("%q has both a value and nested keys", strings.Join(parts, "/"))
+	}
+	m[last] = value
+	return nil
+}
+
+// newWatcher runs blocking queries on the selected keys, notifying the watcher once their modify indexes differ
+// from the retrieved ones, i.e. a key was updated, added or deleted.
+func (s *consulConfigSource) newWatcher(selector string, modifyIndexes map[string]uint64, index uint64, watcherFunc confmap.WatcherFunc) confmap.CloseFunc {
+	watchCtx, cancel := context.WithCancel(context.Background())
+	ebo := backoff.NewExponentialBackOff()
+	ebo.MaxElapsedTime = maxBackoffTime
+
+	go func() {
+		for {
+			_, current, lastIndex, err := s.query(watchCtx, selector, index)
+			if err != nil {
+				if watchCtx.Err() != nil {
+					return
+				}
+
+				s.logger.Info("error watching", zap.String("selector", selector), zap.Error(err))
+				next := ebo.NextBackOff()
+				if next == backoff.Stop {
+					watcherFunc(&confmap.ChangeEvent{Error: err})
+					return
+				}
+				select {
+				case <-time.After(next):
+					continue
+				case <-watchCtx.Done():
+					return
+				}
+			}
+			ebo.Reset()
+
+			if !maps.Equal(current, modifyIndexes) {
+				watcherFunc(&confmap.ChangeEvent{Error: nil})
+				return
+			}
+
+			// The index can go backwards, e.g. after a snapshot restore, the blocking queries restart then. It
+			// must be at least 1 for the queries to block.
+			if lastIndex < index {
+				lastIndex = 0
+			}
+			index = max(lastIndex, 1)
+		}
+	}()
+
+	return func(ctx context.Context) error {
+		cancel()
+		return nil
+	}
+}
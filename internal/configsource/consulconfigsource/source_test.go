@@ -0,0 +1,180 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consulconfigsource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+func TestSourceRetrieve(t *testing.T) {
+	consul, server := newFakeConsul(t, map[string]string{
+		"otel/token":                     "t0k3n",
+		"otel/exporters/":                "",
+		"otel/exporters/signalfx/realm":  "us0",
+		"otel/exporters/signalfx/token":  "t0k3n",
+		"otel/exporters/otlp/endpoint":   "localhost:4317",
+		"invalid/exporters":              "value",
+		"invalid/exporters/otlp/timeout": "10s",
+	})
+	source, err := newConfigSource(&Config{Endpoint: server.URL, Token: "acl-token", Datacenter: "dc2", Namespace: "observability"}, zap.NewNop())
+	require.NoError(t, err)
+
+	tests := []struct {
+		expected any
+		name     string
+		selector string
+		wantErr  string
+	}{
+		{name: "key", selector: "otel/token", expected: "t0k3n"},
+		{
+			name:     "prefix",
+			selector: "otel/exporters/",
+			expected: map[string]any{
+				"signalfx": map[string]any{"realm": "us0", "token": "t0k3n"},
+				"otlp":     map[string]any{"endpoint": "localhost:4317"},
+			},
+		},
+		{name: "missing_key", selector: "otel/missing", wantErr: `no value found for "otel/missing"`},
+		{name: "missing_prefix", selector: "otel/missing/", wantErr: `no value found for "otel/missing/"`},
+		{name: "value_and_nested_keys", selector: "invalid/", wantErr: `"exporters" has both a value and nested keys`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retrieved, err := source.Retrieve(context.Background(), tt.selector, nil, nil)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, retrieved)
+				return
+			}
+			require.NoError(t, err)
+			value, err := retrieved.AsRaw()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+			assert.NoError(t, retrieved.Close(context.Background()))
+
+			query := consul.lastQuery()
+			assert.Equal(t, "acl-token", query.Get("token"))
+			assert.Equal(t, "dc2", query.Get("dc"))
+			assert.Equal(t, "observability", query.Get("ns"))
+		})
+	}
+}
+
+func TestSourceWatcher(t *testing.T) {
+	tests := []struct {
+		update   func(consul *fakeConsul)
+		name     string
+		selector string
+		notify   bool
+	}{
+		{
+			name:     "key_updated",
+			selector: "otel/token",
+			update:   func(consul *fakeConsul) { consul.put("otel/token", "n3w") },
+			notify:   true,
+		},
+		{
+			name:     "key_deleted",
+			selector: "otel/token",
+			update:   func(consul *fakeConsul) { consul.delete("otel/token") },
+			notify:   true,
+		},
+		{
+			name:     "other_key_updated",
+			selector: "otel/token",
+			update:   func(consul *fakeConsul) { consul.put("otel/exporters/otlp/endpoint", "localhost:4318") },
+		},
+		{
+			name:     "prefix_key_updated",
+			selector: "otel/exporters/",
+			update:   func(consul *fakeConsul) { consul.put("otel/exporters/otlp/endpoint", "localhost:4318") },
+			notify:   true,
+		},
+		{
+			name:     "prefix_key_added",
+			selector: "otel/exporters/",
+			update:   func(consul *fakeConsul) { consul.put("otel/exporters/otlp/timeout", "10s") },
+			notify:   true,
+		},
+		{
+			name:     "prefix_key_deleted",
+			selector: "otel/exporters/",
+			update:   func(consul *fakeConsul) { consul.delete("otel/exporters/otlp/endpoint") },
+			notify:   true,
+		},
+		{
+			name:     "key_outside_prefix_updated",
+			selector: "otel/exporters/",
+			update:   func(consul *fakeConsul) { consul.put("otel/token", "n3w") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consul, server := newFakeConsul(t, map[string]string{
+				"otel/token":                   "t0k3n",
+				"otel/exporters/otlp/endpoint": "localhost:4317",
+			})
+			source, err := newConfigSource(&Config{Endpoint: server.URL}, zap.NewNop())
+			require.NoError(t, err)
+
+			events := make(chan *confmap.ChangeEvent, 1)
+			retrieved, err := source.Retrieve(context.Background(), tt.selector, nil, func(event *confmap.ChangeEvent) {
+				events <- event
+			})
+			require.NoError(t, err)
+
+			tt.update(consul)
+			if tt.notify {
+				select {
+				case event := <-events:
+					require.NoError(t, event.Error)
+				case <-time.After(5 * time.Second):
+					t.Fatal("expected the watcher to be notified")
+				}
+			} else {
+				// several blocking queries time out without notification
+				select {
+				case <-events:
+					t.Fatal("unexpected watcher notification")
+				case <-time.After(300 * time.Millisecond):
+				}
+			}
+			require.NoError(t, retrieved.Close(context.Background()))
+		})
+	}
+}
+
+func TestSourceWatcherClose(t *testing.T) {
+	consul, server := newFakeConsul(t, map[string]string{"otel/token": "t0k3n"})
+	source, err := newConfigSource(&Config{Endpoint: server.URL}, zap.NewNop())
+	require.NoError(t, err)
+
+	retrieved, err := source.Retrieve(context.Background(), "otel/token", nil, func(event *confmap.ChangeEvent) {
+		panic(event)
+	})
+	require.NoError(t, err)
+	require.NoError(t, retrieved.Close(context.Background()))
+
+	// no notification once closed
+	consul.put("otel/token", "n3w")
+	time.Sleep(100 * time.Millisecond)
+}
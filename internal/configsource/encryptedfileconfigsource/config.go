@@ -0,0 +1,37 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfileconfigsource
+
+import (
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+// Config holds the configuration for the creation of encrypted file config source objects.
+type Config struct {
+	configsource.SourceSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+
+	// KeyFile is the path of the file holding the age identities and armored PGP
+	// private keys used to decrypt the files.
+	KeyFile string `mapstructure:"key_file"`
+
+	// KeyEnv is the name of the environment variable holding the age identities and
+	// armored PGP private keys used to decrypt the files, when KeyFile is not set.
+	KeyEnv string `mapstructure:"key_env"`
+
+	// WatchFiles is used to control if the referenced files should
+	// be watched for updates or not. The default value is 'false'.
+	// Set it to 'true' to watch the referenced files for changes.
+	WatchFiles bool `mapstructure:"watch_files"`
+}
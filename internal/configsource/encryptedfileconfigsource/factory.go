@@ -0,0 +1,77 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfileconfigsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+const (
+	// The "type" of encrypted file config sources in configuration.
+	typeStr = "encrypted_file"
+)
+
+type (
+	errMissingKey struct{ error }
+	errInvalidKey struct{ error }
+)
+
+type encryptedFileFactory struct{}
+
+func (f *encryptedFileFactory) Type() component.Type {
+	return typeStr
+}
+
+func (f *encryptedFileFactory) CreateDefaultConfig() configsource.Settings {
+	return &Config{
+		SourceSettings: configsource.NewSourceSettings(component.MustNewID(typeStr)),
+	}
+}
+
+func (f *encryptedFileFactory) CreateConfigSource(_ context.Context, settings configsource.Settings, _ *zap.Logger) (configsource.ConfigSource, error) {
+	cfg := settings.(*Config)
+
+	var keyData []byte
+	switch {
+	case cfg.KeyFile != "":
+		var err error
+		if keyData, err = os.ReadFile(cfg.KeyFile); err != nil {
+			return nil, &errInvalidKey{fmt.Errorf("failed to read key file: %w", err)}
+		}
+	case cfg.KeyEnv != "":
+		keyData = []byte(os.Getenv(cfg.KeyEnv))
+	default:
+		return nil, &errMissingKey{errors.New(`either "key_file" or "key_env" must be set`)}
+	}
+
+	k, err := parseKeys(keyData)
+	if err != nil {
+		return nil, &errInvalidKey{err}
+	}
+	return newConfigSource(cfg, k), nil
+}
+
+// NewFactory creates a factory for encrypted file ConfigSource objects.
+func NewFactory() configsource.Factory {
+	return &encryptedFileFactory{}
+}
@@ -0,0 +1,82 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfileconfigsource
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+func TestEncryptedFileFactory_CreateConfigSource(t *testing.T) {
+	ageKey, err := os.ReadFile(path.Join("testdata", "age.key"))
+	require.NoError(t, err)
+	t.Setenv("OTEL_TEST_AGE_KEY", string(ageKey))
+	t.Setenv("OTEL_TEST_INVALID_KEY", "not a key")
+
+	factory := NewFactory()
+	assert.Equal(t, component.MustNewType("encrypted_file"), factory.Type())
+	tests := []struct {
+		config  *Config
+		wantErr error
+		name    string
+	}{
+		{
+			name:    "missing_key",
+			config:  &Config{},
+			wantErr: &errMissingKey{},
+		},
+		{
+			name:    "missing_key_file",
+			config:  &Config{KeyFile: path.Join("testdata", "missing.key")},
+			wantErr: &errInvalidKey{},
+		},
+		{
+			name:    "empty_key_env",
+			config:  &Config{KeyEnv: "OTEL_TEST_UNSET_KEY"},
+			wantErr: &errInvalidKey{},
+		},
+		{
+			name:    "invalid_key_env",
+			config:  &Config{KeyEnv: "OTEL_TEST_INVALID_KEY"},
+			wantErr: &errInvalidKey{},
+		},
+		{
+			name:   "key_file",
+			config: &Config{KeyFile: path.Join("testdata", "pgp.key")},
+		},
+		{
+			name:   "key_env",
+			config: &Config{KeyEnv: "OTEL_TEST_AGE_KEY"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := factory.CreateConfigSource(context.Background(), tt.config, zap.NewNop())
+			require.IsType(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.NotNil(t, actual)
+			} else {
+				assert.Nil(t, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfileconfigsource
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultUnencryptedSuffix = "_unencrypted"
+
+var sopsValueRegexp = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]`)
+
+// macOnlyEncryptedInitialization starts the MAC of files with mac_only_encrypted, so that it differs from the MAC
+// of all values.
+var macOnlyEncryptedInitialization = []byte{
+	0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0x0b,
+	0x0b, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69,
+}
+
+// sopsMetadata is the part of the "sops" section of SOPS files needed to decrypt them with age or PGP keys.
+type sopsMetadata struct {
+	UnencryptedSuffix         string         `yaml:"unencrypted_suffix"`
+	EncryptedSuffix           string         `yaml:"encrypted_suffix"`
+	UnencryptedRegex          string         `yaml:"unencrypted_regex"`
+	EncryptedRegex            string         `yaml:"encrypted_regex"`
+	LastModified              string         `yaml:"lastmodified"`
+	MessageAuthenticationCode string         `yaml:"mac"`
+	KeyGroups                 []sopsKeyGroup `yaml:"key_groups"`
+	sopsKeyGroup              `yaml:",inline"`
+	MACOnlyEncrypted          bool `yaml:"mac_only_encrypted"`
+}
+
+type sopsKeyGroup struct {
+	AgeKeys []sopsKey `yaml:"age"`
+	PGPKeys []sopsKey `yaml:"pgp"`
+}
+
+type sopsKey struct {
+	EncryptedDataKey string `yaml:"enc"`
+}
+
+// sopsFile is a YAML or JSON SOPS file.
+type sopsFile struct {
+	metadata         sopsMetadata
+	unencryptedRegex *regexp.Regexp
+	encryptedRegex   *regexp.Regexp
+	mac              hash.Hash
+	dataKey          []byte
+}
+
+// parseSOPS returns the root mapping of a SOPS file without its "sops" section, nil if the data isn't a SOPS file.
+// The nodes are kept to authenticate the values in the order of the file.
+func parseSOPS(data []byte) (*yaml.Node, *sopsMetadata, error) {
+	var doc struct {
+		Metadata *sopsMetadata `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Metadata == nil {
+		return nil, nil, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, err
+	}
+	tree := root.Content[0]
+	for i := 0; i < len(tree.Content); i += 2 {
+		if tree.Content[i].Value == "sops" {
+			tree.Content = append(tree.Content[:i], tree.Content[i+2:]...)
+			break
+		}
+	}
+	return tree, doc.Metadata, nil
+}
+
+// decryptSOPS returns the decrypted tree of a SOPS file, once its MAC is verified.
+func (k *keys) decryptSOPS(tree *yaml.Node, metadata *sopsMetadata) (map[string]any, error) {
+	f := &sopsFile{metadata: *metadata, mac: sha512.New()}
+	if f.metadata.UnencryptedSuffix == "" && f.metadata.EncryptedSuffix == "" &&
+		f.metadata.UnencryptedRegex == "" && f.metadata.EncryptedRegex == "" {
+		f.metadata.UnencryptedSuffix = defaultUnencryptedSuffix
+	}
+	var err error
+	if f.metadata.UnencryptedRegex != "" {
+		if f.unencryptedRegex, err = regexp.Compile(f.metadata.UnencryptedRegex); err != nil {
+			return nil, fmt.Errorf("invalid unencrypted_regex: %w", err)
+		}
+	}
+	if f.metadata.EncryptedRegex != "" {
+		if f.encryptedRegex, err = regexp.Compile(f.metadata.EncryptedRegex); err != nil {
+			return nil, fmt.Errorf("invalid encrypted_regex: %w", err)
+		}
+	}
+	if f.dataKey, err = k.sopsDataKey(&f.metadata); err != nil {
+		return nil, err
+	}
+	if f.metadata.MACOnlyEncrypted {
+		f.mac.Write(macOnlyEncryptedInitialization)
+	}
+	decrypted, err := f.decryptNode(tree, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.verifyMAC(); err != nil {
+		return nil, err
+	}
+	return decrypted.(map[string]any), nil
+}
+
+// sopsDataKey decrypts the data key of a SOPS file with the first of its age or PGP keys matching ours.
+func (k *keys) sopsDataKey(metadata *sopsMetadata) ([]byte, error) {
+	groups := metadata.KeyGroups
+	switch {
+	case len(groups) > 1:
+		return nil, errors.New("SOPS files with several key groups are not supported")
+	case len(groups) == 0:
+		groups = []sopsKeyGroup{metadata.sopsKeyGroup}
+	}
+	var errs error
+	for _, key := range append(groups[0].AgeKeys, groups[0].PGPKeys...) {
+		dataKey, err := k.decrypt([]byte(key.EncryptedDataKey))
+		if err == nil {
+			return dataKey, nil
+		}
+		errs = multierr.Append(errs, err)
+	}
+	return nil, fmt.Errorf("failed to decrypt the SOPS data key with the age or PGP keys of the file: %w", errs)
+}
+
+// decryptNode decrypts the values of a node, adding them to the MAC in the order SOPS does. Comments aren't
+// part of the MAC and are dropped.
+func (f *sopsFile) decryptNode(node *yaml.Node, path []string) (any, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]any, len(node.Content)/2)
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			childPath := append(append(make([]string, 0, len(path)+1), path...), key)
+			decrypted, err := f.decryptNode(node.Content[i+1], childPath)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = decrypted
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		// The items of sequences are encrypted with the path of the sequence.
+		s := make([]any, 0, len(node.Content))
+		for _, child := range node.Content {
+			decrypted, err := f.decryptNode(child, path)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, decrypted)
+		}
+		return s, nil
+	case yaml.AliasNode:
+		return f.decryptNode(node.Alias, path)
+	case yaml.ScalarNode:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return f.decryptScalar(value, path)
+	default:
+		return nil, fmt.Errorf("unexpected YAML node at %q", strings.Join(path, "."))
+	}
+}
+
+func (f *sopsFile) decryptScalar(value any, path []string) (any, error) {
+	if value == nil {
+		// null values are neither encrypted nor part of the MAC
+		return nil, nil
+	}
+	encrypted := f.isEncrypted(path)
+	if encrypted {
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %T value at %q, expected an encrypted value", value, strings.Join(path, "."))
+		}
+		if v != "" {
+			var err error
+			if value, err = f.decryptString(v, strings.Join(path, ":")+":"); err != nil {
+				return nil, fmt.Errorf("failed to decrypt the value at %q: %w", strings.Join(path, "."), err)
+			}
+		}
+	}
+	if encrypted || !f.metadata.MACOnlyEncrypted {
+		f.mac.Write(macBytes(value))
+	}
+	return value, nil
+}
+
+// macBytes returns the representation of a value in the MAC, as SOPS' ToBytes.
+func macBytes(value any) []byte {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case int:
+		return []byte(strconv.Itoa(v))
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		if v {
+			return []byte("True")
+		}
+		return []byte("False")
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+// verifyMAC compares the MAC of the decrypted values with the MAC of the file, encrypted with its lastmodified
+// timestamp as additional data. Values removed, added or moved without the data key are detected this way.
+func (f *sopsFile) verifyMAC() error {
+	if f.metadata.MessageAuthenticationCode == "" {
+		return errors.New("the SOPS file has no MAC")
+	}
+	lastModified, err := time.Parse(time.RFC3339, f.metadata.LastModified)
+	if err != nil {
+		return fmt.Errorf("invalid SOPS lastmodified timestamp: %w", err)
+	}
+	expected, err := f.decryptString(f.metadata.MessageAuthenticationCode, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt the SOPS MAC: %w", err)
+	}
+	if computed := fmt.Sprintf("%X", f.mac.Sum(nil)); computed != expected {
+		return errors.New("MAC mismatch, the SOPS file was modified without its data key")
+	}
+	return nil
+}
+
+// isEncrypted applies the unencrypted_suffix, encrypted_suffix, unencrypted_regex and encrypted_regex settings,
+// in the order SOPS does.
+func (f *sopsFile) isEncrypted(path []string) bool {
+	encrypted := true
+	if f.metadata.UnencryptedSuffix != "" && anyKey(path, func(key string) bool { return strings.HasSuffix(key, f.metadata.UnencryptedSuffix) }) {
+		encrypted = false
+	}
+	if f.metadata.EncryptedSuffix != "" {
+		encrypted = anyKey(path, func(key string) bool { return strings.HasSuffix(key, f.metadata.EncryptedSuffix) })
+	}
+	if f.unencryptedRegex != nil && anyKey(path, f.unencryptedRegex.MatchString) {
+		encrypted = false
+	}
+	if f.encryptedRegex != nil {
+		encrypted = anyKey(path, f.encryptedRegex.MatchString)
+	}
+	return encrypted
+}
+
+func anyKey(path []string, match func(string) bool) bool {
+	for _, key := range path {
+		if match(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptString decrypts an "ENC[AES256_GCM,data:...,iv:...,tag:...,type:...]" value, authenticating the path of
+// the value as additional data.
+func (f *sopsFile) decryptString(value string, additionalData string) (any, error) {
+	matches := sopsValueRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, errors.New("not a SOPS encrypted value")
+	}
+	var parts [3][]byte
+	for i := range parts {
+		var err error
+		if parts[i], err = base64.StdEncoding.DecodeString(matches[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+	block, err := aes.NewCipher(f.dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, err
+	}
+	switch matches[4] {
+	case "str", "bytes", "comment":
+		return string(plaintext), nil
+	case "int":
+		return strconv.Atoi(string(plaintext))
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(string(plaintext))
+	default:
+		return nil, fmt.Errorf("unknown type %q", matches[4])
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfileconfigsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+	"github.com/signalfx/splunk-otel-collector/internal/configsource/filewatcher"
+)
+
+// keyPathDelim separates the path of the file from the path of the key in the selector.
+const keyPathDelim = "#"
+
+type errBadSelector struct{ error }
+
+type encryptedFileConfigSource struct {
+	*Config
+	keys    *keys
+	watcher filewatcher.FileWatcher
+}
+
+func newConfigSource(config *Config, k *keys) configsource.ConfigSource {
+	return &encryptedFileConfigSource{
+		Config: config,
+		keys:   k,
+	}
+}
+
+func (s *encryptedFileConfigSource) Retrieve(_ context.Context, selector string, _ *confmap.Conf, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	file, keyPath, _ := strings.Cut(selector, keyPathDelim)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := s.decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %q: %w", file, err)
+	}
+	if keyPath != "" {
+		if value, err = traverseToKey(value, keyPath); err != nil {
+			return nil, &errBadSelector{fmt.Errorf("no value at path %q in %q: %w", keyPath, file, err)}
+		}
+	}
+
+	if !s.WatchFiles || watcher == nil {
+		return confmap.NewRetrieved(value)
+	}
+
+	closeFunc, err := s.watcher.WatchFile(file, watcher)
+	if err != nil {
+		return nil, err
+	}
+	return confmap.NewRetrieved(value, confmap.WithRetrievedClose(closeFunc))
+}
+
+// decrypt returns the decrypted content of age and PGP encrypted files, and the decrypted tree of SOPS files.
+func (s *encryptedFileConfigSource) decrypt(data []byte) (any, error) {
+	plaintext, err := s.keys.decrypt(data)
+	if err == nil {
+		return string(plaintext), nil
+	}
+	if !errors.Is(err, errNotEncrypted) {
+		return nil, err
+	}
+
+	tree, metadata, err := parseSOPS(data)
+	if err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return nil, errors.New("unsupported format, the file must be age or PGP encrypted, or a YAML or JSON SOPS file")
+	}
+	return s.keys.decryptSOPS(tree, metadata)
+}
+
+// traverseToKey returns the value at a dot-delimited path of nested maps, the decrypted content of age and PGP
+// encrypted files being parsed as YAML or JSON.
+func traverseToKey(value any, keyPath string) (any, error) {
+	if content, ok := value.(string); ok {
+		if err := yaml.Unmarshal([]byte(content), &value); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range strings.Split(keyPath, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%q is not in a map", key)
+		}
+		if value, ok = m[key]; !ok {
+			return nil, fmt.Errorf("%q not found", key)
+		}
+	}
+	return value, nil
+}
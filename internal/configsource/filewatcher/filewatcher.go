@@ -0,0 +1,91 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filewatcher watches the files retrieved by the file based config sources.
+package filewatcher
+
+import (
+	"context"
+
+	"github.com/fsnotify/fsnotify"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// FileWatcher notifies a confmap.WatcherFunc when the first of the watched files is written to.
+// The zero value is ready to use.
+type FileWatcher struct {
+	watcher      *fsnotify.Watcher
+	watchedFiles map[string]struct{}
+}
+
+// WatchFile adds a file to the watched ones. The first call starts the watch, notifying
+// watcherFunc once, upon the first write or error.
+func (fw *FileWatcher) WatchFile(file string, watcherFunc confmap.WatcherFunc) (confmap.CloseFunc, error) {
+	if _, watched := fw.watchedFiles[file]; watched {
+		// This file is already watched another watch function is not needed.
+		return nil, nil
+	}
+
+	if fw.watcher == nil {
+		// First watcher create a real watch for update function.
+		var err error
+		fw.watcher, err = fsnotify.NewWatcher()
+		if err != nil {
+			return nil, err
+		}
+
+		go func() {
+			for {
+				select {
+				case event, ok := <-fw.watcher.Events:
+					if !ok {
+						return
+					}
+					if event.Op&fsnotify.Write == fsnotify.Write {
+						watcherFunc(&confmap.ChangeEvent{Error: nil})
+						return
+					}
+				case watcherErr, ok := <-fw.watcher.Errors:
+					if !ok {
+						return
+					}
+					watcherFunc(&confmap.ChangeEvent{Error: watcherErr})
+					return
+				}
+			}
+		}()
+	}
+
+	// Now just add the file.
+	if err := fw.watcher.Add(file); err != nil {
+		return nil, err
+	}
+
+	if fw.watchedFiles == nil {
+		fw.watchedFiles = make(map[string]struct{})
+	}
+	fw.watchedFiles[file] = struct{}{}
+
+	return func(ctx context.Context) error {
+		err := fw.watcher.Remove(file)
+		if err != nil {
+			return err
+		}
+		if len(fw.watcher.WatchList()) == 0 {
+			return fw.watcher.Close()
+		}
+		return nil
+	}, nil
+}
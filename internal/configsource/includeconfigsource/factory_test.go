@@ -37,24 +37,21 @@ func TestIncludeConfigSourceFactory_CreateConfigSource(t *testing.T) {
 		{
 			name: "default",
 			expected: &includeConfigSource{
-				Config:       &Config{},
-				watchedFiles: make(map[string]struct{}),
+				Config: &Config{},
 			},
 		},
 		{
 			name:   "delete_files",
 			config: Config{DeleteFiles: true},
 			expected: &includeConfigSource{
-				Config:       &Config{DeleteFiles: true},
-				watchedFiles: make(map[string]struct{}),
+				Config: &Config{DeleteFiles: true},
 			},
 		},
 		{
 			name:   "watch_files",
 			config: Config{WatchFiles: true},
 			expected: &includeConfigSource{
-				Config:       &Config{WatchFiles: true},
-				watchedFiles: make(map[string]struct{}),
+				Config: &Config{WatchFiles: true},
 			},
 		},
 		{
@@ -23,10 +23,10 @@ import (
 	"os"
 	"text/template"
 
-	"github.com/fsnotify/fsnotify"
 	"go.opentelemetry.io/collector/confmap"
 
 	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+	"github.com/signalfx/splunk-otel-collector/internal/configsource/filewatcher"
 )
 
 // Private error types to help with testability.
@@ -37,8 +37,7 @@ type (
 // includeConfigSource implements the configprovider.Session interface.
 type includeConfigSource struct {
 	*Config
-	watcher      *fsnotify.Watcher
-	watchedFiles map[string]struct{}
+	watcher filewatcher.FileWatcher
 }
 
 func newConfigSource(config *Config) (configsource.ConfigSource, error) {
@@ -47,8 +46,7 @@ func newConfigSource(config *Config) (configsource.ConfigSource, error) {
 	}
 
 	return &includeConfigSource{
-		Config:       config,
-		watchedFiles: make(map[string]struct{}),
+		Config: config,
 	}, nil
 }
 
@@ -79,64 +77,9 @@ func (is *includeConfigSource) Retrieve(_ context.Context, selector string, para
 		return confmap.NewRetrieved(buf.String())
 	}
 
-	closeFunc, err := is.watchFile(selector, watcher)
+	closeFunc, err := is.watcher.WatchFile(selector, watcher)
 	if err != nil {
 		return nil, err
 	}
 	return confmap.NewRetrieved(buf.String(), confmap.WithRetrievedClose(closeFunc))
 }
-
-func (is *includeConfigSource) watchFile(file string, watcherFunc confmap.WatcherFunc) (confmap.CloseFunc, error) {
-	if _, watched := is.watchedFiles[file]; watched {
-		// This file is already watched another watch function is not needed.
-		return nil, nil
-	}
-
-	if is.watcher == nil {
-		// First watcher create a real watch for update function.
-		var err error
-		is.watcher, err = fsnotify.NewWatcher()
-		if err != nil {
-			return nil, err
-		}
-
-		go func() {
-			for {
-				select {
-				case event, ok := <-is.watcher.Events:
-					if !ok {
-						return
-					}
-					if event.Op&fsnotify.Write == fsnotify.Write {
-						watcherFunc(&confmap.ChangeEvent{Error: nil})
-						return
-					}
-				case watcherErr, ok := <-is.watcher.Errors:
-					if !ok {
-						return
-					}
-					watcherFunc(&confmap.ChangeEvent{Error: watcherErr})
-					return
-				}
-			}
-		}()
-	}
-
-	// Now just add the file.
-	if err := is.watcher.Add(file); err != nil {
-		return nil, err
-	}
-
-	is.watchedFiles[file] = struct{}{}
-
-	return func(ctx context.Context) error {
-		err := is.watcher.Remove(file)
-		if err != nil {
-			return err
-		}
-		if len(is.watcher.WatchList()) == 0 {
-			return is.watcher.Close()
-		}
-		return nil
-	}, nil
-}
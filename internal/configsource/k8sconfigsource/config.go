@@ -0,0 +1,48 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfigsource
+
+import (
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+const (
+	// AuthTypeServiceAccount uses the service account token and CA mounted in the collector pod.
+	AuthTypeServiceAccount = "serviceAccount"
+	// AuthTypeKubeConfig uses the kubeconfig file found through $KUBECONFIG or at ~/.kube/config.
+	AuthTypeKubeConfig = "kubeConfig"
+
+	// KindSecret resolves selectors from the data of Secrets.
+	KindSecret = "secret"
+	// KindConfigMap resolves selectors from the data of ConfigMaps.
+	KindConfigMap = "configmap"
+)
+
+// Config holds the configuration for the creation of Kubernetes config source objects.
+type Config struct {
+	configsource.SourceSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+
+	// AuthType is how the config source authenticates with the Kubernetes API server,
+	// either "serviceAccount" or "kubeConfig".
+	AuthType string `mapstructure:"auth_type"`
+
+	// Context is the kubeconfig context to use with the "kubeConfig" auth type.
+	// The current context is used if it is empty.
+	Context string `mapstructure:"context"`
+
+	// Kind is the kind of the objects holding the values to be injected in the
+	// configuration, either "secret" or "configmap".
+	Kind string `mapstructure:"kind"`
+}
@@ -0,0 +1,91 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfigsource
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+const (
+	// The "type" of Kubernetes config sources in configuration.
+	typeStr = "k8s"
+)
+
+type (
+	errInvalidAuthType struct{ error }
+	errInvalidKind     struct{ error }
+)
+
+type k8sFactory struct{}
+
+func (f *k8sFactory) Type() component.Type {
+	return typeStr
+}
+
+func (f *k8sFactory) CreateDefaultConfig() configsource.Settings {
+	return &Config{
+		SourceSettings: configsource.NewSourceSettings(component.MustNewID(typeStr)),
+		AuthType:       AuthTypeServiceAccount,
+		Kind:           KindSecret,
+	}
+}
+
+func (f *k8sFactory) CreateConfigSource(_ context.Context, settings configsource.Settings, logger *zap.Logger) (configsource.ConfigSource, error) {
+	k8sCfg := settings.(*Config)
+
+	if k8sCfg.Kind != KindSecret && k8sCfg.Kind != KindConfigMap {
+		return nil, &errInvalidKind{fmt.Errorf("invalid kind %q, must be %q or %q", k8sCfg.Kind, KindSecret, KindConfigMap)}
+	}
+
+	client, err := newClient(k8sCfg)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigSource(client, k8sCfg.Kind, logger), nil
+}
+
+// NewFactory creates a factory for Kubernetes ConfigSource objects.
+func NewFactory() configsource.Factory {
+	return &k8sFactory{}
+}
+
+func newClient(cfg *Config) (kubernetes.Interface, error) {
+	var restConfig *rest.Config
+	var err error
+	switch cfg.AuthType {
+	case AuthTypeServiceAccount:
+		restConfig, err = rest.InClusterConfig()
+	case AuthTypeKubeConfig:
+		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(),
+			&clientcmd.ConfigOverrides{CurrentContext: cfg.Context},
+		).ClientConfig()
+	default:
+		return nil, &errInvalidAuthType{fmt.Errorf("invalid auth_type %q, must be %q or %q", cfg.AuthType, AuthTypeServiceAccount, AuthTypeKubeConfig)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Kubernetes API configuration: %w", err)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
@@ -0,0 +1,69 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfigsource
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+func TestK8sFactory_CreateConfigSource(t *testing.T) {
+	t.Setenv("KUBECONFIG", path.Join("testdata", "kubeconfig.yaml"))
+	// outside of a cluster, the service account auth type fails
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	factory := NewFactory()
+	assert.Equal(t, component.MustNewType("k8s"), factory.Type())
+	tests := []struct {
+		config  *Config
+		wantErr error
+		name    string
+	}{
+		{
+			name:    "invalid_kind",
+			config:  &Config{AuthType: AuthTypeKubeConfig, Kind: "pod"},
+			wantErr: &errInvalidKind{},
+		},
+		{
+			name:    "invalid_auth_type",
+			config:  &Config{AuthType: "none", Kind: KindSecret},
+			wantErr: &errInvalidAuthType{},
+		},
+		{
+			name:   "kube_config",
+			config: &Config{AuthType: AuthTypeKubeConfig, Kind: KindConfigMap},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := factory.CreateConfigSource(context.Background(), tt.config, zap.NewNop())
+			require.IsType(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.NotNil(t, actual)
+			} else {
+				assert.Nil(t, actual)
+			}
+		})
+	}
+
+	_, err := factory.CreateConfigSource(context.Background(), factory.CreateDefaultConfig(), zap.NewNop())
+	require.ErrorContains(t, err, "failed to load the Kubernetes API configuration")
+}
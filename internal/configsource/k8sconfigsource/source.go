@@ -0,0 +1,178 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfigsource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+)
+
+type errInvalidSelector struct{ error }
+
+// k8sConfigSource implements the configsource.ConfigSource interface.
+type k8sConfigSource struct {
+	logger *zap.Logger
+	client kubernetes.Interface
+	kind   string
+}
+
+func newConfigSource(client kubernetes.Interface, kind string, logger *zap.Logger) configsource.ConfigSource {
+	return &k8sConfigSource{
+		logger: logger,
+		client: client,
+		kind:   kind,
+	}
+}
+
+// selector identifies a key in the data of a Secret or ConfigMap, in the "namespace/name/key" format.
+type selector struct {
+	namespace string
+	name      string
+	key       string
+}
+
+func parseSelector(s string) (selector, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return selector{}, &errInvalidSelector{fmt.Errorf("invalid selector %q, must be in the namespace/name/key format", s)}
+	}
+	return selector{namespace: parts[0], name: parts[1], key: parts[2]}, nil
+}
+
+func (s *k8sConfigSource) Retrieve(ctx context.Context, selectorStr string, _ *confmap.Conf, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	sel, err := parseSelector(selectorStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj any
+	switch s.kind {
+	case KindConfigMap:
+		obj, err = s.client.CoreV1().ConfigMaps(sel.namespace).Get(ctx, sel.name, metav1.GetOptions{})
+	default:
+		obj, err = s.client.CoreV1().Secrets(sel.namespace).Get(ctx, sel.name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %w", s.kind, sel.namespace, sel.name, err)
+	}
+	value, ok := lookup(obj, sel.key)
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in %s %s/%s", sel.key, s.kind, sel.namespace, sel.name)
+	}
+
+	if watcher == nil {
+		return confmap.NewRetrieved(value)
+	}
+	closeFunc, err := s.newWatcher(sel, value, watcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s %s/%s: %w", s.kind, sel.namespace, sel.name, err)
+	}
+	return confmap.NewRetrieved(value, confmap.WithRetrievedClose(closeFunc))
+}
+
+// lookup returns the value of a key in the data of a Secret or ConfigMap.
+func lookup(obj any, key string) (string, bool) {
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		if v, ok := o.Data[key]; ok {
+			return string(v), true
+		}
+	case *corev1.ConfigMap:
+		if v, ok := o.Data[key]; ok {
+			return v, true
+		}
+		if v, ok := o.BinaryData[key]; ok {
+			return string(v), true
+		}
+	}
+	return "", false
+}
+
+// newWatcher starts an informer on the object of the selector, notifying the watcher once the value of the key
+// differs from the retrieved one, or the object is deleted.
+func (s *k8sConfigSource) newWatcher(sel selector, value string, watcherFunc confmap.WatcherFunc) (confmap.CloseFunc, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(s.client, 0,
+		informers.WithNamespace(sel.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", sel.name).String()
+		}))
+	var informer cache.SharedIndexInformer
+	switch s.kind {
+	case KindConfigMap:
+		informer = factory.Core().V1().ConfigMaps().Informer()
+	default:
+		informer = factory.Core().V1().Secrets().Informer()
+	}
+
+	var once sync.Once
+	notify := func() {
+		once.Do(func() {
+			s.logger.Debug("value changed", zap.String("kind", s.kind), zap.String("namespace", sel.namespace), zap.String("name", sel.name), zap.String("key", sel.key))
+			watcherFunc(&confmap.ChangeEvent{})
+		})
+	}
+	// Not every API server implementation honors the field selector, the name is checked again.
+	isWatched := func(obj any) bool {
+		m, err := meta.Accessor(obj)
+		return err == nil && m.GetNamespace() == sel.namespace && m.GetName() == sel.name
+	}
+	onChange := func(obj any) {
+		if !isWatched(obj) {
+			return
+		}
+		if v, ok := lookup(obj, sel.key); !ok || v != value {
+			notify()
+		}
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: onChange,
+		UpdateFunc: func(_, newObj any) {
+			onChange(newObj)
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if isWatched(obj) {
+				notify()
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stopCh := make(chan struct{})
+	factory.Start(stopCh)
+	return func(context.Context) error {
+		close(stopCh)
+		factory.Shutdown()
+		return nil
+	}, nil
+}
@@ -0,0 +1,166 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfigsource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func newSecret(namespace, name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestSourceRetrieve(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newSecret("monitoring", "splunk", map[string]string{"token": "t0k3n"}),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Name: "settings"},
+			Data:       map[string]string{"endpoint": "https://ingest"},
+			BinaryData: map[string][]byte{"ca": []byte("cert")},
+		},
+	)
+	secrets := newConfigSource(client, KindSecret, zap.NewNop())
+	configMaps := newConfigSource(client, KindConfigMap, zap.NewNop())
+
+	tests := []struct {
+		source   *k8sConfigSource
+		expected any
+		name     string
+		selector string
+		wantErr  string
+	}{
+		{name: "secret", source: secrets.(*k8sConfigSource), selector: "monitoring/splunk/token", expected: "t0k3n"},
+		{name: "configmap", source: configMaps.(*k8sConfigSource), selector: "monitoring/settings/endpoint", expected: "https://ingest"},
+		{name: "configmap_binary", source: configMaps.(*k8sConfigSource), selector: "monitoring/settings/ca", expected: "cert"},
+		{name: "invalid_selector", source: secrets.(*k8sConfigSource), selector: "splunk/token", wantErr: `invalid selector "splunk/token"`},
+		{name: "missing_object", source: secrets.(*k8sConfigSource), selector: "default/splunk/token", wantErr: "failed to get secret default/splunk"},
+		{name: "missing_key", source: secrets.(*k8sConfigSource), selector: "monitoring/splunk/password", wantErr: `key "password" not found in secret monitoring/splunk`},
+		{name: "wrong_kind", source: configMaps.(*k8sConfigSource), selector: "monitoring/splunk/token", wantErr: "failed to get configmap monitoring/splunk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retrieved, err := tt.source.Retrieve(context.Background(), tt.selector, nil, nil)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, retrieved)
+				return
+			}
+			require.NoError(t, err)
+			value, err := retrieved.AsRaw()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+			assert.NoError(t, retrieved.Close(context.Background()))
+		})
+	}
+}
+
+func TestSourceWatcher(t *testing.T) {
+	tests := []struct {
+		update func(t *testing.T, secrets typedcorev1.SecretInterface)
+		name   string
+		notify bool
+	}{
+		{
+			name: "value_updated",
+			update: func(t *testing.T, secrets typedcorev1.SecretInterface) {
+				_, err := secrets.Update(context.Background(), newSecret("monitoring", "splunk", map[string]string{"token": "n3w"}), metav1.UpdateOptions{})
+				require.NoError(t, err)
+			},
+			notify: true,
+		},
+		{
+			name: "key_removed",
+			update: func(t *testing.T, secrets typedcorev1.SecretInterface) {
+				_, err := secrets.Update(context.Background(), newSecret("monitoring", "splunk", map[string]string{}), metav1.UpdateOptions{})
+				require.NoError(t, err)
+			},
+			notify: true,
+		},
+		{
+			name: "deleted",
+			update: func(t *testing.T, secrets typedcorev1.SecretInterface) {
+				require.NoError(t, secrets.Delete(context.Background(), "splunk", metav1.DeleteOptions{}))
+			},
+			notify: true,
+		},
+		{
+			name: "other_key_updated",
+			update: func(t *testing.T, secrets typedcorev1.SecretInterface) {
+				_, err := secrets.Update(context.Background(), newSecret("monitoring", "splunk", map[string]string{"token": "t0k3n", "other": "value"}), metav1.UpdateOptions{})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "other_secret_created",
+			update: func(t *testing.T, secrets typedcorev1.SecretInterface) {
+				_, err := secrets.Create(context.Background(), newSecret("monitoring", "other", map[string]string{"token": "n3w"}), metav1.CreateOptions{})
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(newSecret("monitoring", "splunk", map[string]string{"token": "t0k3n"}))
+			source := newConfigSource(client, KindSecret, zap.NewNop())
+
+			events := make(chan *confmap.ChangeEvent, 1)
+			retrieved, err := source.Retrieve(context.Background(), "monitoring/splunk/token", nil, func(event *confmap.ChangeEvent) {
+				events <- event
+			})
+			require.NoError(t, err)
+			value, err := retrieved.AsRaw()
+			require.NoError(t, err)
+			require.Equal(t, "t0k3n", value)
+
+			// let the informer start watching before updating
+			time.Sleep(100 * time.Millisecond)
+			tt.update(t, client.CoreV1().Secrets("monitoring"))
+
+			if tt.notify {
+				select {
+				case event := <-events:
+					require.NoError(t, event.Error)
+				case <-time.After(5 * time.Second):
+					t.Fatal("expected the watcher to be notified")
+				}
+			} else {
+				select {
+				case <-events:
+					t.Fatal("unexpected watcher notification")
+				case <-time.After(200 * time.Millisecond):
+				}
+			}
+			require.NoError(t, retrieved.Close(context.Background()))
+		})
+	}
+}
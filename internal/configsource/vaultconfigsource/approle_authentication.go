@@ -0,0 +1,59 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vaultconfigsource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type AppRoleAuthentication struct {
+	// RoleID is the role ID of the AppRole to login with. It is required.
+	RoleID *string `mapstructure:"role_id"`
+	// SecretID is the secret ID issued against the AppRole. It is not needed if
+	// the role does not require secret IDs.
+	SecretID *string `mapstructure:"secret_id"`
+	// SecretIDFile is the path of a file containing the secret ID. The file is
+	// read on every login so the secret ID can be rotated on disk.
+	SecretIDFile *string `mapstructure:"secret_id_file"`
+	// Mount is the path where the AppRole auth method is mounted. The default value is "approle".
+	Mount *string `mapstructure:"mount"`
+}
+
+func (approle *AppRoleAuthentication) Login(client *api.Client) (*api.Secret, error) {
+	mount := "approle"
+	if approle.Mount != nil {
+		mount = *approle.Mount
+	}
+
+	data := map[string]any{
+		"role_id": *approle.RoleID,
+	}
+	switch {
+	case approle.SecretID != nil:
+		data["secret_id"] = *approle.SecretID
+	case approle.SecretIDFile != nil:
+		secretID, err := os.ReadFile(*approle.SecretIDFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read AppRole secret ID: %w", err)
+		}
+		data["secret_id"] = strings.TrimSpace(string(secretID))
+	}
+
+	return client.Logical().Write(fmt.Sprintf("auth/%s/login", mount), data)
+}
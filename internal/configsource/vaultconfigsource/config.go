@@ -29,7 +29,9 @@ type Config struct {
 	// Endpoint is the address of the Vault server, typically it is set via the
 	// VAULT_ADDR environment variable for the Vault CLI.
 	Endpoint string `mapstructure:"endpoint"`
-	// Path is the Vault path where the secret to be retrieved is located.
+	// Path is the Vault path where the secret to be retrieved is located. It is
+	// used for selectors that don't carry their own path, ie.: "data.password"
+	// instead of "secret/data/app#password".
 	Path string `mapstructure:"path"`
 	// PollInterval is the interval in which the config source will check for
 	// changes on the data on the given Vault path. This is only used for
@@ -48,4 +50,10 @@ type Authentication struct {
 	// GCPAuthentication holds the authentication options for GCP. The options
 	// are the same as the vault CLI tool, see https://github.com/hashicorp/vault-plugin-auth-gcp/blob/e1f6784b379d277038ca0661606aa8d23791e392/plugin/cli.go#L120.
 	GCPAuthentication *GCPAuthentication `mapstructure:"gcp"`
+	// AppRoleAuthentication holds the authentication options for the AppRole
+	// auth method, see https://developer.hashicorp.com/vault/docs/auth/approle.
+	AppRoleAuthentication *AppRoleAuthentication `mapstructure:"approle"`
+	// KubernetesAuthentication holds the authentication options for the Kubernetes
+	// auth method, see https://developer.hashicorp.com/vault/docs/auth/kubernetes.
+	KubernetesAuthentication *KubernetesAuthentication `mapstructure:"kubernetes"`
 }
@@ -63,6 +63,14 @@ func TestVaultLoadConfig(t *testing.T) {
 				Token: &otherToken,
 			},
 		},
+		"vault/without_path": &Config{
+			SourceSettings: configsource.NewSourceSettings(component.MustNewIDWithName(typeStr, "without_path")),
+			Endpoint:       "http://localhost:8200",
+			PollInterval:   1 * time.Minute,
+			Authentication: &Authentication{
+				Token: &devToken,
+			},
+		},
 	}
 
 	require.Equal(t, expectedSettings, actualSettings)
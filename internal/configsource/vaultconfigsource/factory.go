@@ -41,8 +41,10 @@ type (
 	errInvalidEndpoint         struct{ error }
 	errMissingAuthentication   struct{ error }
 	errMissingEndpoint         struct{ error }
-	errMissingPath             struct{ error }
+	errMissingRole             struct{ error }
+	errMissingRoleID           struct{ error }
 	errMultipleAuthMethods     struct{ error }
+	errMultipleSecretIDs       struct{ error }
 	errNonPositivePollInterval struct{ error }
 )
 
@@ -70,10 +72,6 @@ func (v *vaultFactory) CreateConfigSource(_ context.Context, settings configsour
 		return nil, &errInvalidEndpoint{fmt.Errorf("invalid endpoint %q: %w", vaultCfg.Endpoint, err)}
 	}
 
-	if vaultCfg.Path == "" {
-		return nil, &errMissingPath{errors.New("cannot connect to vault with an empty path")}
-	}
-
 	if err := validateAuth(vaultCfg.Authentication); err != nil {
 		return nil, err
 	}
@@ -111,6 +109,14 @@ func validateAuth(auth *Authentication) error {
 		countMethods++
 	}
 
+	if auth.AppRoleAuthentication != nil {
+		countMethods++
+	}
+
+	if auth.KubernetesAuthentication != nil {
+		countMethods++
+	}
+
 	if countMethods == 0 {
 		return &errEmptyAuth{errors.New("auth cannot be empty, exactly one method must be used")}
 	}
@@ -119,5 +125,18 @@ func validateAuth(auth *Authentication) error {
 		return &errMultipleAuthMethods{errors.New("multiple auth methods were set, use only one")}
 	}
 
+	if approle := auth.AppRoleAuthentication; approle != nil {
+		if approle.RoleID == nil || *approle.RoleID == "" {
+			return &errMissingRoleID{errors.New("approle auth requires a role_id")}
+		}
+		if approle.SecretID != nil && approle.SecretIDFile != nil {
+			return &errMultipleSecretIDs{errors.New("approle auth accepts either secret_id or secret_id_file, not both")}
+		}
+	}
+
+	if k8s := auth.KubernetesAuthentication; k8s != nil && (k8s.Role == nil || *k8s.Role == "") {
+		return &errMissingRole{errors.New("kubernetes auth requires a role")}
+	}
+
 	return nil
 }
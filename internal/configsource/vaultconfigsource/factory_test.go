@@ -28,6 +28,8 @@ import (
 
 func TestVaultFactory_CreateConfigSource(t *testing.T) {
 	emptyStr := ""
+	roleID := "role-id"
+	secretID := "secret-id"
 	factory := NewFactory()
 	assert.Equal(t, component.MustNewType("vault"), factory.Type())
 	tests := []struct {
@@ -70,9 +72,10 @@ func TestVaultFactory_CreateConfigSource(t *testing.T) {
 				Endpoint: "http://localhost:8200",
 				Path:     "some/path",
 				Authentication: &Authentication{
-					Token:             &tokenStr,
-					IAMAuthentication: &IAMAuthentication{},
-					GCPAuthentication: &GCPAuthentication{},
+					Token:                    &tokenStr,
+					IAMAuthentication:        &IAMAuthentication{},
+					GCPAuthentication:        &GCPAuthentication{},
+					KubernetesAuthentication: &KubernetesAuthentication{},
 				},
 			},
 			wantErr: &errMultipleAuthMethods{},
@@ -89,14 +92,38 @@ func TestVaultFactory_CreateConfigSource(t *testing.T) {
 			wantErr: &errEmptyToken{},
 		},
 		{
-			name: "missing_path",
+			name: "approle_missing_role_id",
 			config: &Config{
 				Endpoint: "http://localhost:8200",
 				Authentication: &Authentication{
-					Token: &tokenStr,
+					AppRoleAuthentication: &AppRoleAuthentication{},
+				},
+			},
+			wantErr: &errMissingRoleID{},
+		},
+		{
+			name: "approle_multiple_secret_ids",
+			config: &Config{
+				Endpoint: "http://localhost:8200",
+				Authentication: &Authentication{
+					AppRoleAuthentication: &AppRoleAuthentication{
+						RoleID:       &roleID,
+						SecretID:     &secretID,
+						SecretIDFile: &secretID,
+					},
+				},
+			},
+			wantErr: &errMultipleSecretIDs{},
+		},
+		{
+			name: "kubernetes_missing_role",
+			config: &Config{
+				Endpoint: "http://localhost:8200",
+				Authentication: &Authentication{
+					KubernetesAuthentication: &KubernetesAuthentication{Role: &emptyStr},
 				},
 			},
-			wantErr: &errMissingPath{},
+			wantErr: &errMissingRole{},
 		},
 		{
 			name: "invalid_poll_interval",
@@ -109,6 +136,16 @@ func TestVaultFactory_CreateConfigSource(t *testing.T) {
 			},
 			wantErr: &errNonPositivePollInterval{},
 		},
+		{
+			name: "success_without_path",
+			config: &Config{
+				Endpoint: "http://localhost:8200",
+				Authentication: &Authentication{
+					Token: &tokenStr,
+				},
+				PollInterval: 2 * time.Minute,
+			},
+		},
 		{
 			name: "success",
 			config: &Config{
@@ -49,7 +49,7 @@ type GCPAuthentication struct {
 	Project *string `mapstructure:"project"`
 }
 
-func (gcp *GCPAuthentication) Token(client *api.Client) (string, error) {
+func (gcp *GCPAuthentication) Login(client *api.Client) (*api.Secret, error) {
 	data := map[string]string{}
 
 	if gcp.Mount != nil {
@@ -72,9 +72,5 @@ func (gcp *GCPAuthentication) Token(client *api.Client) (string, error) {
 	}
 
 	h := gcpauth.CLIHandler{}
-	secret, err := h.Auth(client, data)
-	if err != nil {
-		return "", err
-	}
-	return secret.Auth.ClientToken, nil
+	return h.Auth(client, data)
 }
@@ -37,7 +37,7 @@ type IAMAuthentication struct {
 	Role *string `mapstructure:"role"`
 }
 
-func (iam *IAMAuthentication) Token(client *api.Client) (string, error) {
+func (iam *IAMAuthentication) Login(client *api.Client) (*api.Secret, error) {
 	data := map[string]string{}
 
 	// Have to only set these if provided to not confuse the Auth method below
@@ -61,9 +61,5 @@ func (iam *IAMAuthentication) Token(client *api.Client) (string, error) {
 	}
 
 	h := aws.CLIHandler{}
-	secret, err := h.Auth(client, data)
-	if err != nil {
-		return "", err
-	}
-	return secret.Auth.ClientToken, nil
+	return h.Auth(client, data)
 }
@@ -0,0 +1,57 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vaultconfigsource
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const defaultServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+type KubernetesAuthentication struct {
+	// Role is the name of the Vault role bound to the pod service account. It is required.
+	Role *string `mapstructure:"role"`
+	// ServiceAccountTokenFile is the path of the service account token used to login.
+	// The file is read on every login so projected tokens can be rotated. Defaults to
+	// "/var/run/secrets/kubernetes.io/serviceaccount/token".
+	ServiceAccountTokenFile *string `mapstructure:"service_account_token_file"`
+	// Mount is the path where the Kubernetes auth method is mounted. The default value is "kubernetes".
+	Mount *string `mapstructure:"mount"`
+}
+
+func (k8s *KubernetesAuthentication) Login(client *api.Client) (*api.Secret, error) {
+	mount := "kubernetes"
+	if k8s.Mount != nil {
+		mount = *k8s.Mount
+	}
+
+	tokenFile := defaultServiceAccountTokenFile
+	if k8s.ServiceAccountTokenFile != nil {
+		tokenFile = *k8s.ServiceAccountTokenFile
+	}
+	jwt, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	return client.Logical().Write(fmt.Sprintf("auth/%s/login", mount), map[string]any{
+		"role": *k8s.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+}
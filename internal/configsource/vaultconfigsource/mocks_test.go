@@ -0,0 +1,146 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vaultconfigsource
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVault is a minimal stand-in for a Vault server with a K/V V2 secret engine
+// mounted at "secret" and auth methods accepting any credentials.
+type fakeVault struct {
+	secrets map[string][]map[string]any
+	issued  map[string]time.Time
+	logins  []map[string]any
+	tokens  []string
+	mutex   sync.Mutex
+	renews  int
+	// tokenTTL is the lease duration, in seconds, of the tokens issued by logins.
+	// Tokens are renewable when it is positive, renewals don't extend them beyond
+	// the initial lease duration, like a max TTL.
+	tokenTTL int
+}
+
+func newFakeVault(t *testing.T, secrets map[string][]map[string]any) (*fakeVault, *httptest.Server) {
+	vault := &fakeVault{secrets: secrets, issued: map[string]time.Time{}}
+	server := httptest.NewServer(http.HandlerFunc(vault.handle))
+	t.Cleanup(server.Close)
+	return vault, server
+}
+
+func (f *fakeVault) put(path string, data map[string]any) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.secrets[path] = append(f.secrets[path], data)
+}
+
+func (f *fakeVault) loginRequests() []map[string]any {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]map[string]any{}, f.logins...)
+}
+
+func (f *fakeVault) renewRequests() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.renews
+}
+
+// lastToken returns the token of the last secret read.
+func (f *fakeVault) lastToken() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.tokens) == 0 {
+		return ""
+	}
+	return f.tokens[len(f.tokens)-1]
+}
+
+func (f *fakeVault) handle(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case strings.HasPrefix(path, "auth/") && strings.HasSuffix(path, "/login"):
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body["mount"] = strings.TrimSuffix(strings.TrimPrefix(path, "auth/"), "/login")
+		f.logins = append(f.logins, body)
+		token := "token-" + strconv.Itoa(len(f.logins))
+		f.issued[token] = time.Now()
+		f.writeAuth(w, token, f.tokenTTL)
+	case path == "auth/token/renew-self":
+		f.renews++
+		token := r.Header.Get("X-Vault-Token")
+		remaining := time.Duration(f.tokenTTL)*time.Second - time.Since(f.issued[token])
+		f.writeAuth(w, token, int(max(remaining, 0)/time.Second))
+	case strings.HasPrefix(path, "secret/data/"):
+		f.tokens = append(f.tokens, r.Header.Get("X-Vault-Token"))
+		versions := f.secrets[strings.TrimPrefix(path, "secret/data/")]
+		version := len(versions)
+		if v := r.URL.Query().Get("version"); v != "" {
+			version, _ = strconv.Atoi(v)
+		}
+		if version < 1 || version > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		writeJSON(w, map[string]any{
+			"data": map[string]any{
+				"data":     versions[version-1],
+				"metadata": map[string]any{"created_time": versionTime(version), "version": version},
+			},
+		})
+	case strings.HasPrefix(path, "secret/metadata/"):
+		version := len(f.secrets[strings.TrimPrefix(path, "secret/metadata/")])
+		writeJSON(w, map[string]any{
+			"data": map[string]any{"updated_time": versionTime(version), "current_version": version},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+	}
+}
+
+func (f *fakeVault) writeAuth(w http.ResponseWriter, token string, ttl int) {
+	writeJSON(w, map[string]any{
+		"auth": map[string]any{
+			"client_token":   token,
+			"renewable":      f.tokenTTL > 0,
+			"lease_duration": ttl,
+		},
+	})
+}
+
+func versionTime(version int) string {
+	return time.Date(2024, 1, 1, 0, 0, version, 0, time.UTC).Format(time.RFC3339Nano)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
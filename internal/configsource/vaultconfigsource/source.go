@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,21 +35,33 @@ var errInvalidPollInterval = errors.New("poll interval must be greater than zero
 
 // Error wrapper types to help with testability
 type (
-	errClientRead    struct{ error }
-	errNilSecret     struct{ error }
-	errNilSecretData struct{ error }
-	errBadSelector   struct{ error }
+	errClientRead     struct{ error }
+	errNilSecret      struct{ error }
+	errNilSecretData  struct{ error }
+	errBadSelector    struct{ error }
+	errMissingPath    struct{ error }
+	errInvalidVersion struct{ error }
 )
 
+// pathKeySeparator separates the Vault path from the key on selectors, ie.:
+// "secret/data/app#password".
+const pathKeySeparator = "#"
+
 // vaultConfigSource implements the configprovider.Session interface.
 type vaultConfigSource struct {
-	logger *zap.Logger
-	client *api.Client
-	secret *api.Secret
+	logger     *zap.Logger
+	client     *api.Client
+	auth       Authentication
+	authSecret *api.Secret
+
+	// secrets caches the secrets already read, keyed by path and version.
+	secrets map[string]*api.Secret
 
 	path string
 
 	pollInterval time.Duration
+
+	renewingToken bool
 }
 
 func newConfigSource(cfg *Config, logger *zap.Logger) (configsource.ConfigSource, error) {
@@ -61,12 +74,12 @@ func newConfigSource(cfg *Config, logger *zap.Logger) (configsource.ConfigSource
 		return nil, err
 	}
 
-	token, err := getClientToken(client, *cfg.Authentication)
+	authSecret, err := login(client, *cfg.Authentication)
 	if err != nil {
 		return nil, err
 	}
 
-	client.SetToken(token)
+	client.SetToken(authSecret.Auth.ClientToken)
 
 	if cfg.PollInterval <= 0 {
 		return nil, errInvalidPollInterval
@@ -75,38 +88,76 @@ func newConfigSource(cfg *Config, logger *zap.Logger) (configsource.ConfigSource
 	return &vaultConfigSource{
 		logger:       logger,
 		client:       client,
+		auth:         *cfg.Authentication,
+		authSecret:   authSecret,
+		secrets:      map[string]*api.Secret{},
 		path:         cfg.Path,
 		pollInterval: cfg.PollInterval,
 	}, nil
 }
 
-func (v *vaultConfigSource) Retrieve(_ context.Context, selector string, _ *confmap.Conf, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
-	// By default assume that watcher is not supported. The exception will be the first
-	// value read from the vault secret.
-	var closeFunc confmap.CloseFunc
+func (v *vaultConfigSource) Retrieve(_ context.Context, selector string, params *confmap.Conf, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	path, key, err := v.parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := parseVersion(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var closeFuncs []confmap.CloseFunc
 
-	// The keys come all from the same secret so creating a watcher only for the first is fine.
-	if v.secret == nil {
-		if err := v.readSecret(); err != nil {
+	// The keys of a secret come all from the same read so creating a watcher only for
+	// the first key retrieved from each secret is fine. By default assume that watcher
+	// is not supported.
+	secretID := path
+	if version > 0 {
+		secretID = fmt.Sprintf("%s?version=%d", path, version)
+	}
+	secret, cached := v.secrets[secretID]
+	if !cached {
+		if secret, err = v.readSecret(path, version); err != nil {
 			return nil, err
 		}
+	}
+
+	value := lookupKey(secret, key)
+	if value == nil {
+		return nil, &errBadSelector{fmt.Errorf("no value at path %q for key %q", path, key)}
+	}
 
-		if watcher != nil {
+	// The secret is only cached once a key was successfully retrieved from it, so the
+	// watcher is built for a value that is actually in use.
+	if !cached {
+		v.secrets[secretID] = secret
+
+		// A pinned version of a K/V V2 secret never changes, there is nothing to watch.
+		if watcher != nil && version == 0 {
 			doneCh := make(chan struct{})
-			if err := v.buildWatcherFn(watcher, doneCh); err != nil {
+			if err = v.buildWatcherFn(path, secret, watcher, doneCh); err != nil {
 				return nil, err
 			}
 
-			closeFunc = func(ctx context.Context) error {
+			closeFuncs = append(closeFuncs, func(ctx context.Context) error {
 				close(doneCh)
 				return nil
-			}
+			})
 		}
 	}
 
-	value := traverseToKey(v.secret.Data, selector)
-	if value == nil {
-		return nil, &errBadSelector{fmt.Errorf("no value at path %q for key %q", v.path, selector)}
+	// Tokens obtained from an auth method are kept valid for as long as the values
+	// retrieved with them are in use.
+	if !v.renewingToken && v.authSecret.Auth.Renewable {
+		doneCh := make(chan struct{})
+		v.renewToken(watcher, doneCh)
+		v.renewingToken = true
+
+		closeFuncs = append(closeFuncs, func(ctx context.Context) error {
+			close(doneCh)
+			return nil
+		})
 	}
 
 	// Work around the limitation of the confmap.NewRetrieved that does not accept json.Number.
@@ -120,48 +171,86 @@ func (v *vaultConfigSource) Retrieve(_ context.Context, selector string, _ *conf
 		value = val.String()
 	}
 
-	return confmap.NewRetrieved(value, confmap.WithRetrievedClose(closeFunc))
+	return confmap.NewRetrieved(value, confmap.WithRetrievedClose(configsource.MergeCloseFuncs(closeFuncs)))
 }
 
-// readSecret reads the secret from the vaultConfigSource path and if successful
-// it stores the secret on the vaultConfigSource secret field.
-func (v *vaultConfigSource) readSecret() error {
-	secret, err := v.client.Logical().Read(v.path)
+// parseSelector splits the selector into the Vault path and the key of the value to
+// be retrieved. Selectors without a path use the one from the configuration.
+func (v *vaultConfigSource) parseSelector(selector string) (path, key string, err error) {
+	path, key, found := strings.Cut(selector, pathKeySeparator)
+	if !found {
+		path, key = v.path, selector
+	}
+
+	if path == "" {
+		return "", "", &errMissingPath{fmt.Errorf("no vault path for selector %q, either set path on the config source or use the format <path>%s<key>", selector, pathKeySeparator)}
+	}
+	if key == "" {
+		return "", "", &errBadSelector{fmt.Errorf("no key for selector %q", selector)}
+	}
+
+	return path, key, nil
+}
+
+// parseVersion returns the K/V V2 version set via the "version" parameter, zero if
+// the latest version should be used.
+func parseVersion(params *confmap.Conf) (int, error) {
+	if params == nil || !params.IsSet("version") {
+		return 0, nil
+	}
+
+	raw := params.Get("version")
+	version, err := strconv.Atoi(fmt.Sprint(raw))
+	if err != nil || version <= 0 {
+		return 0, &errInvalidVersion{fmt.Errorf("version must be a positive integer, got %v", raw)}
+	}
+
+	return version, nil
+}
+
+// readSecret reads the secret at the given path, and version if greater than zero,
+// returning an error if no secret data is found.
+func (v *vaultConfigSource) readSecret(path string, version int) (*api.Secret, error) {
+	var data map[string][]string
+	if version > 0 {
+		data = map[string][]string{"version": {strconv.Itoa(version)}}
+	}
+
+	secret, err := v.client.Logical().ReadWithData(path, data)
 	if err != nil {
-		return &errClientRead{err}
+		return nil, &errClientRead{err}
 	}
 
 	// Invalid path does not return error but a nil secret.
 	if secret == nil {
-		return &errNilSecret{fmt.Errorf("no secret found at %q", v.path)}
+		return nil, &errNilSecret{fmt.Errorf("no secret found at %q", path)}
 	}
 
 	// Incorrect path for v2 return nil data and warnings.
 	if secret.Data == nil {
-		return &errNilSecretData{fmt.Errorf("no data at %q warnings: %v", v.path, secret.Warnings)}
+		return nil, &errNilSecretData{fmt.Errorf("no data at %q warnings: %v", path, secret.Warnings)}
 	}
 
-	v.secret = secret
-	return nil
+	return secret, nil
 }
 
-func (v *vaultConfigSource) buildWatcherFn(watcher confmap.WatcherFunc, doneCh chan struct{}) error {
+func (v *vaultConfigSource) buildWatcherFn(path string, secret *api.Secret, watcher confmap.WatcherFunc, doneCh chan struct{}) error {
 	switch {
-	case v.secret.Renewable:
+	case secret.Renewable:
 		// Dynamic secret supporting renewal.
-		return v.buildLifetimeWatcher(watcher, doneCh)
-	case v.secret.LeaseDuration > 0:
+		return v.buildLifetimeWatcher(path, secret, watcher, doneCh)
+	case secret.LeaseDuration > 0:
 		// Version 1 lease: re-fetch it periodically.
-		return v.buildV1LeaseWatcher(watcher, doneCh)
+		return v.buildV1LeaseWatcher(secret, watcher, doneCh)
 	default:
 		// Not a dynamic secret the best that can be done is polling.
-		return v.buildPollingWatcher(watcher, doneCh)
+		return v.buildPollingWatcher(path, secret, watcher, doneCh)
 	}
 }
 
-func (v *vaultConfigSource) buildLifetimeWatcher(watcher confmap.WatcherFunc, doneCh chan struct{}) error {
+func (v *vaultConfigSource) buildLifetimeWatcher(path string, secret *api.Secret, watcher confmap.WatcherFunc, doneCh chan struct{}) error {
 	vaultWatcher, err := v.client.NewLifetimeWatcher(&api.RenewerInput{
-		Secret: v.secret,
+		Secret: secret,
 	})
 	if err != nil {
 		return err
@@ -174,7 +263,7 @@ func (v *vaultConfigSource) buildLifetimeWatcher(watcher confmap.WatcherFunc, do
 		for {
 			select {
 			case <-vaultWatcher.RenewCh():
-				v.logger.Debug("vault secret renewed", zap.String("path", v.path))
+				v.logger.Debug("vault secret renewed", zap.String("path", path))
 			case err := <-vaultWatcher.DoneCh():
 				// Renewal stopped, error or not the client needs to re-fetch the configuration.
 				watcher(&confmap.ChangeEvent{Error: err})
@@ -192,11 +281,11 @@ func (v *vaultConfigSource) buildLifetimeWatcher(watcher confmap.WatcherFunc, do
 // by Vault and triggers the re-fetch of the secret when half of the TTl
 // has passed. In principle, this could be changed to actually check if the
 // values of the secret were actually changed or not.
-func (v *vaultConfigSource) buildV1LeaseWatcher(watcher confmap.WatcherFunc, doneCh chan struct{}) error {
+func (v *vaultConfigSource) buildV1LeaseWatcher(secret *api.Secret, watcher confmap.WatcherFunc, doneCh chan struct{}) error {
 	go func() {
 		// The lease duration is a hint of time to re-fetch the values.
 		// The SmartAgent waits for half ot the lease duration.
-		updateWait := time.Duration(v.secret.LeaseDuration/2) * time.Second
+		updateWait := time.Duration(secret.LeaseDuration/2) * time.Second
 		select {
 		case <-time.After(updateWait):
 			// This is triggering a re-fetch. In principle this could actually check for changes in the values.
@@ -211,10 +300,10 @@ func (v *vaultConfigSource) buildV1LeaseWatcher(watcher confmap.WatcherFunc, don
 }
 
 // buildPollingWatcher builds a watcher function that monitors for changes on
-// the v.secret metadata. In principle this could be done for the actual value of
+// the secret metadata. In principle this could be done for the actual value of
 // the retrieved keys. However, checking for metadata keeps this in sync with the
 // SignalFx SmartAgent behavior.
-func (v *vaultConfigSource) buildPollingWatcher(watcher confmap.WatcherFunc, doneCh chan struct{}) error {
+func (v *vaultConfigSource) buildPollingWatcher(path string, secret *api.Secret, watcher confmap.WatcherFunc, doneCh chan struct{}) error {
 	// Use the same requirements as SignalFx Smart Agent to build a polling watcher for the secret:
 	//
 	// This secret is not renewable or on a lease.  If it has a
@@ -222,26 +311,26 @@ func (v *vaultConfigSource) buildPollingWatcher(watcher confmap.WatcherFunc, don
 	// probably a KV v2 secret.  In that case, we do a poll on the
 	// secret's metadata to refresh it and notice if a new version is
 	// added to the secret.
-	mdValue := v.secret.Data["metadata"]
-	if mdValue == nil || !strings.Contains(v.path, "/data/") {
-		v.logger.Warn("Missing metadata to create polling watcher for vault config source", zap.String("path", v.path))
+	mdValue := secret.Data["metadata"]
+	if mdValue == nil || !strings.Contains(path, "/data/") {
+		v.logger.Warn("Missing metadata to create polling watcher for vault config source", zap.String("path", path))
 		return nil
 	}
 
 	mdMap, ok := mdValue.(map[string]any)
 	if !ok {
-		v.logger.Warn("Metadata not in the expected format to create polling watcher for vault config source", zap.String("path", v.path))
+		v.logger.Warn("Metadata not in the expected format to create polling watcher for vault config source", zap.String("path", path))
 		return nil
 	}
 
 	originalVersion := v.extractVersionMetadata(mdMap, "created_time", "version")
 	if originalVersion == nil {
-		v.logger.Warn("Failed to extract version metadata to create to create polling watcher for vault config source", zap.String("path", v.path))
+		v.logger.Warn("Failed to extract version metadata to create to create polling watcher for vault config source", zap.String("path", path))
 		return nil
 	}
 
 	go func() {
-		metadataPath := strings.Replace(v.path, "/data/", "/metadata/", 1)
+		metadataPath := strings.Replace(path, "/data/", "/metadata/", 1)
 		ticker := time.NewTicker(v.pollInterval)
 		defer ticker.Stop()
 
@@ -317,6 +406,24 @@ func (v *vaultConfigSource) extractVersionMetadata(metadataMap map[string]any, t
 	}
 }
 
+// lookupKey returns the value of the key on the secret. For K/V V2 secrets keys not
+// found at the root of the secret are looked up under its "data" map, so both
+// "data.password" and "password" select the same value.
+func lookupKey(secret *api.Secret, key string) any {
+	if value := traverseToKey(secret.Data, key); value != nil {
+		return value
+	}
+
+	if _, hasMetadata := secret.Data["metadata"]; !hasMetadata {
+		return nil
+	}
+	data, ok := secret.Data["data"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return traverseToKey(data, key)
+}
+
 // Allows key to be dot-delimited to traverse nested maps.
 func traverseToKey(data map[string]any, key string) any {
 	// Since strings.Split is called with a non-empty separator it will always return
@@ -337,14 +444,101 @@ func traverseToKey(data map[string]any, key string) any {
 	}
 }
 
-func getClientToken(client *api.Client, auth Authentication) (string, error) {
+// renewToken keeps the client token valid while doneCh is open, renewing it for as
+// long as possible and logging in again once it can't be renewed anymore. The watcher,
+// if any, is notified if the login fails since the retrieved values can't be refreshed
+// afterwards.
+func (v *vaultConfigSource) renewToken(watcher confmap.WatcherFunc, doneCh chan struct{}) {
+	go func() {
+		authSecret := v.authSecret
+		for {
+			tokenWatcher, err := v.client.NewLifetimeWatcher(&api.RenewerInput{
+				Secret: authSecret,
+			})
+			if err != nil {
+				v.notifyTokenError(watcher, err)
+				return
+			}
+
+			go tokenWatcher.Start()
+			if stopped := v.waitTokenRenewal(tokenWatcher, doneCh); stopped {
+				return
+			}
+
+			if authSecret, err = login(v.client, v.auth); err != nil {
+				select {
+				case <-doneCh:
+					// Stopped while logging in, nothing to refresh anymore.
+				default:
+					v.notifyTokenError(watcher, err)
+				}
+				return
+			}
+			v.client.SetToken(authSecret.Auth.ClientToken)
+			v.logger.Debug("vault token obtained via new login")
+
+			if !authSecret.Auth.Renewable {
+				return
+			}
+		}
+	}()
+}
+
+// waitTokenRenewal waits until the token can't be renewed anymore or doneCh is closed,
+// in which case it returns true.
+func (v *vaultConfigSource) waitTokenRenewal(tokenWatcher *api.LifetimeWatcher, doneCh chan struct{}) bool {
+	defer tokenWatcher.Stop()
+
+	for {
+		select {
+		case <-tokenWatcher.RenewCh():
+			v.logger.Debug("vault token renewed")
+		case err := <-tokenWatcher.DoneCh():
+			if err != nil {
+				v.logger.Warn("Failed to renew vault token, logging in again", zap.Error(err))
+			}
+			return false
+		case <-doneCh:
+			return true
+		}
+	}
+}
+
+func (v *vaultConfigSource) notifyTokenError(watcher confmap.WatcherFunc, err error) {
+	err = fmt.Errorf("failed to refresh vault token: %w", err)
+	if watcher == nil {
+		v.logger.Error("Vault config source can't refresh its token", zap.Error(err))
+		return
+	}
+	watcher(&confmap.ChangeEvent{Error: err})
+}
+
+// login authenticates with the configured method and returns the secret holding
+// the client token.
+func login(client *api.Client, auth Authentication) (*api.Secret, error) {
+	var secret *api.Secret
+	var err error
 	switch {
 	case auth.Token != nil:
-		return *auth.Token, nil
+		return &api.Secret{Auth: &api.SecretAuth{ClientToken: *auth.Token}}, nil
 	case auth.IAMAuthentication != nil:
-		return auth.IAMAuthentication.Token(client)
+		secret, err = auth.IAMAuthentication.Login(client)
 	case auth.GCPAuthentication != nil:
-		return auth.GCPAuthentication.Token(client)
+		secret, err = auth.GCPAuthentication.Login(client)
+	case auth.AppRoleAuthentication != nil:
+		secret, err = auth.AppRoleAuthentication.Login(client)
+	case auth.KubernetesAuthentication != nil:
+		secret, err = auth.KubernetesAuthentication.Login(client)
+	default:
+		return nil, &errEmptyAuth{errors.New("auth cannot be empty, exactly one method must be used")}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Auth methods may not return an error but an empty secret.
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, errors.New("vault login did not return a client token")
 	}
-	return "", &errEmptyAuth{errors.New("auth cannot be empty, exactly one method must be used")}
+	return secret, nil
 }
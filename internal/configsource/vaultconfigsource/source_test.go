@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -440,6 +441,278 @@ func Test_vaultSession_extractVersionMetadata(t *testing.T) {
 	}
 }
 
+func TestVaultRetrieveSelectorPath(t *testing.T) {
+	vault, server := newFakeVault(t, map[string][]map[string]any{
+		"app": {
+			{"password": "p1"},
+			{"password": "p2", "nested": map[string]any{"key": "value"}},
+		},
+		"db": {{"username": "admin"}},
+	})
+
+	tests := []struct {
+		params   *confmap.Conf
+		expected any
+		err      error
+		name     string
+		path     string
+		selector string
+	}{
+		{name: "path_and_key", selector: "secret/data/app#password", expected: "p2"},
+		{name: "path_and_full_key", selector: "secret/data/app#data.password", expected: "p2"},
+		{name: "path_and_nested_key", selector: "secret/data/app#nested.key", expected: "value"},
+		{name: "other_path", selector: "secret/data/db#username", expected: "admin"},
+		{name: "metadata", selector: "secret/data/app#metadata.version", expected: "2"},
+		{
+			name:     "version",
+			selector: "secret/data/app#password",
+			params:   confmap.NewFromStringMap(map[string]any{"version": 1}),
+			expected: "p1",
+		},
+		{
+			name:     "version_string",
+			selector: "secret/data/app#password",
+			params:   confmap.NewFromStringMap(map[string]any{"version": "1"}),
+			expected: "p1",
+		},
+		{name: "config_path", path: "secret/data/app", selector: "data.password", expected: "p2"},
+		{name: "config_path_overridden", path: "secret/data/app", selector: "secret/data/db#username", expected: "admin"},
+		{name: "missing_path", selector: "password", err: &errMissingPath{}},
+		{name: "missing_key", selector: "secret/data/app#", err: &errBadSelector{}},
+		{name: "bad_key", selector: "secret/data/app#missing", err: &errBadSelector{}},
+		{name: "non_existent_path", selector: "secret/data/missing#password", err: &errNilSecret{}},
+		{
+			name:     "non_existent_version",
+			selector: "secret/data/app#password",
+			params:   confmap.NewFromStringMap(map[string]any{"version": 3}),
+			err:      &errNilSecret{},
+		},
+		{
+			name:     "invalid_version",
+			selector: "secret/data/app#password",
+			params:   confmap.NewFromStringMap(map[string]any{"version": "latest"}),
+			err:      &errInvalidVersion{},
+		},
+		{
+			name:     "non_positive_version",
+			selector: "secret/data/app#password",
+			params:   confmap.NewFromStringMap(map[string]any{"version": 0}),
+			err:      &errInvalidVersion{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := newConfigSource(&Config{
+				Endpoint:       server.URL,
+				Authentication: &Authentication{Token: &tokenStr},
+				Path:           tt.path,
+				PollInterval:   time.Minute,
+			}, zap.NewNop())
+			require.NoError(t, err)
+
+			retrieved, err := source.Retrieve(context.Background(), tt.selector, tt.params, nil)
+			if tt.err != nil {
+				require.Error(t, err)
+				assert.IsType(t, tt.err, err)
+				assert.Nil(t, retrieved)
+				return
+			}
+			require.NoError(t, err)
+			val, err := retrieved.AsRaw()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, val)
+			assert.Equal(t, token, vault.lastToken())
+			require.NoError(t, retrieved.Close(context.Background()))
+		})
+	}
+}
+
+func TestVaultWatchPerPath(t *testing.T) {
+	vault, server := newFakeVault(t, map[string][]map[string]any{
+		"app": {{"password": "p1"}},
+		"db":  {{"username": "admin"}},
+	})
+
+	source, err := newConfigSource(&Config{
+		Endpoint:       server.URL,
+		Authentication: &Authentication{Token: &tokenStr},
+		PollInterval:   10 * time.Millisecond,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	watchCh := make(chan *confmap.ChangeEvent, 1)
+	retrievedApp, err := source.Retrieve(context.Background(), "secret/data/app#password", nil, func(event *confmap.ChangeEvent) {
+		watchCh <- event
+	})
+	require.NoError(t, err)
+
+	dbWatchCh := make(chan *confmap.ChangeEvent, 1)
+	retrievedDB, err := source.Retrieve(context.Background(), "secret/data/db#username", nil, func(event *confmap.ChangeEvent) {
+		dbWatchCh <- event
+	})
+	require.NoError(t, err)
+
+	retrievedPinned, err := source.Retrieve(context.Background(), "secret/data/app#password",
+		confmap.NewFromStringMap(map[string]any{"version": 1}), func(event *confmap.ChangeEvent) {
+			panic("must not be called for a pinned version")
+		})
+	require.NoError(t, err)
+
+	vault.put("app", map[string]any{"password": "p2"})
+
+	select {
+	case ce := <-watchCh:
+		require.NoError(t, ce.Error)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to be notified")
+	}
+	// The secret at the other path didn't change.
+	assert.Empty(t, dbWatchCh)
+
+	require.NoError(t, retrievedApp.Close(context.Background()))
+	require.NoError(t, retrievedDB.Close(context.Background()))
+	require.NoError(t, retrievedPinned.Close(context.Background()))
+}
+
+func TestVaultLogin(t *testing.T) {
+	dir := t.TempDir()
+	secretIDFile := filepath.Join(dir, "secret_id")
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("file-secret-id\n"), 0600))
+	saTokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(saTokenFile, []byte("service-account-jwt"), 0600))
+
+	roleID := "role-id"
+	secretID := "secret-id"
+	role := "collector"
+	mount := "custom"
+	tests := []struct {
+		auth     *Authentication
+		expected map[string]any
+		name     string
+	}{
+		{
+			name:     "approle",
+			auth:     &Authentication{AppRoleAuthentication: &AppRoleAuthentication{RoleID: &roleID, SecretID: &secretID}},
+			expected: map[string]any{"mount": "approle", "role_id": "role-id", "secret_id": "secret-id"},
+		},
+		{
+			name:     "approle_secret_id_file",
+			auth:     &Authentication{AppRoleAuthentication: &AppRoleAuthentication{RoleID: &roleID, SecretIDFile: &secretIDFile, Mount: &mount}},
+			expected: map[string]any{"mount": "custom", "role_id": "role-id", "secret_id": "file-secret-id"},
+		},
+		{
+			name:     "approle_without_secret_id",
+			auth:     &Authentication{AppRoleAuthentication: &AppRoleAuthentication{RoleID: &roleID}},
+			expected: map[string]any{"mount": "approle", "role_id": "role-id"},
+		},
+		{
+			name:     "kubernetes",
+			auth:     &Authentication{KubernetesAuthentication: &KubernetesAuthentication{Role: &role, ServiceAccountTokenFile: &saTokenFile}},
+			expected: map[string]any{"mount": "kubernetes", "role": "collector", "jwt": "service-account-jwt"},
+		},
+		{
+			name:     "kubernetes_mount",
+			auth:     &Authentication{KubernetesAuthentication: &KubernetesAuthentication{Role: &role, ServiceAccountTokenFile: &saTokenFile, Mount: &mount}},
+			expected: map[string]any{"mount": "custom", "role": "collector", "jwt": "service-account-jwt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault, server := newFakeVault(t, map[string][]map[string]any{"app": {{"password": "p1"}}})
+
+			source, err := newConfigSource(&Config{
+				Endpoint:       server.URL,
+				Authentication: tt.auth,
+				PollInterval:   time.Minute,
+			}, zap.NewNop())
+			require.NoError(t, err)
+			assert.Equal(t, []map[string]any{tt.expected}, vault.loginRequests())
+
+			retrieved, err := source.Retrieve(context.Background(), "secret/data/app#password", nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "token-1", vault.lastToken())
+			require.NoError(t, retrieved.Close(context.Background()))
+		})
+	}
+}
+
+func TestVaultLoginErrors(t *testing.T) {
+	_, server := newFakeVault(t, map[string][]map[string]any{})
+
+	missingFile := filepath.Join(t.TempDir(), "missing")
+	roleID := "role-id"
+	role := "collector"
+	tests := []struct {
+		auth     *Authentication
+		endpoint string
+		name     string
+	}{
+		{
+			name:     "secret_id_file_not_found",
+			endpoint: server.URL,
+			auth:     &Authentication{AppRoleAuthentication: &AppRoleAuthentication{RoleID: &roleID, SecretIDFile: &missingFile}},
+		},
+		{
+			name:     "service_account_token_file_not_found",
+			endpoint: server.URL,
+			auth:     &Authentication{KubernetesAuthentication: &KubernetesAuthentication{Role: &role, ServiceAccountTokenFile: &missingFile}},
+		},
+		{
+			name:     "no_client_token",
+			endpoint: server.URL + "/no_auth",
+			auth:     &Authentication{AppRoleAuthentication: &AppRoleAuthentication{RoleID: &roleID}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := newConfigSource(&Config{
+				Endpoint:       tt.endpoint,
+				Authentication: tt.auth,
+				PollInterval:   time.Minute,
+			}, zap.NewNop())
+			require.Error(t, err)
+			assert.Nil(t, source)
+		})
+	}
+}
+
+func TestVaultTokenRenewal(t *testing.T) {
+	vault, server := newFakeVault(t, map[string][]map[string]any{
+		"app": {{"password": "p1"}},
+		"db":  {{"username": "admin"}},
+	})
+	vault.tokenTTL = 2
+
+	roleID := "role-id"
+	source, err := newConfigSource(&Config{
+		Endpoint:       server.URL,
+		Authentication: &Authentication{AppRoleAuthentication: &AppRoleAuthentication{RoleID: &roleID}},
+		PollInterval:   time.Minute,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	retrieved, err := source.Retrieve(context.Background(), "secret/data/app#password", nil, func(event *confmap.ChangeEvent) {
+		panic("must not be called while the token can be refreshed")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", vault.lastToken())
+
+	// The token is renewed until it reaches its max TTL and then a new login happens.
+	require.Eventually(t, func() bool {
+		return vault.renewRequests() > 0 && len(vault.loginRequests()) > 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	retrievedDB, err := source.Retrieve(context.Background(), "secret/data/db#username", nil, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, "token-1", vault.lastToken())
+
+	require.NoError(t, retrieved.Close(context.Background()))
+	require.NoError(t, retrievedDB.Close(context.Background()))
+}
+
 func requireCmdRun(t *testing.T, cli string) {
 	skipCheck(t)
 	parts := strings.Split(cli, " ")
@@ -461,3 +734,38 @@ func skipCheck(t *testing.T) {
 	}
 	t.Skipf("Test must be explicitly enabled via 'RUN_VAULT_DOCKER_TESTS' environment variable.")
 }
+
+func TestVaultWatchAfterBadSelector(t *testing.T) {
+	vault, server := newFakeVault(t, map[string][]map[string]any{
+		"app": {{"password": "p1"}},
+	})
+
+	source, err := newConfigSource(&Config{
+		Endpoint:       server.URL,
+		Authentication: &Authentication{Token: &tokenStr},
+		PollInterval:   10 * time.Millisecond,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = source.Retrieve(context.Background(), "secret/data/app#missing", nil, func(event *confmap.ChangeEvent) {
+		panic("must not be called for a missing key")
+	})
+	require.ErrorAs(t, err, new(*errBadSelector))
+
+	watchCh := make(chan *confmap.ChangeEvent, 1)
+	retrieved, err := source.Retrieve(context.Background(), "secret/data/app#password", nil, func(event *confmap.ChangeEvent) {
+		watchCh <- event
+	})
+	require.NoError(t, err)
+
+	vault.put("app", map[string]any{"password": "p2"})
+
+	select {
+	case ce := <-watchCh:
+		require.NoError(t, ce.Error)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to be notified")
+	}
+
+	require.NoError(t, retrieved.Close(context.Background()))
+}
@@ -24,9 +24,12 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/signalfx/splunk-otel-collector/internal/configsource"
+	"github.com/signalfx/splunk-otel-collector/internal/configsource/consulconfigsource"
+	"github.com/signalfx/splunk-otel-collector/internal/configsource/encryptedfileconfigsource"
 	"github.com/signalfx/splunk-otel-collector/internal/configsource/envvarconfigsource"
 	"github.com/signalfx/splunk-otel-collector/internal/configsource/etcd2configsource"
 	"github.com/signalfx/splunk-otel-collector/internal/configsource/includeconfigsource"
+	"github.com/signalfx/splunk-otel-collector/internal/configsource/k8sconfigsource"
 	"github.com/signalfx/splunk-otel-collector/internal/configsource/vaultconfigsource"
 	"github.com/signalfx/splunk-otel-collector/internal/configsource/zookeeperconfigsource"
 )
@@ -39,6 +42,9 @@ var configSourceFactories = func() configsource.Factories {
 		vaultconfigsource.NewFactory(),
 		zookeeperconfigsource.NewFactory(),
 		etcd2configsource.NewFactory(),
+		k8sconfigsource.NewFactory(),
+		encryptedfileconfigsource.NewFactory(),
+		consulconfigsource.NewFactory(),
 	} {
 		if _, ok := factories[f.Type()]; ok {
 			panic(fmt.Sprintf("duplicate config source factory %q", f.Type()))
@@ -54,8 +54,12 @@ import (
 )
 
 const (
-	durationEnvVar = "SPLUNK_DISCOVERY_DURATION"
-	logLevelEnvVar = "SPLUNK_DISCOVERY_LOG_LEVEL"
+	continuousEnvVar = "SPLUNK_DISCOVERY_CONTINUOUS"
+	durationEnvVar   = "SPLUNK_DISCOVERY_DURATION"
+	logLevelEnvVar   = "SPLUNK_DISCOVERY_LOG_LEVEL"
+
+	endpointEventTypePrefix  = "endpoint."
+	endpointRemovedEventType = endpointEventTypePrefix + "removed"
 )
 
 var (
@@ -67,7 +71,11 @@ var (
 // that will stand up the observers and discovery receivers based on the .discovery.yaml
 // contents of the config dir, acting as a log consumer to determine which
 // of the underlying receivers were successfully discovered by the
-// discovery receiver from its emitted log records.
+// discovery receiver from its emitted log records. In continuous mode the
+// observers and discovery receivers keep running after the preflight and
+// the watcher is notified whenever the set of discovered receivers changes.
+// The discovery receivers are then recreated without the receivers activated
+// in the service so that these don't run twice against their targets.
 type discoverer struct {
 	factories       otelcol.Factories
 	expandConverter confmap.Converter
@@ -81,11 +89,28 @@ type discoverer struct {
 	discoveredObservers       map[component.ID]discovery.StatusType
 	// propertiesConf is a store of all properties from cmdline args and env vars
 	// that's merged with receiver/observer configs before creation
-	propertiesConf          *confmap.Conf
-	info                    component.BuildInfo
-	duration                time.Duration
-	mu                      sync.Mutex
+	propertiesConf *confmap.Conf
+	// the discovery config, receivers, and observers retained in continuous mode
+	cfg                *Config
+	discoveryReceivers map[component.ID]otelcolreceiver.Logs
+	discoveryObservers map[component.ID]otelcolextension.Extension
+	// receiverID -> observerID::endpointID of its successfully discovered endpoints
+	successfulEndpoints map[component.ID]map[string]struct{}
+	// the receivers of the last determined discovery config, and the ones the running
+	// discovery receivers were created without in continuous mode
+	activatedReceivers map[component.ID]struct{}
+	excludedReceivers  map[component.ID]struct{}
+	// watcher is notified once of the next discovery config change in continuous mode
+	watcher  confmap.WatcherFunc
+	info     component.BuildInfo
+	duration time.Duration
+	mu       sync.Mutex
+	// syncMu serializes the recreation and shutdown of the continuous mode discovery receivers
+	syncMu                  sync.Mutex
 	propertiesFileSpecified bool
+	continuous              bool
+	// configChanged is set when the discovery config changes without a watcher to notify
+	configChanged bool
 }
 
 func newDiscoverer(logger *zap.Logger) (*discoverer, error) {
@@ -102,6 +127,14 @@ func newDiscoverer(logger *zap.Logger) (*discoverer, error) {
 		}
 	}
 
+	var continuous bool
+	if c, ok := os.LookupEnv(continuousEnvVar); ok {
+		var err error
+		if continuous, err = strconv.ParseBool(c); err != nil {
+			logger.Warn("Invalid SPLUNK_DISCOVERY_CONTINUOUS. Using default of false", zap.String("continuous", c))
+		}
+	}
+
 	factories, err := components.Get()
 	if err != nil {
 		return (*discoverer)(nil), err
@@ -113,12 +146,14 @@ func newDiscoverer(logger *zap.Logger) (*discoverer, error) {
 		extensions:                map[component.ID]otelcolextension.Extension{},
 		configs:                   map[string]*Config{},
 		duration:                  duration,
+		continuous:                continuous,
 		mu:                        sync.Mutex{},
 		expandConverter:           expandconverter.New(confmap.ConverterSettings{}),
 		discoveredReceivers:       map[component.ID]discovery.StatusType{},
 		unexpandedReceiverEntries: map[component.ID]map[component.ID]map[string]any{},
 		discoveredConfig:          map[component.ID]map[string]any{},
 		discoveredObservers:       map[component.ID]discovery.StatusType{},
+		successfulEndpoints:       map[component.ID]map[string]struct{}{},
 	}
 	d.propertiesConf = d.propertiesConfFromEnv()
 	return d, nil
@@ -159,7 +194,7 @@ func (d *discoverer) propertiesConfFromEnv() *confmap.Conf {
 		equalsIdx := strings.Index(env, "=")
 		if equalsIdx != -1 && len(env) > equalsIdx+1 {
 			envVar := env[:equalsIdx]
-			if envVar == logLevelEnvVar || envVar == durationEnvVar {
+			if envVar == logLevelEnvVar || envVar == durationEnvVar || envVar == continuousEnvVar {
 				continue
 			}
 			if p, ok, e := properties.NewPropertyFromEnvVar(envVar, env[equalsIdx+1:]); ok {
@@ -175,7 +210,8 @@ func (d *discoverer) propertiesConfFromEnv() *confmap.Conf {
 }
 
 // discover will create all .discovery.yaml components, start them, wait the configured
-// duration, and tear them down before returning the discovery config.
+// duration, and tear them down before returning the discovery config. In continuous mode
+// the components aren't torn down and a zero duration skips the preflight wait.
 func (d *discoverer) discover(cfg *Config) (map[string]any, error) {
 	if !d.propertiesFileSpecified {
 		if err := d.mergeDiscoveryPropertiesEntry(cfg); err != nil {
@@ -225,40 +261,173 @@ func (d *discoverer) discover(cfg *Config) (map[string]any, error) {
 		}
 	}
 
-	_, _ = fmt.Fprintf(os.Stderr, "Discovering for next %s...\n", d.duration)
-	select {
-	case <-time.After(d.duration):
-	case <-context.Background().Done():
+	if !d.continuous || d.duration > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Discovering for next %s...\n", d.duration)
+		select {
+		case <-time.After(d.duration):
+		case <-context.Background().Done():
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Discovery complete.\n")
+	}
+
+	if d.continuous {
+		_, _ = fmt.Fprintf(os.Stderr, "Continuing discovery in the background.\n")
+		d.mu.Lock()
+		d.cfg = cfg
+		d.discoveryReceivers = discoveryReceivers
+		d.discoveryObservers = discoveryObservers
+		d.mu.Unlock()
+	} else {
+		d.shutdownComponents(discoveryReceivers, discoveryObservers)
 	}
-	_, _ = fmt.Fprintf(os.Stderr, "Discovery complete.\n")
 
+	discoveryConfig, err := d.discoveryConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed constructing discovery config: %w", err)
+	}
+	if d.continuous {
+		d.syncDiscoveryReceivers()
+	}
+	return discoveryConfig, nil
+}
+
+// continuousDiscoveryConfig returns the current discovery config of the continuous mode
+// and arms the watcher to be notified of its next change.
+func (d *discoverer) continuousDiscoveryConfig(watcher confmap.WatcherFunc) (map[string]any, bool, error) {
+	d.mu.Lock()
+	cfg := d.cfg
+	d.mu.Unlock()
+	if cfg == nil {
+		// no components are running, nothing can change
+		return nil, false, nil
+	}
+
+	discoveryConfig, err := d.discoveryConfig(cfg)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed constructing discovery config: %w", err)
+	}
+	d.syncDiscoveryReceivers()
+	d.watch(watcher)
+	return discoveryConfig, true, nil
+}
+
+// syncDiscoveryReceivers recreates the discovery receivers of the continuous mode when the receivers activated
+// in the service changed, without the activated ones. Once activated, a receiver only runs in the service,
+// and it is evaluated again by the discovery receivers once all its successful endpoints were removed.
+func (d *discoverer) syncDiscoveryReceivers() {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+
+	d.mu.Lock()
+	cfg, activated := d.cfg, d.activatedReceivers
+	discoveryReceivers, discoveryObservers := d.discoveryReceivers, d.discoveryObservers
+	upToDate := sameReceivers(activated, d.excludedReceivers)
+	d.mu.Unlock()
+	if cfg == nil || upToDate {
+		return
+	}
+
+	// the discovery receivers consume their statuses with the lock held so they must be shut down without it
+	d.shutdownComponents(discoveryReceivers, nil)
+
+	recreated := map[component.ID]otelcolreceiver.Logs{}
+	for observerID := range discoveryObservers {
+		receiverID, receiver, err := d.createDiscoveryReceiver(cfg, observerID, activated)
+		if err != nil {
+			d.logger.Warn(fmt.Sprintf("failed recreating the %q discovery receiver", observerID), zap.Error(err))
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err = receiver.Start(ctx, d); err != nil {
+			d.logger.Warn(fmt.Sprintf("%q startup failed.", receiverID), zap.Error(err))
+		}
+		cancel()
+		recreated[receiverID] = receiver
+	}
+
+	d.mu.Lock()
+	d.discoveryReceivers = recreated
+	d.excludedReceivers = activated
+	d.mu.Unlock()
+}
+
+func sameReceivers(a, b map[component.ID]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for receiverID := range a {
+		if _, ok := b[receiverID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// watch arms the watcher to be notified of the next discovery config change, or
+// notifies it right away if the config changed since it was last determined.
+func (d *discoverer) watch(watcher confmap.WatcherFunc) {
+	if watcher == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.watcher = watcher
+	if d.configChanged {
+		d.notifyConfigChange()
+	}
+}
+
+// notifyConfigChange notifies the armed watcher, if any, that the discovery config changed.
+// It must be called with the lock held.
+func (d *discoverer) notifyConfigChange() {
+	if d.watcher == nil {
+		d.configChanged = true
+		return
+	}
+	watcher := d.watcher
+	d.watcher = nil
+	d.configChanged = false
+	// the watcher triggers a config reload that will block on the resolver, so don't hold the lock
+	go watcher(&confmap.ChangeEvent{})
+}
+
+// shutdown tears down the discovery components kept running in continuous mode.
+func (d *discoverer) shutdown() {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+
+	d.mu.Lock()
+	discoveryReceivers, discoveryObservers := d.discoveryReceivers, d.discoveryObservers
+	d.discoveryReceivers, d.discoveryObservers = nil, nil
+	d.cfg, d.watcher = nil, nil
+	d.excludedReceivers = nil
+	d.mu.Unlock()
+
+	d.shutdownComponents(discoveryReceivers, discoveryObservers)
+}
+
+func (d *discoverer) shutdownComponents(discoveryReceivers map[component.ID]otelcolreceiver.Logs, discoveryObservers map[component.ID]otelcolextension.Extension) {
 	for receiverID, receiver := range discoveryReceivers {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		cancels = append(cancels, cancel)
 		if e := receiver.Shutdown(ctx); e != nil {
 			d.logger.Warn(fmt.Sprintf("error shutting down receiver %q", receiverID), zap.Error(e))
 		}
+		cancel()
 	}
 	for observerID, observer := range discoveryObservers {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		cancels = append(cancels, cancel)
 		if e := observer.Shutdown(ctx); e != nil {
 			d.logger.Warn(fmt.Sprintf("error shutting down observer %q", observerID), zap.Error(e))
 		}
+		cancel()
 	}
-
-	discoveryConfig, err := d.discoveryConfig(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed constructing discovery config: %w", err)
-	}
-	return discoveryConfig, nil
 }
 
 func (d *discoverer) createDiscoveryReceiversAndObservers(cfg *Config) (map[component.ID]otelcolreceiver.Logs, map[component.ID]otelcolextension.Extension, error) {
 	discoveryObservers := map[component.ID]otelcolextension.Extension{}
 	discoveryReceivers := map[component.ID]otelcolreceiver.Logs{}
 
-	discoveryReceiverFactory := discoveryreceiver.NewFactory()
 	for _, observerID := range cfg.observersForDiscoveryMode() {
 		observer, err := d.createObserver(observerID, cfg)
 		if err != nil {
@@ -272,88 +441,113 @@ func (d *discoverer) createDiscoveryReceiversAndObservers(cfg *Config) (map[comp
 		d.extensions[observerID] = observer
 		discoveryObservers[observerID] = observer
 
-		discoveryReceiverDefaultConfig := discoveryReceiverFactory.CreateDefaultConfig()
-		discoveryReceiverConfig, ok := discoveryReceiverDefaultConfig.(*discoveryreceiver.Config)
-		if !ok {
-			return nil, nil, fmt.Errorf("failed to coerce to discoveryreceiver.Config")
+		receiverID, receiver, err := d.createDiscoveryReceiver(cfg, observerID, nil)
+		if err != nil {
+			return nil, nil, err
 		}
+		discoveryReceivers[receiverID] = receiver
+	}
+
+	return discoveryReceivers, discoveryObservers, nil
+}
 
-		discoveryReceiverRaw := map[string]any{}
-		receivers := map[string]any{}
+// createDiscoveryReceiver creates the discovery receiver of the observer for all the receivers to discover
+// but the excluded ones.
+func (d *discoverer) createDiscoveryReceiver(cfg *Config, observerID component.ID, excluded map[component.ID]struct{}) (component.ID, otelcolreceiver.Logs, error) {
+	discoveryReceiverFactory := discoveryreceiver.NewFactory()
+	discoveryReceiverDefaultConfig := discoveryReceiverFactory.CreateDefaultConfig()
+	discoveryReceiverConfig, ok := discoveryReceiverDefaultConfig.(*discoveryreceiver.Config)
+	if !ok {
+		return component.ID{}, nil, fmt.Errorf("failed to coerce to discoveryreceiver.Config")
+	}
 
-		receiversPropertiesConf := confmap.New()
-		if d.propertiesConf.IsSet("receivers") {
-			receiversPropertiesConf, err = d.propertiesConf.Sub("receivers")
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed obtaining receivers properties config: %w", err)
-			}
+	discoveryReceiverRaw := map[string]any{}
+	receivers := map[string]any{}
+	receiverEntries := map[component.ID]map[string]any{}
+
+	var err error
+	receiversPropertiesConf := confmap.New()
+	if d.propertiesConf.IsSet("receivers") {
+		receiversPropertiesConf, err = d.propertiesConf.Sub("receivers")
+		if err != nil {
+			return component.ID{}, nil, fmt.Errorf("failed obtaining receivers properties config: %w", err)
 		}
-		for receiverID, receiver := range cfg.ReceiversToDiscover {
-			if ok, err = d.updateReceiverForObserver(receiverID, receiver, observerID); err != nil {
-				return nil, nil, err
-			} else if !ok {
-				continue
-			}
-			enabled := true
-			if e := receiver.Enabled; e != nil {
-				enabled = *e
+	}
+	for receiverID, receiver := range cfg.ReceiversToDiscover {
+		if _, isExcluded := excluded[receiverID]; isExcluded {
+			continue
+		}
+		if ok, err = d.updateReceiverForObserver(receiverID, receiver, observerID); err != nil {
+			return component.ID{}, nil, err
+		} else if !ok {
+			continue
+		}
+		enabled := true
+		if e := receiver.Enabled; e != nil {
+			enabled = *e
+		}
+		receiverEntry := receiver.Entry.ToStringMap()
+		if receiversPropertiesConf.IsSet(receiverID.String()) {
+			receiverPropertiesConf, e := receiversPropertiesConf.Sub(receiverID.String())
+			if e != nil {
+				return component.ID{}, nil, fmt.Errorf("failed obtaining receiver properties config: %w", e)
 			}
-			receiverEntry := receiver.Entry.ToStringMap()
-			if receiversPropertiesConf.IsSet(receiverID.String()) {
-				receiverPropertiesConf, e := receiversPropertiesConf.Sub(receiverID.String())
-				if e != nil {
-					return nil, nil, fmt.Errorf("failed obtaining receiver properties config: %w", e)
-				}
-				entryConf := confmap.NewFromStringMap(receiverEntry)
+			entryConf := confmap.NewFromStringMap(receiverEntry)
 
-				if receiverPropertiesConf.IsSet("enabled") {
-					if b, convErr := strconv.ParseBool(strings.ToLower(fmt.Sprintf("%v", receiverPropertiesConf.Get("enabled")))); convErr == nil {
-						// convErr would have been detected in properties
-						enabled = b
-					}
-					pc := receiverPropertiesConf.ToStringMap()
-					delete(pc, "enabled")
-					receiverPropertiesConf = confmap.NewFromStringMap(pc)
-				}
-
-				if err = entryConf.Merge(receiverPropertiesConf); err != nil {
-					return nil, nil, fmt.Errorf("failed merging receiver %q properties config: %w", receiverID, err)
+			if receiverPropertiesConf.IsSet("enabled") {
+				if b, convErr := strconv.ParseBool(strings.ToLower(fmt.Sprintf("%v", receiverPropertiesConf.Get("enabled")))); convErr == nil {
+					// convErr would have been detected in properties
+					enabled = b
 				}
-				receiverEntry = entryConf.ToStringMap()
+				pc := receiverPropertiesConf.ToStringMap()
+				delete(pc, "enabled")
+				receiverPropertiesConf = confmap.NewFromStringMap(pc)
 			}
 
-			if !enabled {
-				continue
+			if err = entryConf.Merge(receiverPropertiesConf); err != nil {
+				return component.ID{}, nil, fmt.Errorf("failed merging receiver %q properties config: %w", receiverID, err)
 			}
+			receiverEntry = entryConf.ToStringMap()
+		}
 
-			d.addUnexpandedReceiverConfig(receiverID, observerID, receiverEntry)
-			receivers[receiverID.String()] = receiverEntry
+		if !enabled {
+			continue
 		}
 
-		discoveryReceiverRaw["receivers"] = receivers
-		discoveryReceiverConfMap, err := d.resolveConfig(discoveryReceiverRaw)
+		receiverEntries[receiverID] = receiverEntry
+		receivers[receiverID.String()] = receiverEntry
+	}
 
-		if err != nil {
-			return nil, nil, fmt.Errorf("error preparing discovery receiver config: %w", err)
-		}
+	// the unexpanded configs are read when consuming the statuses of the running discovery receivers
+	d.mu.Lock()
+	for receiverID, receiverEntry := range receiverEntries {
+		d.addUnexpandedReceiverConfig(receiverID, observerID, receiverEntry)
+	}
+	d.mu.Unlock()
 
-		if err = component.UnmarshalConfig(discoveryReceiverConfMap, discoveryReceiverConfig); err != nil {
-			return nil, nil, fmt.Errorf("failed unmarshaling discovery receiver config: %w", err)
-		}
+	discoveryReceiverRaw["receivers"] = receivers
+	discoveryReceiverConfMap, err := d.resolveConfig(discoveryReceiverRaw)
 
-		discoveryReceiverConfig.WatchObservers = append(discoveryReceiverConfig.WatchObservers, observerID)
-		discoveryReceiverConfig.EmbedReceiverConfig = true
+	if err != nil {
+		return component.ID{}, nil, fmt.Errorf("error preparing discovery receiver config: %w", err)
+	}
 
-		discoveryReceiverSettings := d.createReceiverCreateSettings()
-		discoveryReceiverSettings.ID = observerID
-		var lr otelcolreceiver.Logs
-		if lr, err = discoveryReceiverFactory.CreateLogsReceiver(context.Background(), discoveryReceiverSettings, discoveryReceiverDefaultConfig, d); err != nil {
-			return nil, nil, fmt.Errorf("failed creating discovery receiver: %w", err)
-		}
-		discoveryReceivers[component.MustNewIDWithName(discoveryReceiverFactory.Type().String(), observerID.String())] = lr
+	if err = component.UnmarshalConfig(discoveryReceiverConfMap, discoveryReceiverConfig); err != nil {
+		return component.ID{}, nil, fmt.Errorf("failed unmarshaling discovery receiver config: %w", err)
 	}
 
-	return discoveryReceivers, discoveryObservers, nil
+	discoveryReceiverConfig.WatchObservers = append(discoveryReceiverConfig.WatchObservers, observerID)
+	discoveryReceiverConfig.EmbedReceiverConfig = true
+	// endpoint removals tear down the receivers discovered with them in continuous mode
+	discoveryReceiverConfig.LogEndpoints = discoveryReceiverConfig.LogEndpoints || d.continuous
+
+	discoveryReceiverSettings := d.createReceiverCreateSettings()
+	discoveryReceiverSettings.ID = observerID
+	var lr otelcolreceiver.Logs
+	if lr, err = discoveryReceiverFactory.CreateLogsReceiver(context.Background(), discoveryReceiverSettings, discoveryReceiverDefaultConfig, d); err != nil {
+		return component.ID{}, nil, fmt.Errorf("failed creating discovery receiver: %w", err)
+	}
+	return component.MustNewIDWithName(discoveryReceiverFactory.Type().String(), observerID.String()), lr, nil
 }
 
 func (d *discoverer) createObserver(observerID component.ID, cfg *Config) (otelcolextension.Extension, error) {
@@ -472,13 +666,20 @@ func factoryForObserverType(extType component.Type) (otelcolextension.Factory, e
 }
 
 func (d *discoverer) discoveryConfig(cfg *Config) (map[string]any, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// the config is up-to-date with the discovered statuses from here
+	d.configChanged = false
+
 	dCfg := confmap.New()
 	receiverAdded := false
+	activated := map[component.ID]struct{}{}
 	for receiverID, receiverStatus := range d.discoveredReceivers {
 		if receiverStatus != discovery.Successful {
 			continue
 		}
 		if receiverCfgMap, ok := d.discoveredConfig[receiverID]; ok {
+			activated[receiverID] = struct{}{}
 			receiverCreator := confmap.NewFromStringMap(
 				map[string]any{"receivers": map[string]any{"receiver_creator/discovery": receiverCfgMap}},
 			)
@@ -488,6 +689,7 @@ func (d *discoverer) discoveryConfig(cfg *Config) (map[string]any, error) {
 			receiverAdded = true
 		}
 	}
+	d.activatedReceivers = activated
 
 	if receiverAdded {
 		if err := dCfg.Merge(
@@ -604,8 +806,13 @@ func (d *discoverer) getUnexpandedReceiverConfig(receiverID, observerID componen
 
 var _ component.Host = (*discoverer)(nil)
 
-// ReportFatalError is a component.Host method.
+// ReportFatalError is a component.Host method. The continuous mode components run alongside
+// the Collector service so their errors are logged instead.
 func (d *discoverer) ReportFatalError(err error) {
+	if d.continuous {
+		d.logger.Error("--discovery fatal error", zap.Error(err))
+		return
+	}
 	panic(fmt.Sprintf("--discovery fatal error: %v", err))
 }
 
@@ -651,6 +858,13 @@ func (d *discoverer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	var configChanged bool
+	defer func() {
+		if configChanged {
+			d.notifyConfigChange()
+		}
+	}()
+
 	rlogs := ld.ResourceLogs()
 	for i := 0; i < rlogs.Len(); i++ {
 		var (
@@ -661,6 +875,13 @@ func (d *discoverer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
 		)
 		rlog := rlogs.At(i)
 		rAttrs := rlog.Resource().Attributes()
+		if eventType, ok := rAttrs.Get(discovery.EventTypeAttr); ok && strings.HasPrefix(eventType.Str(), endpointEventTypePrefix) {
+			// endpoint event records are only requested in continuous mode
+			if d.continuous && eventType.Str() == endpointRemovedEventType {
+				configChanged = d.removeEndpoints(rlog) || configChanged
+			}
+			continue
+		}
 		if rName, ok := rAttrs.Get(discovery.ReceiverNameAttr); ok {
 			receiverName = rName.Str()
 		}
@@ -734,6 +955,9 @@ func (d *discoverer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
 			lrs := slog.LogRecords()
 			for k := 0; k < lrs.Len(); k++ {
 				lr := lrs.At(k)
+				if d.continuous {
+					d.trackSuccessfulEndpoint(receiverID, observerID, endpointID, lr)
+				}
 				if currentReceiverStatus != discovery.Successful || currentObserverStatus != discovery.Successful {
 					if rStatusAttr, ok := lr.Attributes().Get(discovery.StatusAttr); ok {
 						rStatus := discovery.StatusType(rStatusAttr.Str())
@@ -752,6 +976,9 @@ func (d *discoverer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
 						}
 						d.discoveredReceivers[receiverID] = receiverStatus
 						d.discoveredObservers[observerID] = determineCurrentStatus(currentObserverStatus, rStatus)
+						if receiverStatus == discovery.Successful && currentReceiverStatus != discovery.Successful {
+							configChanged = true
+						}
 					}
 				}
 			}
@@ -761,6 +988,70 @@ func (d *discoverer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
 	return nil
 }
 
+// trackSuccessfulEndpoint records the endpoint of a successful status log record
+// so that the receiver can be torn down once all its successful endpoints are removed.
+func (d *discoverer) trackSuccessfulEndpoint(receiverID, observerID component.ID, endpointID string, lr plog.LogRecord) {
+	if rStatus, ok := lr.Attributes().Get(discovery.StatusAttr); !ok || discovery.StatusType(rStatus.Str()) != discovery.Successful {
+		return
+	}
+	endpoints, ok := d.successfulEndpoints[receiverID]
+	if !ok {
+		endpoints = map[string]struct{}{}
+		d.successfulEndpoints[receiverID] = endpoints
+	}
+	endpoints[endpointKey(observerID, endpointID)] = struct{}{}
+}
+
+// removeEndpoints forgets the endpoints of an endpoint.removed resource, resetting the status
+// of the receivers left without successful endpoints. It returns whether any receiver was reset.
+func (d *discoverer) removeEndpoints(rlog plog.ResourceLogs) bool {
+	rAttrs := rlog.Resource().Attributes()
+	var observerType, observerName string
+	if oType, ok := rAttrs.Get(discovery.ObserverTypeAttr); ok {
+		observerType = oType.Str()
+	}
+	if oName, ok := rAttrs.Get(discovery.ObserverNameAttr); ok {
+		observerName = oName.Str()
+	}
+	observerID, err := component.NewType(observerType)
+	if err != nil {
+		d.logger.Debug("invalid observer type for removed endpoints", zap.String("observer type", observerType), zap.Error(err))
+		return false
+	}
+
+	var reset bool
+	slogs := rlog.ScopeLogs()
+	for j := 0; j < slogs.Len(); j++ {
+		lrs := slogs.At(j).LogRecords()
+		for k := 0; k < lrs.Len(); k++ {
+			id, ok := lrs.At(k).Attributes().Get("id")
+			if !ok {
+				continue
+			}
+			key := endpointKey(component.NewIDWithName(observerID, observerName), id.Str())
+			for receiverID, endpoints := range d.successfulEndpoints {
+				if _, found := endpoints[key]; !found {
+					continue
+				}
+				delete(endpoints, key)
+				if len(endpoints) > 0 {
+					continue
+				}
+				_, _ = fmt.Fprintf(os.Stderr, "No longer discovering %q: endpoint %q was removed.\n", receiverID, id.Str())
+				delete(d.successfulEndpoints, receiverID)
+				delete(d.discoveredReceivers, receiverID)
+				delete(d.discoveredConfig, receiverID)
+				reset = true
+			}
+		}
+	}
+	return reset
+}
+
+func endpointKey(observerID component.ID, endpointID string) string {
+	return fmt.Sprintf("%s::%s", observerID, endpointID)
+}
+
 // mergeDiscoveryPropertiesEntry validates and merges properties.discovery.yaml content with existing sources.
 // Priority is discovery.properties.yaml < env var properties < --set properties. --set and env var properties
 // are already resolved at this point.
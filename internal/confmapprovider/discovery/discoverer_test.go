@@ -15,6 +15,8 @@
 package discovery
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"strings"
@@ -22,6 +24,12 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	otelcolextension "go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	otelcolreceiver "go.opentelemetry.io/collector/receiver"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 
@@ -67,6 +75,210 @@ func TestDiscovererDurationFromEnv(t *testing.T) {
 	}, 2*time.Second, time.Millisecond)
 }
 
+func TestDiscovererContinuousFromEnv(t *testing.T) {
+	t.Cleanup(func() func() {
+		initial, ok := os.LookupEnv("SPLUNK_DISCOVERY_CONTINUOUS")
+		os.Unsetenv("SPLUNK_DISCOVERY_CONTINUOUS")
+		return func() {
+			if ok {
+				os.Setenv("SPLUNK_DISCOVERY_CONTINUOUS", initial)
+			} else {
+				os.Unsetenv("SPLUNK_DISCOVERY_CONTINUOUS")
+			}
+		}
+	}())
+	d, err := newDiscoverer(zap.NewNop())
+	require.NoError(t, err)
+	require.False(t, d.continuous)
+
+	os.Setenv("SPLUNK_DISCOVERY_CONTINUOUS", "true")
+	d, err = newDiscoverer(zap.NewNop())
+	require.NoError(t, err)
+	require.True(t, d.continuous)
+	require.False(t, d.propertiesConf.IsSet("continuous"))
+
+	os.Setenv("SPLUNK_DISCOVERY_CONTINUOUS", "invalid")
+	zc, observedLogs := observer.New(zap.DebugLevel)
+	d, err = newDiscoverer(zap.New(zc))
+	require.NoError(t, err)
+	require.False(t, d.continuous)
+	require.Equal(t, 1, observedLogs.FilterMessage("Invalid SPLUNK_DISCOVERY_CONTINUOUS. Using default of false").Len())
+}
+
+func TestDiscovererContinuousConfigChanges(t *testing.T) {
+	d, err := newDiscoverer(zap.NewNop())
+	require.NoError(t, err)
+	d.continuous = true
+	d.cfg = NewConfig(zap.NewNop())
+
+	changes := make(chan *confmap.ChangeEvent, 1)
+	watcher := func(event *confmap.ChangeEvent) { changes <- event }
+	requireDiscovered := func(discovered bool) {
+		discoveryCfg, e := d.discoveryConfig(d.cfg)
+		require.NoError(t, e)
+		conf := confmap.NewFromStringMap(discoveryCfg)
+		require.Equal(t, discovered, conf.IsSet("receivers::receiver_creator/discovery::receivers::redis"))
+		require.Equal(t, discovered, conf.IsSet("service::receivers/splunk.discovery"))
+	}
+	requireChange := func() {
+		select {
+		case event := <-changes:
+			require.NoError(t, event.Error)
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the watcher to be notified")
+		}
+	}
+	requireNoChange := func() {
+		select {
+		case <-changes:
+			t.Fatal("unexpected watcher notification")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	discoveryCfg, running, err := d.continuousDiscoveryConfig(watcher)
+	require.NoError(t, err)
+	require.True(t, running)
+	require.False(t, confmap.NewFromStringMap(discoveryCfg).IsSet("receivers::receiver_creator/discovery::receivers::redis"))
+
+	require.NoError(t, d.ConsumeLogs(context.Background(), statusLogs("endpoint-1", discovery.Partial)))
+	requireNoChange()
+
+	require.NoError(t, d.ConsumeLogs(context.Background(), statusLogs("endpoint-1", discovery.Successful)))
+	requireChange()
+	requireDiscovered(true)
+	d.watch(watcher)
+
+	// a second successful endpoint keeps the receiver once the first one is removed
+	require.NoError(t, d.ConsumeLogs(context.Background(), statusLogs("endpoint-2", discovery.Successful)))
+	require.NoError(t, d.ConsumeLogs(context.Background(), endpointRemovedLogs("endpoint-1")))
+	requireNoChange()
+	requireDiscovered(true)
+
+	// removing an endpoint of another observer has no effect
+	removedLogs := endpointRemovedLogs("endpoint-2")
+	removedLogs.ResourceLogs().At(0).Resource().Attributes().PutStr(discovery.ObserverTypeAttr, "host_observer")
+	require.NoError(t, d.ConsumeLogs(context.Background(), removedLogs))
+	requireNoChange()
+
+	require.NoError(t, d.ConsumeLogs(context.Background(), endpointRemovedLogs("endpoint-2")))
+	requireChange()
+	requireDiscovered(false)
+
+	// changes without an armed watcher are notified once it is armed
+	require.NoError(t, d.ConsumeLogs(context.Background(), statusLogs("endpoint-3", discovery.Successful)))
+	requireNoChange()
+	d.watch(watcher)
+	requireChange()
+	requireDiscovered(true)
+
+	d.shutdown()
+	discoveryCfg, running, err = d.continuousDiscoveryConfig(watcher)
+	require.NoError(t, err)
+	require.False(t, running)
+	require.Nil(t, discoveryCfg)
+}
+
+func TestDiscovererSyncDiscoveryReceivers(t *testing.T) {
+	d, err := newDiscoverer(zap.NewNop())
+	require.NoError(t, err)
+	d.continuous = true
+	dockerObserver := component.MustNewID("docker_observer")
+	d.cfg = NewConfig(zap.NewNop())
+	d.cfg.ReceiversToDiscover[component.MustNewID("redis")] = ReceiverToDiscoverEntry{
+		Rule:   map[component.ID]string{dockerObserver: `type == "container"`},
+		Config: map[component.ID]map[string]any{defaultType: {"endpoint": "`endpoint`"}},
+		Entry: Entry{"status": map[string]any{
+			"metrics": map[string]any{"successful": []any{map[string]any{"strict": "redis.uptime"}}},
+		}},
+	}
+	// the observer isn't an observable so the discovery receivers fail to start, which doesn't matter here
+	nopObserver, err := extensiontest.NewNopFactory().CreateExtension(context.Background(), extensiontest.NewNopCreateSettings(), nil)
+	require.NoError(t, err)
+	d.discoveryObservers = map[component.ID]otelcolextension.Extension{dockerObserver: nopObserver}
+	d.discoveryReceivers = map[component.ID]otelcolreceiver.Logs{}
+	discoveryReceiverID := component.MustNewIDWithName("discovery", "docker_observer")
+	t.Cleanup(d.shutdown)
+
+	// nothing is activated yet, the discovery receivers evaluate every receiver
+	_, running, err := d.continuousDiscoveryConfig(nil)
+	require.NoError(t, err)
+	require.True(t, running)
+	require.Empty(t, d.discoveryReceivers)
+
+	require.NoError(t, d.ConsumeLogs(context.Background(), statusLogs("endpoint-1", discovery.Successful)))
+	_, _, err = d.continuousDiscoveryConfig(nil)
+	require.NoError(t, err)
+	require.Equal(t, map[component.ID]struct{}{component.MustNewID("redis"): {}}, d.excludedReceivers)
+	activated := d.discoveryReceivers[discoveryReceiverID]
+	require.NotNil(t, activated)
+
+	// the discovery receivers are only recreated when the activated receivers change
+	_, _, err = d.continuousDiscoveryConfig(nil)
+	require.NoError(t, err)
+	require.True(t, activated == d.discoveryReceivers[discoveryReceiverID])
+
+	require.NoError(t, d.ConsumeLogs(context.Background(), endpointRemovedLogs("endpoint-1")))
+	_, _, err = d.continuousDiscoveryConfig(nil)
+	require.NoError(t, err)
+	require.Empty(t, d.excludedReceivers)
+	require.NotNil(t, d.discoveryReceivers[discoveryReceiverID])
+	require.False(t, activated == d.discoveryReceivers[discoveryReceiverID])
+}
+
+func TestDiscovererConsumeStatusLogs(t *testing.T) {
+	for _, eventType := range []string{"metric.match", "statement.match"} {
+		t.Run(eventType, func(t *testing.T) {
+			d, err := newDiscoverer(zap.NewNop())
+			require.NoError(t, err)
+			cfg := NewConfig(zap.NewNop())
+
+			ld := statusLogs("endpoint-1", discovery.Successful)
+			ld.ResourceLogs().At(0).Resource().Attributes().PutStr(discovery.EventTypeAttr, eventType)
+			require.NoError(t, d.ConsumeLogs(context.Background(), ld))
+
+			// endpoint events aren't receiver statuses
+			added := endpointRemovedLogs("endpoint-2")
+			added.ResourceLogs().At(0).Resource().Attributes().PutStr(discovery.EventTypeAttr, "endpoint.added")
+			require.NoError(t, d.ConsumeLogs(context.Background(), added))
+
+			require.Equal(t, discovery.Successful, d.discoveredReceivers[component.MustNewID("redis")])
+			discoveryCfg, err := d.discoveryConfig(cfg)
+			require.NoError(t, err)
+			require.True(t, confmap.NewFromStringMap(discoveryCfg).IsSet("receivers::receiver_creator/discovery::receivers::redis"))
+		})
+	}
+}
+
+func statusLogs(endpointID string, status discovery.StatusType) plog.Logs {
+	ld := plog.NewLogs()
+	rAttrs := ld.ResourceLogs().AppendEmpty().Resource().Attributes()
+	// as emitted by the discovery receiver's metric evaluator
+	rAttrs.PutStr(discovery.EventTypeAttr, "metric.match")
+	rAttrs.PutStr(discovery.ReceiverTypeAttr, "redis")
+	rAttrs.PutStr(discovery.ObserverIDAttr, "docker_observer")
+	rAttrs.PutStr(discovery.EndpointIDAttr, endpointID)
+	rAttrs.PutStr(discovery.ReceiverConfigAttr, base64.StdEncoding.EncodeToString([]byte(
+		"receivers:\n  redis:\n    rule: type == \"container\"\n    config:\n      endpoint: '`endpoint`'\n",
+	)))
+	lr := ld.ResourceLogs().At(0).ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.Body().SetStr(fmt.Sprintf("redis %s", status))
+	lr.Attributes().PutStr(discovery.StatusAttr, string(status))
+	return ld
+}
+
+func endpointRemovedLogs(endpointID string) plog.Logs {
+	ld := plog.NewLogs()
+	rAttrs := ld.ResourceLogs().AppendEmpty().Resource().Attributes()
+	rAttrs.PutStr(discovery.EventTypeAttr, "endpoint.removed")
+	rAttrs.PutStr(discovery.ObserverTypeAttr, "docker_observer")
+	rAttrs.PutStr(discovery.ObserverNameAttr, "")
+	lr := ld.ResourceLogs().At(0).ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.Body().SetStr(fmt.Sprintf("endpoint.removed container endpoint %s", endpointID))
+	lr.Attributes().PutStr("id", endpointID)
+	return ld
+}
+
 func TestDetermineCurrentStatus(t *testing.T) {
 	for _, test := range []struct {
 		current, observed, expected discovery.StatusType
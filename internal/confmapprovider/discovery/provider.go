@@ -52,6 +52,7 @@ type Provider interface {
 
 type providerShim struct {
 	retrieve func(ctx context.Context, uri string, watcher confmap.WatcherFunc) (*confmap.Retrieved, error)
+	shutdown func()
 	scheme   string
 }
 
@@ -64,6 +65,9 @@ func (p providerShim) Scheme() string {
 }
 
 func (p providerShim) Shutdown(context.Context) error {
+	if p.shutdown != nil {
+		p.shutdown()
+	}
 	return nil
 }
 
@@ -106,6 +110,7 @@ func (m *mapProvider) DiscoveryModeProvider() confmap.Provider {
 	return &providerShim{
 		scheme:   m.DiscoveryModeScheme(),
 		retrieve: m.retrieve(m.DiscoveryModeScheme()),
+		shutdown: m.discoverer.shutdown,
 	}
 }
 
@@ -124,7 +129,7 @@ func (m *mapProvider) PropertiesFileProvider() confmap.Provider {
 }
 
 func (m *mapProvider) retrieve(scheme string) func(context.Context, string, confmap.WatcherFunc) (*confmap.Retrieved, error) {
-	return func(ctx context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	return func(ctx context.Context, uri string, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
 		schemePrefix := fmt.Sprintf("%s:", scheme)
 		if !strings.HasPrefix(uri, schemePrefix) {
 			return nil, fmt.Errorf("uri %q is not supported by %s provider", uri, scheme)
@@ -175,6 +180,16 @@ func (m *mapProvider) retrieve(scheme string) func(context.Context, string, conf
 			// introduced repeated config resolution call so we need to memoize the provider to avoid
 			// duplicate loading. TODO: expand this to be uri based for all providers
 			if m.retrieved != nil {
+				// in continuous mode the discovery config is kept current with the running discovery
+				if m.discoverer.continuous {
+					discoveryCfg, running, err := m.discoverer.continuousDiscoveryConfig(watcher)
+					if err != nil {
+						return nil, err
+					}
+					if running {
+						return confmap.NewRetrieved(discoveryCfg)
+					}
+				}
 				return m.retrieved, nil
 			}
 			var bundledCfg *Config
@@ -195,8 +210,13 @@ func (m *mapProvider) retrieve(scheme string) func(context.Context, string, conf
 			if err != nil {
 				return nil, fmt.Errorf("failed to successfully discover target services: %w", err)
 			}
-			m.retrieved, err = confmap.NewRetrieved(discoveryCfg)
-			return m.retrieved, err
+			if m.retrieved, err = confmap.NewRetrieved(discoveryCfg); err != nil {
+				return nil, err
+			}
+			if m.discoverer.continuous {
+				m.discoverer.watch(watcher)
+			}
+			return m.retrieved, nil
 		}
 
 		return nil, fmt.Errorf("unsupported %s scheme %q", scheme, uri)
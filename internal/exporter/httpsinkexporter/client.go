@@ -18,66 +18,123 @@ package httpsinkexporter
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/jaegertracing/jaeger/model"
+	jaegertranslator "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/jaeger"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
 )
 
 type client struct {
-	spans   chan *model.Batch
-	metrics chan pmetric.Metrics
-	opts    options
-	stopped bool
+	opts      options
+	telemetry *sinkTelemetry
+	// retained holds the telemetry received by the sink before the client was registered.
+	retained []any
+	// queue buffers the telemetry received while the client is registered.
+	queue      chan any
+	dropOldest bool
+	// dropped is the number of batches dropped because the queue was full.
+	dropped  atomic.Uint64
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
-func newClient(opts options) *client {
+func newClient(opts options, queueCfg ClientQueueConfig, telemetry *sinkTelemetry) *client {
 	return &client{
-		spans:   make(chan *model.Batch),
-		metrics: make(chan pmetric.Metrics),
-		opts:    opts,
+		opts:       opts,
+		telemetry:  telemetry,
+		queue:      make(chan any, queueCfg.Size),
+		dropOldest: queueCfg.DropPolicy == dropPolicyOldest,
+		done:       make(chan struct{}),
 	}
 }
 
+// offer queues the telemetry for the client without blocking. When the queue is full,
+// either the given telemetry or the oldest queued one is dropped depending on the drop policy.
+func (c *client) offer(data any) {
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	select {
+	case c.queue <- data:
+		return
+	default:
+	}
+
+	if c.dropOldest {
+		select {
+		case evicted := <-c.queue:
+			c.drop(evicted)
+		default:
+		}
+		select {
+		case c.queue <- data:
+			return
+		default:
+		}
+	}
+	c.drop(data)
+}
+
+func (c *client) drop(data any) {
+	c.dropped.Add(1)
+	c.telemetry.recordDropped(c.opts.dataType, data)
+}
+
+// stop marks the client as no longer accepting telemetry.
+func (c *client) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
 func (c *client) response(ctx context.Context) ([]byte, error) {
-	// TODO: add support to filter spans by attributes
-	defer func() {
-		c.stopped = true
-	}()
+	defer c.stop()
 
 	results := []string{}
-	received := 0
+	received, live := 0, 0
+
+	// Retained history is replayed to every new client, so only the items received
+	// while the request was waiting are recorded as delivered.
+	consume := func(data any, replayed bool) (bool, error) {
+		rendered, count, err := c.render(data)
+		if err != nil {
+			return false, err
+		}
+		results = append(results, rendered...)
+		received += count
+		if !replayed {
+			live += count
+		}
+		return received >= c.opts.count, nil
+	}
+
+	for _, data := range c.retained {
+		if done, err := consume(data, true); err != nil || done {
+			return c.result(results), err
+		}
+	}
 
-	done := make(chan struct{})
 	for {
 		select {
-		case batch := <-c.spans:
-			batch = c.filterSpans(batch)
-			for _, span := range batch.Spans {
-				json, err := spanMarshaler.MarshalToString(span)
-				if err != nil {
-					return nil, err
-				}
-				results = append(results, json)
-				received++
-				if received == c.opts.count {
-					close(done)
-				}
+		case data := <-c.queue:
+			done, err := consume(data, false)
+			if err != nil {
+				return nil, err
 			}
-		case md := <-c.metrics:
-			md = c.filterMetrics(md)
-			count := md.MetricCount()
-			if count > 0 {
-				json, err := metricMarshaler.MarshalMetrics(md)
-				if err != nil {
-					return nil, err
-				}
-				results = append(results, string(json))
-				received += count
-			}
-			if received >= c.opts.count {
-				close(done)
+			if done {
+				c.telemetry.recordDelivered(c.opts.dataType, live)
+				return c.result(results), nil
 			}
 
 		case <-time.After(c.opts.timeout):
@@ -85,28 +142,116 @@ func (c *client) response(ctx context.Context) ([]byte, error) {
 
 		case <-ctx.Done():
 			return nil, fmt.Errorf("context deadline exceeded")
+		}
+	}
+}
+
+func (c *client) result(results []string) []byte {
+	return []byte("[" + strings.Join(results, ",") + "]")
+}
+
+// render filters the given telemetry and encodes the matching items as JSON.
+// It returns the encoded results and the number of matching items.
+func (c *client) render(data any) ([]string, int, error) {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		return c.renderSpans(d)
+
+	case pmetric.Metrics:
+		md := c.filterMetrics(d)
+		count := md.MetricCount()
+		if count == 0 {
+			return nil, 0, nil
+		}
+		json, err := metricMarshaler.MarshalMetrics(md)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []string{string(json)}, count, nil
+
+	case plog.Logs:
+		ld := c.filterLogs(d)
+		count := ld.LogRecordCount()
+		if count == 0 {
+			return nil, 0, nil
+		}
+		json, err := logMarshaler.MarshalLogs(ld)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []string{string(json)}, count, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %T", data)
+}
+
+func (c *client) renderSpans(td ptrace.Traces) ([]string, int, error) {
+	td = c.filterSpans(td)
+	count := td.SpanCount()
+	if count == 0 {
+		return nil, 0, nil
+	}
 
-		case <-done:
-			result := "[" + strings.Join(results, ",") + "]"
-			return []byte(result), nil
+	if c.opts.spansFormat == spansFormatOTLP {
+		json, err := tracesMarshaler.MarshalTraces(td)
+		if err != nil {
+			return nil, 0, err
 		}
+		return []string{string(json)}, count, nil
 	}
+
+	batches, err := jaegertranslator.ProtoFromTraces(td)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]string, 0, count)
+	for _, batch := range batches {
+		for _, span := range batch.Spans {
+			json, err := spanMarshaler.MarshalToString(span)
+			if err != nil {
+				return nil, 0, err
+			}
+			results = append(results, json)
+		}
+	}
+	return results, count, nil
 }
 
-func (c *client) filterSpans(b *model.Batch) *model.Batch {
-	spans := []*model.Span{}
+func (c *client) filterSpans(td ptrace.Traces) ptrace.Traces {
+	t := ptrace.NewTraces()
+	td.CopyTo(t)
+	t.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		if !c.filterResource(rs.Resource()) {
+			return true
+		}
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				return !c.filterSpan(span)
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return t
+}
 
-	for _, span := range b.Spans {
-		match := c.filterByName(span.OperationName)
-		if match {
-			spans = append(spans, span)
+func (c *client) filterResource(res pcommon.Resource) bool {
+	if len(c.opts.services) > 0 {
+		serviceName, ok := res.Attributes().Get(conventions.AttributeServiceName)
+		if !ok || !slices.Contains(c.opts.services, serviceName.AsString()) {
+			return false
 		}
 	}
+	return matchAttrs(res.Attributes(), c.opts.resourceAttrs)
+}
 
-	return &model.Batch{
-		Process: b.Process,
-		Spans:   spans,
+func (c *client) filterSpan(span ptrace.Span) bool {
+	if len(c.opts.spanKinds) > 0 && !slices.Contains(c.opts.spanKinds, span.Kind()) {
+		return false
 	}
+	if len(c.opts.statusCodes) > 0 && !slices.Contains(c.opts.statusCodes, span.Status().Code()) {
+		return false
+	}
+	return c.filterByName(span.Name()) && matchAttrs(span.Attributes(), c.opts.attrs)
 }
 
 func (c *client) filterMetrics(md pmetric.Metrics) pmetric.Metrics {
@@ -125,6 +270,48 @@ func (c *client) filterMetrics(md pmetric.Metrics) pmetric.Metrics {
 	return m
 }
 
+func (c *client) filterLogs(ld plog.Logs) plog.Logs {
+	l := plog.NewLogs()
+	ld.CopyTo(l)
+	l.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				return !c.filterLogRecord(lr)
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	return l
+}
+
+func (c *client) filterLogRecord(lr plog.LogRecord) bool {
+	if len(c.opts.severities) > 0 {
+		matched := false
+		for _, severity := range c.opts.severities {
+			if strings.EqualFold(lr.SeverityText(), severity) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if c.opts.body != "" || c.opts.bodyRegex != nil {
+		body := lr.Body().AsString()
+		if c.opts.body != "" && !strings.Contains(body, c.opts.body) {
+			return false
+		}
+		if c.opts.bodyRegex != nil && !c.opts.bodyRegex.MatchString(body) {
+			return false
+		}
+	}
+
+	return matchAttrs(lr.Attributes(), c.opts.attrs)
+}
+
 func (c *client) filterByName(name string) bool {
 	if len(c.opts.names) == 0 {
 		return true
@@ -161,3 +348,14 @@ func (c *client) filterMetricByAttrs(metric pmetric.Metric) bool {
 	}
 	return false
 }
+
+// matchAttrs returns true if all the expected attributes are present with the expected values.
+func matchAttrs(attrs pcommon.Map, expected map[string]string) bool {
+	for k, v := range expected {
+		val, found := attrs.Get(k)
+		if !found || val.AsString() != v {
+			return false
+		}
+	}
+	return true
+}
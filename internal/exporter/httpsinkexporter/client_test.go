@@ -0,0 +1,361 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsinkexporter
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newTestClient(t *testing.T, opts options) *client {
+	telemetry, err := newSinkTelemetry(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	return newClient(opts, createDefaultConfig().(*Config).ClientQueue, telemetry)
+}
+
+func testLogs() plog.Logs {
+	ld := plog.NewLogs()
+	lrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+
+	lr := lrs.AppendEmpty()
+	lr.Body().SetStr("failed to connect to database")
+	lr.SetSeverityText("ERROR")
+	lr.Attributes().PutStr("log.file.name", "app.log")
+
+	lr = lrs.AppendEmpty()
+	lr.Body().SetStr("connected to database")
+	lr.SetSeverityText("INFO")
+	lr.Attributes().PutStr("log.file.name", "app.log")
+
+	lr = lrs.AppendEmpty()
+	lr.Body().SetStr("failed to open file")
+	lr.SetSeverityText("ERROR")
+	lr.Attributes().PutStr("log.file.name", "other.log")
+	return ld
+}
+
+func TestFilterLogs(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "no filters",
+			query:    "",
+			expected: []string{"failed to connect to database", "connected to database", "failed to open file"},
+		},
+		{
+			name:     "body substring",
+			query:    "body=database",
+			expected: []string{"failed to connect to database", "connected to database"},
+		},
+		{
+			name:     "body regex",
+			query:    "body_regex=^failed",
+			expected: []string{"failed to connect to database", "failed to open file"},
+		},
+		{
+			name:     "severity",
+			query:    "severity=info",
+			expected: []string{"connected to database"},
+		},
+		{
+			name:     "attributes and severity",
+			query:    "severity=ERROR&attr=log.file.name=app.log",
+			expected: []string{"failed to connect to database"},
+		},
+		{
+			name:     "no match",
+			query:    "severity=WARN",
+			expected: []string{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseOptions(httptest.NewRequest("GET", "/logs?"+tt.query, nil))
+			require.NoError(t, err)
+
+			ld := newTestClient(t, opts).filterLogs(testLogs())
+			bodies := []string{}
+			for i := 0; i < ld.ResourceLogs().Len(); i++ {
+				sls := ld.ResourceLogs().At(i).ScopeLogs()
+				for j := 0; j < sls.Len(); j++ {
+					lrs := sls.At(j).LogRecords()
+					for k := 0; k < lrs.Len(); k++ {
+						bodies = append(bodies, lrs.At(k).Body().AsString())
+					}
+				}
+			}
+			assert.Equal(t, tt.expected, bodies)
+		})
+	}
+}
+
+func TestParseOptionsInvalidBodyRegex(t *testing.T) {
+	_, err := parseOptions(httptest.NewRequest("GET", "/logs?body_regex=(", nil))
+	assert.ErrorContains(t, err, "body_regex")
+}
+
+func TestClientLogsResponse(t *testing.T) {
+	opts, err := parseOptions(httptest.NewRequest("GET", "/logs?count=2&severity=ERROR", nil))
+	require.NoError(t, err)
+	c := newTestClient(t, opts)
+	c.offer(testLogs())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := c.response(ctx)
+	require.NoError(t, err)
+
+	ld, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(result[1 : len(result)-1])
+	require.NoError(t, err)
+	assert.Equal(t, 2, ld.LogRecordCount())
+}
+
+func testTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "users-api")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+
+	span := spans.AppendEmpty()
+	span.SetName("GET")
+	span.SetTraceID([16]byte{1})
+	span.SetSpanID([8]byte{1})
+	span.SetKind(ptrace.SpanKindServer)
+	span.Attributes().PutStr("http.route", "/users")
+
+	span = spans.AppendEmpty()
+	span.SetName("GET")
+	span.SetTraceID([16]byte{1})
+	span.SetSpanID([8]byte{2})
+	span.SetKind(ptrace.SpanKindServer)
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Attributes().PutStr("http.route", "/health")
+
+	span = spans.AppendEmpty()
+	span.SetName("SELECT")
+	span.SetTraceID([16]byte{1})
+	span.SetSpanID([8]byte{3})
+	span.SetKind(ptrace.SpanKindClient)
+
+	rs = td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "billing")
+	rs.Resource().Attributes().PutStr("deployment.environment", "test")
+	span = rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("GET")
+	span.SetTraceID([16]byte{2})
+	span.SetSpanID([8]byte{4})
+	span.SetKind(ptrace.SpanKindServer)
+	span.Attributes().PutStr("http.route", "/users")
+	return td
+}
+
+func TestFilterSpans(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "no filters",
+			query:    "",
+			expected: []string{"01", "02", "03", "04"},
+		},
+		{
+			name:     "attributes",
+			query:    "attr=http.route=/users",
+			expected: []string{"01", "04"},
+		},
+		{
+			name:     "service name",
+			query:    "service=users-api&attr=http.route=/users",
+			expected: []string{"01"},
+		},
+		{
+			name:     "resource attributes",
+			query:    "resource_attr=deployment.environment=test",
+			expected: []string{"04"},
+		},
+		{
+			name:     "span kind",
+			query:    "kind=client&kind=SPAN_KIND_INTERNAL",
+			expected: []string{"03"},
+		},
+		{
+			name:     "status code",
+			query:    "status=error",
+			expected: []string{"02"},
+		},
+		{
+			name:     "name and status code",
+			query:    "name=GET&status=unset&service=billing",
+			expected: []string{"04"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseOptions(httptest.NewRequest("GET", "/spans?"+tt.query, nil))
+			require.NoError(t, err)
+
+			td := newTestClient(t, opts).filterSpans(testTraces())
+			spanIDs := []string{}
+			for i := 0; i < td.ResourceSpans().Len(); i++ {
+				sss := td.ResourceSpans().At(i).ScopeSpans()
+				for j := 0; j < sss.Len(); j++ {
+					spans := sss.At(j).Spans()
+					for k := 0; k < spans.Len(); k++ {
+						spanID := spans.At(k).SpanID()
+						spanIDs = append(spanIDs, hex.EncodeToString(spanID[:1]))
+					}
+				}
+			}
+			assert.Equal(t, tt.expected, spanIDs)
+		})
+	}
+}
+
+func TestParseOptionsInvalidSpanFilters(t *testing.T) {
+	_, err := parseOptions(httptest.NewRequest("GET", "/spans?kind=sideways", nil))
+	assert.EqualError(t, err, `kind query string parameter "sideways" is not a valid span kind`)
+
+	_, err = parseOptions(httptest.NewRequest("GET", "/spans?status=maybe", nil))
+	assert.EqualError(t, err, `status query string parameter "maybe" is not a valid status code`)
+
+	_, err = parseOptions(httptest.NewRequest("GET", "/spans?resource_attr=service.name", nil))
+	assert.EqualError(t, err, "resource_attr query string parameter is not formatted correctly")
+}
+
+func TestClientSpansResponse(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "jaeger", query: "count=3&name=GET", expected: 3},
+		{name: "otlp", query: "count=3&name=GET&format=otlp", expected: 3},
+		{name: "otlp attributes", query: "name=GET&attr=http.route=/users&service=users-api&format=otlp", expected: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseOptions(httptest.NewRequest("GET", "/spans?"+tt.query, nil))
+			require.NoError(t, err)
+			if opts.spansFormat == "" {
+				opts.spansFormat = spansFormatJaeger
+			}
+			c := newTestClient(t, opts)
+			c.offer(testTraces())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			result, err := c.response(ctx)
+			require.NoError(t, err)
+
+			if opts.spansFormat == spansFormatJaeger {
+				var spans []map[string]any
+				require.NoError(t, json.Unmarshal(result, &spans))
+				assert.Len(t, spans, tt.expected)
+				return
+			}
+
+			td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(result[1 : len(result)-1])
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, td.SpanCount())
+			serviceName, ok := td.ResourceSpans().At(0).Resource().Attributes().Get("service.name")
+			assert.True(t, ok)
+			assert.Equal(t, "users-api", serviceName.Str())
+		})
+	}
+}
+
+func TestParseOptionsInvalidFormat(t *testing.T) {
+	_, err := parseOptions(httptest.NewRequest("GET", "/spans?format=zipkin", nil))
+	assert.ErrorContains(t, err, "unsupported spans format")
+}
+
+func TestClientOffer(t *testing.T) {
+	for _, tt := range []struct {
+		dropPolicy string
+		expected   []any
+	}{
+		{dropPolicy: dropPolicyNewest, expected: []any{0, 1, 2}},
+		{dropPolicy: dropPolicyOldest, expected: []any{2, 3, 4}},
+	} {
+		t.Run(tt.dropPolicy, func(t *testing.T) {
+			telemetry, err := newSinkTelemetry(componenttest.NewNopTelemetrySettings())
+			require.NoError(t, err)
+			c := newClient(options{dataType: typeLogs}, ClientQueueConfig{Size: 3, DropPolicy: tt.dropPolicy}, telemetry)
+
+			for i := 0; i < 5; i++ {
+				c.offer(i)
+			}
+			assert.Equal(t, uint64(2), c.dropped.Load())
+
+			var queued []any
+			for len(c.queue) > 0 {
+				queued = append(queued, <-c.queue)
+			}
+			assert.Equal(t, tt.expected, queued)
+
+			c.stop()
+			c.stop()
+			c.offer(5)
+			assert.Empty(t, c.queue)
+			assert.Equal(t, uint64(2), c.dropped.Load())
+		})
+	}
+}
+
+func TestClientRecordsDeliveredItems(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	set := componenttest.NewNopTelemetrySettings()
+	set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	telemetry, err := newSinkTelemetry(set)
+	require.NoError(t, err)
+
+	opts, err := parseOptions(httptest.NewRequest("GET", "/logs?count=4&severity=ERROR", nil))
+	require.NoError(t, err)
+	c := newClient(opts, createDefaultConfig().(*Config).ClientQueue, telemetry)
+	c.retained = []any{testLogs()}
+	c.offer(testLogs())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.response(ctx)
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name == "exporter_httpsink_delivered_items" {
+			sum := m.Data.(metricdata.Sum[int64])
+			require.Len(t, sum.DataPoints, 1)
+			assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+			return
+		}
+	}
+	t.Fatal("exporter_httpsink_delivered_items not recorded")
+}
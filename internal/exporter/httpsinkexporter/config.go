@@ -17,13 +17,55 @@ package httpsinkexporter
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 )
 
+const (
+	spansFormatJaeger = "jaeger"
+	spansFormatOTLP   = "otlp"
+
+	dropPolicyNewest = "drop_newest"
+	dropPolicyOldest = "drop_oldest"
+)
+
 // Config defines configuration for file exporter.
 type Config struct {
 	Endpoint string `mapstructure:"endpoint"`
+	// SpansFormat is the default encoding of spans returned by the /spans endpoint.
+	// Can be overridden per request with the "format" query string parameter.
+	// Supported values are "jaeger" and "otlp".
+	SpansFormat string `mapstructure:"spans_format"`
+	// History configures the telemetry retained by the exporter so that requests
+	// can match data received before they were made.
+	History HistoryConfig `mapstructure:"history"`
+	// ClientQueue configures the telemetry buffered for each client.
+	ClientQueue ClientQueueConfig `mapstructure:"client_queue"`
+}
+
+// HistoryConfig defines how much of the received telemetry is retained.
+type HistoryConfig struct {
+	// MaxBatches is the number of received batches retained per data type.
+	// Retention is disabled when set to 0.
+	MaxBatches int `mapstructure:"max_batches"`
+	// MaxItems is the number of items (spans, metrics or log records) retained
+	// per data type. The oldest batches are dropped once it is exceeded, batches
+	// with more items are not retained at all. No limit is applied when set to 0.
+	MaxItems int `mapstructure:"max_items"`
+	// MaxAge is the maximum age of the retained batches. Batches are retained
+	// until MaxBatches is reached when set to 0.
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// ClientQueueConfig defines how the telemetry is buffered for each client.
+type ClientQueueConfig struct {
+	// Size is the number of received batches buffered for each client.
+	Size int `mapstructure:"size"`
+	// DropPolicy defines which batches are dropped when a client queue is full.
+	// Supported values are "drop_newest" and "drop_oldest".
+	DropPolicy string `mapstructure:"drop_policy"`
 }
 
 var _ component.Config = (*Config)(nil)
@@ -34,5 +76,39 @@ func (cfg *Config) Validate() error {
 		return errors.New("endpoint must not be empty")
 	}
 
+	if err := validateSpansFormat(cfg.SpansFormat); err != nil {
+		return err
+	}
+
+	if cfg.History.MaxBatches < 0 {
+		return errors.New("history max_batches must not be negative")
+	}
+
+	if cfg.History.MaxItems < 0 {
+		return errors.New("history max_items must not be negative")
+	}
+
+	if cfg.History.MaxAge < 0 {
+		return errors.New("history max_age must not be negative")
+	}
+
+	if cfg.ClientQueue.Size <= 0 {
+		return errors.New("client_queue size must be positive")
+	}
+
+	switch cfg.ClientQueue.DropPolicy {
+	case dropPolicyNewest, dropPolicyOldest:
+	default:
+		return fmt.Errorf("unsupported client_queue drop_policy %q. only %q and %q are supported", cfg.ClientQueue.DropPolicy, dropPolicyNewest, dropPolicyOldest)
+	}
+
 	return nil
 }
+
+func validateSpansFormat(format string) error {
+	switch format {
+	case spansFormatJaeger, spansFormatOTLP:
+		return nil
+	}
+	return fmt.Errorf("unsupported spans format %q. only %q and %q are supported", format, spansFormatJaeger, spansFormatOTLP)
+}
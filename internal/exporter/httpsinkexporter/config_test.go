@@ -17,6 +17,7 @@ package httpsinkexporter
 import (
 	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,6 +46,44 @@ func TestLoadConfig(t *testing.T) {
 
 	assert.Equal(t,
 		&Config{
-			Endpoint: "localhost:3333",
+			Endpoint:    "localhost:3333",
+			SpansFormat: spansFormatOTLP,
+			History: HistoryConfig{
+				MaxBatches: 100,
+				MaxItems:   5000,
+				MaxAge:     5 * time.Minute,
+			},
+			ClientQueue: ClientQueueConfig{
+				Size:       10,
+				DropPolicy: dropPolicyOldest,
+			},
 		}, e1)
 }
+
+func TestConfigValidate(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SpansFormat = "zipkin"
+	assert.EqualError(t, cfg.Validate(), `unsupported spans format "zipkin". only "jaeger" and "otlp" are supported`)
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.Endpoint = ""
+	assert.EqualError(t, cfg.Validate(), "endpoint must not be empty")
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.History.MaxBatches = -1
+	assert.EqualError(t, cfg.Validate(), "history max_batches must not be negative")
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.History.MaxItems = -1
+	assert.EqualError(t, cfg.Validate(), "history max_items must not be negative")
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.ClientQueue.Size = 0
+	assert.EqualError(t, cfg.Validate(), "client_queue size must be positive")
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.ClientQueue.DropPolicy = "block"
+	assert.EqualError(t, cfg.Validate(), `unsupported client_queue drop_policy "block". only "drop_newest" and "drop_oldest" are supported`)
+}
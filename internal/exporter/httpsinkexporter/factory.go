@@ -27,6 +27,9 @@ const (
 	// The value of "type" key in configuration.
 	typeStr         = "httpsink"
 	defaultEndpoint = "localhost:8378"
+
+	defaultClientQueueSize = 1000
+	defaultHistoryMaxItems = 100000
 )
 
 // NewFactory creates a factory for httpsink exporter.
@@ -36,12 +39,21 @@ func NewFactory() exporter.Factory {
 		createDefaultConfig,
 		exporter.WithMetrics(createMetricsExporter, component.StabilityLevelDevelopment),
 		exporter.WithTraces(createTracesExporter, component.StabilityLevelDevelopment),
+		exporter.WithLogs(createLogsExporter, component.StabilityLevelDevelopment),
 	)
 }
 
 func createDefaultConfig() component.Config {
 	return &Config{
-		Endpoint: defaultEndpoint,
+		Endpoint:    defaultEndpoint,
+		SpansFormat: spansFormatJaeger,
+		History: HistoryConfig{
+			MaxItems: defaultHistoryMaxItems,
+		},
+		ClientQueue: ClientQueueConfig{
+			Size:       defaultClientQueueSize,
+			DropPolicy: dropPolicyNewest,
+		},
 	}
 }
 
@@ -49,7 +61,10 @@ func createTracesExporter(ctx context.Context,
 	set exporter.CreateSettings,
 	cfg component.Config,
 ) (exporter.Traces, error) {
-	exp := newExporter(set.Logger, cfg.(*Config).Endpoint)
+	exp, err := newExporter(set.TelemetrySettings, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
 	return exporterhelper.NewTracesExporter(
 		ctx,
 		set,
@@ -64,7 +79,10 @@ func createMetricsExporter(ctx context.Context,
 	set exporter.CreateSettings,
 	cfg component.Config,
 ) (exporter.Metrics, error) {
-	exp := newExporter(set.Logger, cfg.(*Config).Endpoint)
+	exp, err := newExporter(set.TelemetrySettings, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
 	return exporterhelper.NewMetricsExporter(
 		ctx,
 		set,
@@ -74,3 +92,21 @@ func createMetricsExporter(ctx context.Context,
 		exporterhelper.WithShutdown(exp.Shutdown),
 	)
 }
+
+func createLogsExporter(ctx context.Context,
+	set exporter.CreateSettings,
+	cfg component.Config,
+) (exporter.Logs, error) {
+	exp, err := newExporter(set.TelemetrySettings, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return exporterhelper.NewLogsExporter(
+		ctx,
+		set,
+		cfg,
+		exp.ConsumeLogs,
+		exporterhelper.WithStart(exp.Start),
+		exporterhelper.WithShutdown(exp.Shutdown),
+	)
+}
@@ -0,0 +1,104 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsinkexporter
+
+import (
+	"time"
+)
+
+type historyEntry struct {
+	received time.Time
+	data     any
+	items    int
+}
+
+// history is a ring buffer retaining the most recently received batches of a
+// single data type, bounded by the number of batches, their items and their age.
+type history struct {
+	entries  []historyEntry
+	maxAge   time.Duration
+	maxItems int
+	items    int
+	head     int
+	size     int
+}
+
+func newHistory(cfg HistoryConfig) *history {
+	return &history{
+		entries:  make([]historyEntry, cfg.MaxBatches),
+		maxAge:   cfg.MaxAge,
+		maxItems: cfg.MaxItems,
+	}
+}
+
+func (h *history) add(now time.Time, data any) {
+	if len(h.entries) == 0 {
+		return
+	}
+	h.evict(now)
+	items := itemCount(data)
+	if h.maxItems > 0 && items > h.maxItems {
+		return
+	}
+	if h.size == len(h.entries) {
+		h.dropOldest()
+	}
+	for h.maxItems > 0 && h.items+items > h.maxItems {
+		h.dropOldest()
+	}
+	tail := (h.head + h.size) % len(h.entries)
+	h.entries[tail] = historyEntry{received: now, data: data, items: items}
+	h.items += items
+	h.size++
+}
+
+// snapshot returns the retained batches received at or after since, oldest first.
+func (h *history) snapshot(now, since time.Time) []any {
+	h.evict(now)
+	var data []any
+	for i := 0; i < h.size; i++ {
+		entry := h.entries[(h.head+i)%len(h.entries)]
+		if !entry.received.Before(since) {
+			data = append(data, entry.data)
+		}
+	}
+	return data
+}
+
+func (h *history) clear() {
+	for i := range h.entries {
+		h.entries[i] = historyEntry{}
+	}
+	h.head = 0
+	h.size = 0
+	h.items = 0
+}
+
+// evict drops the batches older than maxAge.
+func (h *history) evict(now time.Time) {
+	if h.maxAge <= 0 {
+		return
+	}
+	for h.size > 0 && now.Sub(h.entries[h.head].received) > h.maxAge {
+		h.dropOldest()
+	}
+}
+
+func (h *history) dropOldest() {
+	h.items -= h.entries[h.head].items
+	h.entries[h.head] = historyEntry{}
+	h.head = (h.head + 1) % len(h.entries)
+	h.size--
+}
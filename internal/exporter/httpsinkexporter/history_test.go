@@ -0,0 +1,108 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsinkexporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	now := time.Now()
+
+	h := newHistory(HistoryConfig{MaxBatches: 3})
+	for i := 0; i < 5; i++ {
+		h.add(now.Add(time.Duration(i)*time.Second), i)
+	}
+	assert.Equal(t, []any{2, 3, 4}, h.snapshot(now.Add(5*time.Second), time.Time{}))
+	assert.Equal(t, []any{3, 4}, h.snapshot(now.Add(5*time.Second), now.Add(3*time.Second)))
+
+	h.clear()
+	assert.Empty(t, h.snapshot(now, time.Time{}))
+
+	h = newHistory(HistoryConfig{MaxBatches: 10, MaxAge: 2 * time.Second})
+	for i := 0; i < 5; i++ {
+		h.add(now.Add(time.Duration(i)*time.Second), i)
+	}
+	assert.Equal(t, []any{3, 4}, h.snapshot(now.Add(5*time.Second), time.Time{}))
+
+	h = newHistory(HistoryConfig{})
+	h.add(now, 1)
+	assert.Empty(t, h.snapshot(now, time.Time{}))
+}
+
+func TestHistoryMaxItems(t *testing.T) {
+	now := time.Now()
+
+	// Every batch of test logs holds 3 log records.
+	h := newHistory(HistoryConfig{MaxBatches: 10, MaxItems: 7})
+	for i := 0; i < 3; i++ {
+		h.add(now, testLogs())
+	}
+	assert.Len(t, h.snapshot(now, time.Time{}), 2)
+	assert.Equal(t, 6, h.items)
+
+	h = newHistory(HistoryConfig{MaxBatches: 10, MaxItems: 2})
+	h.add(now, testLogs())
+	assert.Empty(t, h.snapshot(now, time.Time{}))
+	assert.Equal(t, 0, h.items)
+
+	h = newHistory(HistoryConfig{MaxBatches: 2, MaxItems: 100})
+	for i := 0; i < 3; i++ {
+		h.add(now, testLogs())
+	}
+	assert.Len(t, h.snapshot(now, time.Time{}), 2)
+	assert.Equal(t, 6, h.items)
+
+	h.clear()
+	assert.Equal(t, 0, h.items)
+}
+
+func TestSinkRetainedLogs(t *testing.T) {
+	s := newTestSink(t, &Config{
+		Endpoint:    t.Name(),
+		SpansFormat: spansFormatJaeger,
+		History:     HistoryConfig{MaxBatches: 10},
+		ClientQueue: createDefaultConfig().(*Config).ClientQueue,
+	})
+
+	s.publish(typeLogs, testLogs())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	s.handle(rec, httptest.NewRequest(http.MethodGet, "/logs?count=3", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	since := time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+	rec = httptest.NewRecorder()
+	s.handle(rec, httptest.NewRequest(http.MethodGet, "/logs?timeout=1&since="+since, nil).WithContext(ctx))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handle(rec, httptest.NewRequest(http.MethodDelete, "/logs", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handle(rec, httptest.NewRequest(http.MethodGet, "/logs?timeout=1", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
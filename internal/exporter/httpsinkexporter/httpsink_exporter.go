@@ -19,55 +19,48 @@ import (
 	"context"
 
 	"github.com/gogo/protobuf/jsonpb"
-	"github.com/jaegertracing/jaeger/model"
-	jaegertranslator "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/jaeger"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
-	"go.uber.org/zap"
 )
 
 var spanMarshaler = &jsonpb.Marshaler{}
+var tracesMarshaler = &ptrace.JSONMarshaler{}
 var metricMarshaler = &pmetric.JSONMarshaler{}
+var logMarshaler = &plog.JSONMarshaler{}
 
 // httpSinkExporter ...
 type httpSinkExporter struct {
 	sink *sink
-
-	metrics chan pmetric.Metrics
-	spans   chan *model.Batch
 }
 
-func newExporter(logger *zap.Logger, endpoint string) *httpSinkExporter {
-	logger.Warn("This component is deprecated and must be used for testing purposes only. It does not redact or filter any telemetry content it exposes and should not be used with production data.")
-	return &httpSinkExporter{sink: newSink(logger, endpoint)}
+func newExporter(set component.TelemetrySettings, cfg *Config) (*httpSinkExporter, error) {
+	set.Logger.Warn("This component is deprecated and must be used for testing purposes only. It does not redact or filter any telemetry content it exposes and should not be used with production data.")
+	s, err := newSink(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &httpSinkExporter{sink: s}, nil
 }
 
 func (e *httpSinkExporter) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
-	// TODO: replace jaeger with OTLP
-	batches, err := jaegertranslator.ProtoFromTraces(td)
-	if err != nil {
-		return err
-	}
-	for _, batch := range batches {
-		go func(b *model.Batch) {
-			e.spans <- b
-		}(batch)
-	}
+	e.sink.publish(typeSpans, td)
 	return nil
 }
 
 func (e *httpSinkExporter) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
-	go func(m pmetric.Metrics) {
-		e.metrics <- m
-	}(md)
+	e.sink.publish(typeMetrics, md)
+	return nil
+}
+
+func (e *httpSinkExporter) ConsumeLogs(_ context.Context, ld plog.Logs) error {
+	e.sink.publish(typeLogs, ld)
 	return nil
 }
 
 func (e *httpSinkExporter) Start(ctx context.Context, _ component.Host) error {
 	e.sink.start(ctx)
-	go e.fanOutSpans()
-	go e.fanOutMetrics()
 	return nil
 }
 
@@ -75,33 +68,3 @@ func (e *httpSinkExporter) Start(ctx context.Context, _ component.Host) error {
 func (e *httpSinkExporter) Shutdown(ctx context.Context) error {
 	return e.sink.shutdown(ctx)
 }
-
-func (e *httpSinkExporter) fanOutSpans() error {
-	e.spans = make(chan *model.Batch)
-	for {
-		batch := <-e.spans
-		clients := e.sink.clients(typeSpans)
-		for _, c := range clients {
-			if !c.stopped {
-				go func(c *client) {
-					c.spans <- batch
-				}(c)
-			}
-		}
-	}
-}
-
-func (e *httpSinkExporter) fanOutMetrics() error {
-	e.metrics = make(chan pmetric.Metrics)
-	for {
-		metrics := <-e.metrics
-		clients := e.sink.clients(typeMetrics)
-		for _, c := range clients {
-			if !c.stopped {
-				go func(c *client) {
-					c.metrics <- metrics
-				}(c)
-			}
-		}
-	}
-}
@@ -19,14 +19,41 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component/componenttest"
-	"go.uber.org/zap"
+	"go.opentelemetry.io/collector/pdata/plog"
 )
 
 func Test_httpSinkExporter_Start(t *testing.T) {
-	exp := newExporter(zap.NewNop(), "localhost:0")
-	err := exp.Start(context.Background(), componenttest.NewNopHost())
+	cfg := createDefaultConfig().(*Config)
+	cfg.Endpoint = "localhost:0"
+	exp, err := newExporter(componenttest.NewNopTelemetrySettings(), cfg)
+	require.NoError(t, err)
+	err = exp.Start(context.Background(), componenttest.NewNopHost())
 	assert.NoError(t, err)
 	err = exp.Shutdown(context.Background())
 	assert.NoError(t, err)
 }
+
+func Test_httpSinkExporter_ConsumeDoesNotBlock(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Endpoint = t.Name()
+	cfg.ClientQueue.Size = 2
+	exp, err := newExporter(componenttest.NewNopTelemetrySettings(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sinkFactoryMu.Lock()
+		delete(sinks, cfg.Endpoint)
+		sinkFactoryMu.Unlock()
+	})
+
+	c := exp.sink.newClient(options{dataType: typeLogs})
+	exp.sink.addClient(c)
+	defer exp.sink.removeClient(c)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, exp.ConsumeLogs(context.Background(), plog.NewLogs()))
+	}
+	assert.Len(t, c.queue, 2)
+	assert.Equal(t, uint64(8), c.dropped.Load())
+}
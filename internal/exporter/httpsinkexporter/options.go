@@ -18,9 +18,12 @@ package httpsinkexporter
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 type dataType int
@@ -28,44 +31,127 @@ type dataType int
 const (
 	typeSpans dataType = iota
 	typeMetrics
+	typeLogs
 )
 
+var dataTypeNames = map[dataType]string{
+	typeSpans:   "spans",
+	typeMetrics: "metrics",
+	typeLogs:    "logs",
+}
+
+var spanKinds = map[string]ptrace.SpanKind{
+	"unspecified": ptrace.SpanKindUnspecified,
+	"internal":    ptrace.SpanKindInternal,
+	"server":      ptrace.SpanKindServer,
+	"client":      ptrace.SpanKindClient,
+	"producer":    ptrace.SpanKindProducer,
+	"consumer":    ptrace.SpanKindConsumer,
+}
+
+var statusCodes = map[string]ptrace.StatusCode{
+	"unset": ptrace.StatusCodeUnset,
+	"ok":    ptrace.StatusCodeOk,
+	"error": ptrace.StatusCodeError,
+}
+
 type options struct {
-	attrs    map[string]string
-	names    []string
-	dataType dataType
-	count    int
-	timeout  time.Duration
+	attrs         map[string]string
+	resourceAttrs map[string]string
+	bodyRegex     *regexp.Regexp
+	names         []string
+	severities    []string
+	services      []string
+	spanKinds     []ptrace.SpanKind
+	statusCodes   []ptrace.StatusCode
+	body          string
+	spansFormat   string
+	dataType      dataType
+	count         int
+	timeout       time.Duration
+	since         time.Time
+	stream        bool
 }
 
 func parseOptions(r *http.Request) (options, error) {
 	opts := options{
-		count:   1,
-		timeout: time.Second * 10,
-		names:   []string{},
-		attrs:   map[string]string{},
+		count:         1,
+		timeout:       time.Second * 10,
+		names:         []string{},
+		severities:    []string{},
+		attrs:         map[string]string{},
+		resourceAttrs: map[string]string{},
+	}
+
+	path := r.URL.Path
+	if strings.HasPrefix(path, streamPathPrefix) {
+		opts.stream = true
+		path = strings.TrimPrefix(path, streamPathPrefix)
 	}
 
-	switch r.URL.Path {
+	switch path {
 	case "/metrics":
 		opts.dataType = typeMetrics
 	case "/spans":
 		opts.dataType = typeSpans
+	case "/logs":
+		opts.dataType = typeLogs
 	default:
-		return opts, fmt.Errorf("unsupported data-type. only metrics and spans are supported")
+		return opts, fmt.Errorf("unsupported data-type. only metrics, spans and logs are supported")
 	}
 
 	q := r.URL.Query()
 
-	for _, attr := range q["attr"] {
-		parts := strings.SplitN(attr, "=", 2)
-		if len(parts) != 2 {
-			return opts, fmt.Errorf("attr query string parameter is not formatted correctly")
+	if err := parseAttrs(q["attr"], "attr", opts.attrs); err != nil {
+		return opts, err
+	}
+	if err := parseAttrs(q["resource_attr"], "resource_attr", opts.resourceAttrs); err != nil {
+		return opts, err
+	}
+
+	for _, kind := range q["kind"] {
+		spanKind, ok := spanKinds[strings.TrimPrefix(strings.ToLower(kind), "span_kind_")]
+		if !ok {
+			return opts, fmt.Errorf("kind query string parameter %q is not a valid span kind", kind)
 		}
-		opts.attrs[parts[0]] = parts[1]
+		opts.spanKinds = append(opts.spanKinds, spanKind)
+	}
+
+	for _, status := range q["status"] {
+		statusCode, ok := statusCodes[strings.TrimPrefix(strings.ToLower(status), "status_code_")]
+		if !ok {
+			return opts, fmt.Errorf("status query string parameter %q is not a valid status code", status)
+		}
+		opts.statusCodes = append(opts.statusCodes, statusCode)
 	}
 
 	opts.names = q["name"]
+	opts.services = q["service"]
+	opts.severities = q["severity"]
+	opts.body = q.Get("body")
+
+	if format := q.Get("format"); format != "" {
+		if err := validateSpansFormat(format); err != nil {
+			return opts, err
+		}
+		opts.spansFormat = format
+	}
+
+	if bodyRegex := q.Get("body_regex"); bodyRegex != "" {
+		re, err := regexp.Compile(bodyRegex)
+		if err != nil {
+			return opts, fmt.Errorf("body_regex query string parameter is not a valid regular expression: %w", err)
+		}
+		opts.bodyRegex = re
+	}
+
+	if since := q.Get("since"); since != "" {
+		sinceTime, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return opts, fmt.Errorf("since query string parameter is not a valid RFC 3339 timestamp: %w", err)
+		}
+		opts.since = sinceTime
+	}
 
 	if timeout, ok := q["timeout"]; ok {
 		timeoutNum, err := strconv.Atoi(timeout[0])
@@ -85,3 +171,14 @@ func parseOptions(r *http.Request) (options, error) {
 
 	return opts, nil
 }
+
+func parseAttrs(values []string, param string, attrs map[string]string) error {
+	for _, attr := range values {
+		parts := strings.SplitN(attr, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s query string parameter is not formatted correctly", param)
+		}
+		attrs[parts[0]] = parts[1]
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/collector/component"
 	"go.uber.org/zap"
 )
 
@@ -29,27 +30,47 @@ var sinkFactoryMu = sync.Mutex{}
 var sinks = map[string]*sink{}
 
 type sink struct {
-	server    *http.Server
-	logger    *zap.Logger
-	endpoint  string
-	_clients  []*client
+	server      *http.Server
+	logger      *zap.Logger
+	endpoint    string
+	spansFormat string
+	clientQueue ClientQueueConfig
+	telemetry   *sinkTelemetry
+	_clients    []*client
+	histories   map[dataType]*history
+	// ctx is canceled when the server shuts down to release the open requests.
+	ctx       context.Context
+	cancel    context.CancelFunc
 	startOnce sync.Once
 	mu        sync.Mutex
 }
 
-func newSink(logger *zap.Logger, endpoint string) *sink {
+func newSink(set component.TelemetrySettings, cfg *Config) (*sink, error) {
 	sinkFactoryMu.Lock()
 	defer sinkFactoryMu.Unlock()
-	s, ok := sinks[endpoint]
+	s, ok := sinks[cfg.Endpoint]
 	if !ok {
+		telemetry, err := newSinkTelemetry(set)
+		if err != nil {
+			return nil, err
+		}
 		s = &sink{
-			logger:    logger,
-			endpoint:  endpoint,
+			logger:      set.Logger,
+			endpoint:    cfg.Endpoint,
+			spansFormat: cfg.SpansFormat,
+			clientQueue: cfg.ClientQueue,
+			telemetry:   telemetry,
+			histories: map[dataType]*history{
+				typeSpans:   newHistory(cfg.History),
+				typeMetrics: newHistory(cfg.History),
+				typeLogs:    newHistory(cfg.History),
+			},
 			startOnce: sync.Once{},
 		}
-		sinks[endpoint] = s
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		sinks[cfg.Endpoint] = s
 	}
-	return s
+	return s, nil
 }
 
 func (s *sink) start(ctx context.Context) {
@@ -59,6 +80,8 @@ func (s *sink) start(ctx context.Context) {
 		mux.Handle("/", http.HandlerFunc(s.handleDefault))
 		mux.Handle("/spans", http.HandlerFunc(s.handle))
 		mux.Handle("/metrics", http.HandlerFunc(s.handle))
+		mux.Handle("/logs", http.HandlerFunc(s.handle))
+		mux.Handle(streamPathPrefix+"/", http.HandlerFunc(s.handleStream))
 
 		s.server = &http.Server{
 			Addr:    s.endpoint,
@@ -68,6 +91,7 @@ func (s *sink) start(ctx context.Context) {
 			},
 			ReadHeaderTimeout: 5 * time.Second,
 		}
+		s.server.RegisterOnShutdown(s.cancel)
 		go s.server.ListenAndServe()
 	})
 }
@@ -79,24 +103,51 @@ func (s *sink) shutdown(ctx context.Context) error {
 	return nil
 }
 
-func (s *sink) clients(dType dataType) []*client {
+// publish retains the received telemetry and queues it for the registered clients.
+// Queuing never blocks, so publishing is not slowed down by clients that are not keeping up.
+func (s *sink) publish(dType dataType, data any) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	var clients []*client
+	s.histories[dType].add(time.Now(), data)
 	for _, c := range s._clients {
 		if c.opts.dataType == dType {
-			clients = append(clients, c)
+			c.offer(data)
 		}
 	}
-	return clients
 }
 
+func (s *sink) newClient(opts options) *client {
+	if opts.spansFormat == "" {
+		opts.spansFormat = s.spansFormat
+	}
+	return newClient(opts, s.clientQueue, s.telemetry)
+}
+
+// requestContext returns a context that is canceled when the request ends or the server shuts down.
+func (s *sink) requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	stop := context.AfterFunc(s.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// addClient registers the client and hands it the retained telemetry it can match.
+// Both happen under the same lock as publish so that no batch is missed or seen twice.
 func (s *sink) addClient(c *client) {
 	s.mu.Lock()
+	c.retained = s.histories[c.opts.dataType].snapshot(time.Now(), c.opts.since)
 	s._clients = append(s._clients, c)
 	s.mu.Unlock()
 }
 
+func (s *sink) clearHistory(dType dataType) {
+	s.mu.Lock()
+	s.histories[dType].clear()
+	s.mu.Unlock()
+}
+
 func (s *sink) removeClient(c *client) {
 	s.mu.Lock()
 	index := -1
@@ -114,7 +165,7 @@ func (s *sink) removeClient(c *client) {
 
 func (s *sink) handleDefault(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "text/plain")
-	w.Write([]byte("404 not found - use one of the following endpoints: \n\n \t- /spans\n \t- /metrics"))
+	w.Write([]byte("404 not found - use one of the following endpoints: \n\n \t- /spans\n \t- /metrics\n \t- /logs\n \t- /stream/spans\n \t- /stream/metrics\n \t- /stream/logs"))
 }
 
 func (s *sink) handle(w http.ResponseWriter, r *http.Request) {
@@ -124,11 +175,20 @@ func (s *sink) handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := newClient(opts)
+	if r.Method == http.MethodDelete {
+		s.clearHistory(opts.dataType)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	c := s.newClient(opts)
 	s.addClient(c)
 	defer s.removeClient(c)
 
-	result, err := c.response(r.Context())
+	result, err := c.response(ctx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
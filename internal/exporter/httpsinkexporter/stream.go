@@ -0,0 +1,125 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsinkexporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const streamPathPrefix = "/stream"
+
+func (s *sink) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported by streaming endpoints", http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts, err := parseOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+
+	c := s.newClient(opts)
+	s.addClient(c)
+	defer s.removeClient(c)
+
+	if err = c.stream(ctx, w, flusher.Flush, sse); err != nil {
+		s.logger.Debug("stream closed", zap.Error(err))
+	}
+	if dropped := c.dropped.Load(); dropped > 0 {
+		s.logger.Info("dropped batches for a slow streaming client", zap.Uint64("dropped", dropped))
+	}
+}
+
+// stream writes every matching item to w, as server-sent events or newline-delimited JSON,
+// until the context is done or a write fails.
+func (c *client) stream(ctx context.Context, w io.Writer, flush func(), sse bool) error {
+	defer c.stop()
+
+	var reportedDrops uint64
+	// Retained history is replayed to every new client, so only the items received
+	// while streaming are recorded as delivered.
+	write := func(data any, replayed bool) error {
+		rendered, count, err := c.render(data)
+		if err != nil {
+			return err
+		}
+		if sse {
+			if dropped := c.dropped.Load(); dropped > reportedDrops {
+				if _, err = fmt.Fprintf(w, ": dropped %d batches\n\n", dropped-reportedDrops); err != nil {
+					return err
+				}
+				reportedDrops = dropped
+			}
+		}
+		for _, item := range rendered {
+			if sse {
+				_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", dataTypeNames[c.opts.dataType], item)
+			} else {
+				_, err = fmt.Fprintf(w, "%s\n", item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		flush()
+		if !replayed {
+			c.telemetry.recordDelivered(c.opts.dataType, count)
+		}
+		return nil
+	}
+
+	for _, data := range c.retained {
+		if err := write(data, true); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case data := <-c.queue:
+			if err := write(data, false); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
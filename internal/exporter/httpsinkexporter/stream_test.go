@@ -0,0 +1,85 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsinkexporter
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func newTestSink(t *testing.T, cfg *Config) *sink {
+	s, err := newSink(componenttest.NewNopTelemetrySettings(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sinkFactoryMu.Lock()
+		delete(sinks, cfg.Endpoint)
+		sinkFactoryMu.Unlock()
+	})
+	return s
+}
+
+func TestStreamLogs(t *testing.T) {
+	s := newTestSink(t, &Config{Endpoint: t.Name(), SpansFormat: spansFormatJaeger, ClientQueue: createDefaultConfig().(*Config).ClientQueue})
+	server := httptest.NewServer(http.HandlerFunc(s.handleStream))
+	defer server.Close()
+	defer s.cancel()
+
+	for _, tt := range []struct {
+		name   string
+		accept string
+		prefix string
+	}{
+		{name: "ndjson", accept: "", prefix: ""},
+		{name: "sse", accept: "text/event-stream", prefix: "data: "},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/stream/logs?severity=ERROR", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", tt.accept)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Eventually(t, func() bool {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				return len(s._clients) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			s.publish(typeLogs, testLogs())
+
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if !strings.HasPrefix(line, tt.prefix+"{") {
+					continue
+				}
+				ld, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(strings.TrimPrefix(line, tt.prefix)))
+				require.NoError(t, err)
+				assert.Equal(t, 2, ld.LogRecordCount())
+				return
+			}
+			t.Fatal("stream ended before receiving logs")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsinkexporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+const scopeName = "github.com/signalfx/splunk-otel-collector/internal/exporter/httpsinkexporter"
+
+// sinkTelemetry records the sink's own metrics.
+type sinkTelemetry struct {
+	deliveredItems otelmetric.Int64Counter
+	droppedItems   otelmetric.Int64Counter
+	attrs          map[dataType]otelmetric.AddOption
+}
+
+func newSinkTelemetry(set component.TelemetrySettings) (*sinkTelemetry, error) {
+	meter := set.MeterProvider.Meter(scopeName)
+
+	deliveredItems, err := meter.Int64Counter(
+		"exporter_httpsink_delivered_items",
+		otelmetric.WithDescription("Number of items (spans, metrics or log records) delivered to the sink clients, excluding replayed history."),
+		otelmetric.WithUnit("{items}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	droppedItems, err := meter.Int64Counter(
+		"exporter_httpsink_dropped_items",
+		otelmetric.WithDescription("Number of items (spans, metrics or log records) dropped because a sink client was not keeping up."),
+		otelmetric.WithUnit("{items}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[dataType]otelmetric.AddOption{}
+	for dType, name := range dataTypeNames {
+		attrs[dType] = otelmetric.WithAttributes(attribute.String("data_type", name))
+	}
+
+	return &sinkTelemetry{
+		deliveredItems: deliveredItems,
+		droppedItems:   droppedItems,
+		attrs:          attrs,
+	}, nil
+}
+
+// recordDelivered records the given number of items written to a sink client after filtering.
+func (t *sinkTelemetry) recordDelivered(dType dataType, count int) {
+	if count > 0 {
+		t.deliveredItems.Add(context.Background(), int64(count), t.attrs[dType])
+	}
+}
+
+func (t *sinkTelemetry) recordDropped(dType dataType, data any) {
+	t.droppedItems.Add(context.Background(), int64(itemCount(data)), t.attrs[dType])
+}
+
+// itemCount returns the number of spans, metrics or log records of the given telemetry.
+func itemCount(data any) int {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		return d.SpanCount()
+	case pmetric.Metrics:
+		return d.MetricCount()
+	case plog.Logs:
+		return d.LogRecordCount()
+	}
+	return 0
+}
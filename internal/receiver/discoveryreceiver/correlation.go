@@ -56,6 +56,9 @@ type correlationStore interface {
 	GetOrCreate(receiverID component.ID, endpointID observer.EndpointID) correlation
 	Attrs(receiverID component.ID) map[string]string
 	UpdateAttrs(receiverID component.ID, attrs map[string]string)
+	// MarkLogged records that a first only status was emitted for the endpoint and
+	// returns whether it already was. Records are cleared when the endpoint is removed.
+	MarkLogged(endpointID observer.EndpointID, key string) bool
 	// Start the reaping loop to prevent unnecessary endpoint buildup
 	Start()
 	// Stop the reaping loop
@@ -75,6 +78,8 @@ type store struct {
 	endpointLocks *keyLock
 	receiverAttrs *sync.Map
 	receiverLocks *keyLock
+	// logged is a ~synchronized map[endpointID]map[string]struct{} of emitted first only statuses
+	logged *sync.Map
 	// sentinel for terminating reaper loop
 	sentinel     chan struct{}
 	reapInterval time.Duration
@@ -88,6 +93,7 @@ func newCorrelationStore(logger *zap.Logger, ttl time.Duration) correlationStore
 		endpointLocks: newKeyLock(),
 		receiverAttrs: &sync.Map{},
 		receiverLocks: newKeyLock(),
+		logged:        &sync.Map{},
 		reapInterval:  30 * time.Second,
 		ttl:           ttl,
 		sentinel:      make(chan struct{}, 1),
@@ -98,6 +104,11 @@ func newCorrelationStore(logger *zap.Logger, ttl time.Duration) correlationStore
 // creates a new no-type ~singleton w/ the initial correlation info for later use in correlation creation.
 func (s *store) UpdateEndpoint(endpoint observer.Endpoint, state endpointState, observerID component.ID) {
 	defer s.endpointLocks.Lock(endpoint.ID)()
+	if state == removedState {
+		// A removed endpoint may be added again with the same ID, for instance when
+		// a container is restarted, and its statuses must be emitted again.
+		s.logged.Delete(endpoint.ID)
+	}
 	rMap, ok := s.correlations.LoadOrStore(endpoint.ID, &sync.Map{})
 	receiverMap := rMap.(*sync.Map)
 	if !ok {
@@ -172,6 +183,13 @@ func (s *store) UpdateAttrs(receiverID component.ID, attrs map[string]string) {
 	s.receiverAttrs.Store(receiverID, receiverAttrs)
 }
 
+func (s *store) MarkLogged(endpointID observer.EndpointID, key string) bool {
+	defer s.endpointLocks.Lock(endpointID)()
+	lMap, _ := s.logged.LoadOrStore(endpointID, &sync.Map{})
+	_, alreadyLogged := lMap.(*sync.Map).LoadOrStore(key, struct{}{})
+	return alreadyLogged
+}
+
 func (s *store) Start() {
 	go func() {
 		timer := time.NewTicker(s.reapInterval)
@@ -202,6 +220,7 @@ func (s *store) reap() {
 			if corr.lastState == removedState &&
 				time.Since(corr.lastUpdated) > s.ttl {
 				s.correlations.Delete(endpointID)
+				s.logged.Delete(endpointID)
 			}
 		}
 		return true
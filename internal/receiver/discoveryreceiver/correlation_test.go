@@ -234,7 +234,12 @@ func TestReaperLoop(t *testing.T) {
 	require.True(t, isCorr)
 	require.Equal(t, addedState, noTypedReceiverCorr.lastState)
 
+	require.False(t, cs.MarkLogged(endpointID, "a.status"))
+	require.True(t, cs.MarkLogged(endpointID, "a.status"))
+
 	cs.UpdateEndpoint(endpoint, removedState, observerID)
+	_, hasLogged := cStore.logged.Load(endpointID)
+	require.False(t, hasLogged)
 
 	// repeat check once to ensure loop-driven removal
 	_, hasEndpoint := cStore.correlations.Load(endpointID)
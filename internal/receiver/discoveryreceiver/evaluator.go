@@ -18,7 +18,6 @@ import (
 	"encoding/base64"
 	"fmt"
 	"regexp"
-	"sync"
 
 	"github.com/antonmedv/expr"
 	"github.com/antonmedv/expr/vm"
@@ -40,19 +39,15 @@ type evaluator struct {
 	logger       *zap.Logger
 	config       *Config
 	correlations correlationStore
-	// if match.FirstOnly this ~sync.Map(map[string]struct{}) keeps track of
-	// whether we've already emitted a record for the statement and can skip processing.
-	alreadyLogged *sync.Map
-	exprEnv       exprEnvFunc
+	exprEnv      exprEnvFunc
 }
 
 func newEvaluator(logger *zap.Logger, config *Config, correlations correlationStore, envFunc exprEnvFunc) *evaluator {
 	return &evaluator{
-		logger:        logger,
-		config:        config,
-		correlations:  correlations,
-		alreadyLogged: &sync.Map{},
-		exprEnv:       envFunc,
+		logger:       logger,
+		config:       config,
+		correlations: correlations,
+		exprEnv:      envFunc,
 	}
 }
 
@@ -107,9 +102,11 @@ func (e *evaluator) evaluateMatch(match Match, pattern string, status discovery.
 		return false, err
 	}
 
+	// the correlation store keeps track of whether we've already emitted a record for the
+	// statement and endpoint, until the endpoint is removed, and can skip processing.
 	if match.FirstOnly {
-		loggedKey := fmt.Sprintf("%s::%s::%s::%s", endpointID, receiverID.String(), status, matchPattern)
-		if _, ok := e.alreadyLogged.LoadOrStore(loggedKey, struct{}{}); ok {
+		loggedKey := fmt.Sprintf("%s::%s::%s", receiverID.String(), status, matchPattern)
+		if e.correlations.MarkLogged(endpointID, loggedKey) {
 			shouldLog = false
 		}
 	}
@@ -17,7 +17,6 @@ package discoveryreceiver
 import (
 	"encoding/base64"
 	"fmt"
-	"sync"
 	"testing"
 	"time"
 
@@ -33,12 +32,10 @@ func setup(_ *testing.T) (*evaluator, component.ID, observer.EndpointID) {
 	// all statements. Not in regular use to avoid spamming output.
 	// logger := zaptest.NewLogger(t)
 	logger := zap.NewNop()
-	alreadyLogged := &sync.Map{}
 	eval := &evaluator{
-		logger:        logger,
-		config:        &Config{},
-		correlations:  newCorrelationStore(logger, time.Hour),
-		alreadyLogged: alreadyLogged,
+		logger:       logger,
+		config:       &Config{},
+		correlations: newCorrelationStore(logger, time.Hour),
 		exprEnv: func(pattern string) map[string]any {
 			return map[string]any{"item": pattern}
 		},
@@ -87,6 +84,34 @@ func TestEvaluateMatch(t *testing.T) {
 	}
 }
 
+func TestEvaluateMatchAfterEndpointReAdded(t *testing.T) {
+	eval, receiverID, endpointID := setup(t)
+	endpoint := observer.Endpoint{ID: endpointID, Target: "a.target"}
+	observerID := component.MustNewIDWithName("observer", "name")
+	m := Match{Strict: "must.match", FirstOnly: true}
+
+	eval.correlations.UpdateEndpoint(endpoint, addedState, observerID)
+	shouldLog, err := eval.evaluateMatch(m, "must.match", "some.status", receiverID, endpointID)
+	require.NoError(t, err)
+	require.True(t, shouldLog)
+
+	shouldLog, err = eval.evaluateMatch(m, "must.match", "some.status", receiverID, endpointID)
+	require.NoError(t, err)
+	require.False(t, shouldLog)
+
+	// e.g. a container restarted, keeping its endpoint ID
+	eval.correlations.UpdateEndpoint(endpoint, removedState, observerID)
+	eval.correlations.UpdateEndpoint(endpoint, addedState, observerID)
+
+	shouldLog, err = eval.evaluateMatch(m, "must.match", "some.status", receiverID, endpointID)
+	require.NoError(t, err)
+	require.True(t, shouldLog)
+
+	shouldLog, err = eval.evaluateMatch(m, "must.match", "some.status", receiverID, endpointID)
+	require.NoError(t, err)
+	require.False(t, shouldLog)
+}
+
 func TestEvaluateInvalidMatch(t *testing.T) {
 	eval, receiverID, endpointID := setup(t)
 
@@ -29,13 +29,15 @@ import (
 	mnoop "go.opentelemetry.io/otel/metric/noop"
 	tnoop "go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
+
+	"github.com/signalfx/splunk-otel-collector/internal/common/discovery"
 )
 
 const (
-	eventTypeAttr             = "discovery.event.type"
+	eventTypeAttr             = discovery.EventTypeAttr
 	metricNameAttr            = "metric.name"
-	observerNameAttr          = "discovery.observer.name"
-	observerTypeAttr          = "discovery.observer.type"
+	observerNameAttr          = discovery.ObserverNameAttr
+	observerTypeAttr          = discovery.ObserverTypeAttr
 	receiverRuleAttr          = "discovery.receiver.rule"
 	receiverUpdatedConfigAttr = "discovery.receiver.updated.config"
 )
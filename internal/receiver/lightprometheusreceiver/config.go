@@ -16,6 +16,7 @@ package lightprometheusreceiver
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
@@ -23,14 +24,17 @@ import (
 	"go.opentelemetry.io/collector/receiver/scraperhelper"
 )
 
+const defaultMaxConcurrentScrapes = 10
+
 func createDefaultConfig() component.Config {
 	scs := scraperhelper.NewDefaultScraperControllerSettings(typeStr)
 	// set the default collection interval to 30 seconds which is half of the
 	// lowest job frequency of 1 minute
 	scs.CollectionInterval = time.Second * 30
 	return &Config{
-		ControllerConfig: scs,
-		ClientConfig:     confighttp.NewDefaultClientConfig(),
+		ControllerConfig:     scs,
+		ClientConfig:         confighttp.NewDefaultClientConfig(),
+		MaxConcurrentScrapes: defaultMaxConcurrentScrapes,
 		ResourceAttributes: ResourceAttributesConfig{
 			ServiceInstanceID: ResourceAttributeConfig{Enabled: true},
 			ServiceName:       ResourceAttributeConfig{Enabled: true},
@@ -55,16 +59,108 @@ type ResourceAttributesConfig struct {
 	HTTPScheme        ResourceAttributeConfig `mapstructure:"http.scheme"`
 }
 
+// TargetConfig is a target to scrape with its labels.
+type TargetConfig struct {
+	// Labels are added as resource attributes to the metrics scraped from the target.
+	Labels map[string]string `mapstructure:"labels"`
+	// Endpoint is the address to request Prometheus metrics from, including the metrics path.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// FileSDConfig discovers targets from files in the Prometheus file-based service discovery format.
+type FileSDConfig struct {
+	// Files are the paths or glob patterns of the JSON or YAML files listing the target groups.
+	Files []string `mapstructure:"files"`
+	// RefreshInterval is the interval at which the files are re-read in addition to watching them for changes.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// HTTPSDConfig discovers targets from an endpoint serving the Prometheus HTTP service discovery format.
+type HTTPSDConfig struct {
+	confighttp.ClientConfig `mapstructure:",squash"`
+	// RefreshInterval is the interval at which the endpoint is requested.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// MetricFilterConfig filters the scraped metrics by name, with regular expressions matching the whole name.
+type MetricFilterConfig struct {
+	// Include keeps only the metrics matching one of the expressions, if any.
+	Include []string `mapstructure:"include"`
+	// Exclude drops the metrics matching one of the expressions.
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// LabelRuleConfig is a rule applied to the labels of the scraped series, following the Prometheus relabeling semantics.
+type LabelRuleConfig struct {
+	// Action is one of keep, drop, replace or labelmap. Defaults to replace.
+	Action string `mapstructure:"action"`
+	// Separator joins the values of the source labels. Defaults to ;.
+	Separator string `mapstructure:"separator"`
+	// Regex is matched against the joined values of the source labels, or the label names for labelmap.
+	// Defaults to (.*).
+	Regex string `mapstructure:"regex"`
+	// TargetLabel is the label set by the replace action.
+	TargetLabel string `mapstructure:"target_label"`
+	// Replacement is the value set by the replace action, or the label name set by the labelmap action,
+	// referencing the regex capture groups. Defaults to $1.
+	Replacement string `mapstructure:"replacement"`
+	// SourceLabels are the labels whose values are joined and matched. __name__ is the metric name.
+	SourceLabels []string `mapstructure:"source_labels"`
+}
+
 type Config struct {
 	confighttp.ClientConfig        `mapstructure:",squash"`
 	scraperhelper.ControllerConfig `mapstructure:",squash"`
+	// FileSD discovers the targets to scrape from files.
+	FileSD *FileSDConfig `mapstructure:"file_sd"`
+	// HTTPSD discovers the targets to scrape from an HTTP endpoint.
+	HTTPSD *HTTPSDConfig `mapstructure:"http_sd"`
 	// ResourceAttributes that added to scraped metrics.
 	ResourceAttributes ResourceAttributesConfig `mapstructure:"resource_attributes"`
+	// Targets are scraped in addition to the endpoint.
+	Targets []TargetConfig `mapstructure:"targets"`
+	// LabelRules are applied in order to the labels of the scraped series.
+	LabelRules []LabelRuleConfig `mapstructure:"label_rules"`
+	// MetricFilter filters the scraped metrics by name.
+	MetricFilter MetricFilterConfig `mapstructure:"metric_filter"`
+	// MaxConcurrentScrapes is the maximum number of targets scraped at the same time.
+	MaxConcurrentScrapes int `mapstructure:"max_concurrent_scrapes"`
+	// SampleLimit fails the scrape of a target exposing more samples after filtering and relabeling.
+	// 0 means no limit.
+	SampleLimit int `mapstructure:"sample_limit"`
 }
 
 func (cfg *Config) Validate() error {
-	if cfg.ClientConfig.Endpoint == "" {
-		return errors.New(`"endpoint" is required`)
+	if cfg.ClientConfig.Endpoint == "" && len(cfg.Targets) == 0 && cfg.FileSD == nil && cfg.HTTPSD == nil {
+		return errors.New(`"endpoint", "targets", "file_sd" or "http_sd" is required`)
+	}
+	for i, target := range cfg.Targets {
+		if target.Endpoint == "" {
+			return fmt.Errorf(`"endpoint" is required for target %d`, i)
+		}
+	}
+	if cfg.FileSD != nil {
+		if len(cfg.FileSD.Files) == 0 {
+			return errors.New(`"files" is required for "file_sd"`)
+		}
+		if cfg.FileSD.RefreshInterval < 0 {
+			return errors.New(`"refresh_interval" must not be negative for "file_sd"`)
+		}
+	}
+	if cfg.HTTPSD != nil {
+		if cfg.HTTPSD.Endpoint == "" {
+			return errors.New(`"endpoint" is required for "http_sd"`)
+		}
+		if cfg.HTTPSD.RefreshInterval < 0 {
+			return errors.New(`"refresh_interval" must not be negative for "http_sd"`)
+		}
+	}
+	if cfg.MaxConcurrentScrapes <= 0 {
+		return errors.New(`"max_concurrent_scrapes" must be positive`)
+	}
+	if cfg.SampleLimit < 0 {
+		return errors.New(`"sample_limit" must not be negative`)
 	}
-	return nil
+	_, err := newRelabeler(cfg)
+	return err
 }
@@ -52,12 +52,69 @@ func TestValidConfig(t *testing.T) {
 			NetHostPort:       ResourceAttributeConfig{Enabled: false},
 			HTTPScheme:        ResourceAttributeConfig{Enabled: false},
 		},
+		MaxConcurrentScrapes: 10,
 	}
 	expectedCfg.ClientConfig.Endpoint = "http://localhost:9090/metrics"
 	require.Equal(t, expectedCfg, cfg)
 }
 
+func TestTargetsConfig(t *testing.T) {
+	configs, err := confmaptest.LoadConf(path.Join(".", "testdata", "config.yaml"))
+	require.NoError(t, err)
+
+	cm, err := configs.Sub("lightprometheus/targets")
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	require.NoError(t, component.UnmarshalConfig(cm, cfg))
+	require.NoError(t, cfg.Validate())
+
+	require.Equal(t, []TargetConfig{
+		{Endpoint: "http://localhost:9100/metrics", Labels: map[string]string{"job": "node"}},
+		{Endpoint: "https://localhost:9101/custom/metrics"},
+	}, cfg.Targets)
+	require.Equal(t, &FileSDConfig{Files: []string{"/etc/targets/*.json"}, RefreshInterval: time.Minute}, cfg.FileSD)
+	require.Equal(t, "http://localhost:8080/targets", cfg.HTTPSD.Endpoint)
+	require.Equal(t, 30*time.Second, cfg.HTTPSD.RefreshInterval)
+	require.Equal(t, 4, cfg.MaxConcurrentScrapes)
+}
+
+func TestRelabelConfig(t *testing.T) {
+	configs, err := confmaptest.LoadConf(path.Join(".", "testdata", "config.yaml"))
+	require.NoError(t, err)
+
+	cm, err := configs.Sub("lightprometheus/relabel")
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	require.NoError(t, component.UnmarshalConfig(cm, cfg))
+	require.NoError(t, cfg.Validate())
+
+	require.Equal(t, MetricFilterConfig{Include: []string{"http_.*"}, Exclude: []string{"http_client_.*"}}, cfg.MetricFilter)
+	require.Equal(t, []LabelRuleConfig{
+		{Action: "drop", SourceLabels: []string{"path"}, Regex: "/health"},
+		{SourceLabels: []string{"code"}, Regex: `(\d)\d\d`, TargetLabel: "class", Replacement: "${1}xx"},
+	}, cfg.LabelRules)
+	require.Equal(t, 1000, cfg.SampleLimit)
+}
+
 func TestInvalidConfig(t *testing.T) {
 	cfg := createDefaultConfig().(*Config)
 	require.ErrorContains(t, cfg.Validate(), "endpoint")
+
+	cfg.Targets = []TargetConfig{{}}
+	require.ErrorContains(t, cfg.Validate(), "target 0")
+
+	cfg.Targets = nil
+	cfg.FileSD = &FileSDConfig{}
+	require.ErrorContains(t, cfg.Validate(), `"files" is required`)
+
+	cfg.FileSD = nil
+	cfg.HTTPSD = &HTTPSDConfig{}
+	require.ErrorContains(t, cfg.Validate(), `"endpoint" is required for "http_sd"`)
+
+	cfg.HTTPSD = nil
+	cfg.ClientConfig.Endpoint = "http://localhost:9090/metrics"
+	cfg.MaxConcurrentScrapes = 0
+	require.ErrorContains(t, cfg.Validate(), "max_concurrent_scrapes")
 }
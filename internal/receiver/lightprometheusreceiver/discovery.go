@@ -0,0 +1,334 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	schemeLabel      = "__scheme__"
+	metricsPathLabel = "__metrics_path__"
+
+	defaultScheme      = "http"
+	defaultMetricsPath = "/metrics"
+
+	defaultFileSDRefreshInterval = 5 * time.Minute
+	defaultHTTPSDRefreshInterval = time.Minute
+)
+
+// targetGroup is a group of targets sharing the same labels, as described by the Prometheus
+// file-based and HTTP service discovery formats.
+type targetGroup struct {
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+	Targets []string          `json:"targets" yaml:"targets"`
+}
+
+// discoverer discovers targets to scrape in the background.
+type discoverer interface {
+	start(ctx context.Context, host component.Host) error
+	shutdown()
+	targets() []TargetConfig
+}
+
+// discoveredTargets holds the last targets discovered, shared between the discovery and the scrapes.
+type discoveredTargets struct {
+	current []TargetConfig
+	mu      sync.RWMutex
+}
+
+func (d *discoveredTargets) targets() []TargetConfig {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.current
+}
+
+func (d *discoveredTargets) set(groups []targetGroup) {
+	targets := targetsFromGroups(groups)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.current = targets
+}
+
+// targetsFromGroups converts discovered target groups to targets. The address of each target is turned into an
+// endpoint using the __scheme__ and __metrics_path__ labels, other labels starting with __ are dropped.
+func targetsFromGroups(groups []targetGroup) []TargetConfig {
+	var targets []TargetConfig
+	for _, group := range groups {
+		scheme := defaultScheme
+		if v := group.Labels[schemeLabel]; v != "" {
+			scheme = v
+		}
+		path := defaultMetricsPath
+		if v := group.Labels[metricsPathLabel]; v != "" {
+			path = v
+		}
+		labels := map[string]string{}
+		for k, v := range group.Labels {
+			if !strings.HasPrefix(k, "__") {
+				labels[k] = v
+			}
+		}
+		for _, address := range group.Targets {
+			u := url.URL{Scheme: scheme, Host: address, Path: path}
+			targets = append(targets, TargetConfig{Endpoint: u.String(), Labels: labels})
+		}
+	}
+	return targets
+}
+
+// fileDiscovery discovers targets from files in the Prometheus file-based service discovery format.
+// The files are re-read when they change and at the refresh interval.
+type fileDiscovery struct {
+	discoveredTargets
+	logger  *zap.Logger
+	cfg     *FileSDConfig
+	watcher *fsnotify.Watcher
+	groups  map[string][]targetGroup
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newFileDiscovery(cfg *FileSDConfig, logger *zap.Logger) *fileDiscovery {
+	return &fileDiscovery{
+		logger: logger,
+		cfg:    cfg,
+		groups: map[string][]targetGroup{},
+		done:   make(chan struct{}),
+	}
+}
+
+func (d *fileDiscovery) start(context.Context, component.Host) error {
+	var err error
+	if d.watcher, err = fsnotify.NewWatcher(); err != nil {
+		return err
+	}
+	// Watch the directories rather than the files, which are usually replaced rather than written to.
+	dirs := map[string]bool{}
+	for _, pattern := range d.cfg.Files {
+		dir := filepath.Dir(pattern)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err = d.watcher.Add(dir); err != nil {
+			_ = d.watcher.Close()
+			return fmt.Errorf("failed to watch %q: %w", dir, err)
+		}
+	}
+	d.refresh()
+
+	interval := d.cfg.RefreshInterval
+	if interval == 0 {
+		interval = defaultFileSDRefreshInterval
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case event, ok := <-d.watcher.Events:
+				if !ok {
+					return
+				}
+				if d.matches(event.Name) {
+					d.refresh()
+				}
+			case err, ok := <-d.watcher.Errors:
+				if !ok {
+					return
+				}
+				d.logger.Warn("Error watching service discovery files", zap.Error(err))
+			case <-ticker.C:
+				d.refresh()
+			case <-d.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (d *fileDiscovery) shutdown() {
+	if d.watcher == nil {
+		return
+	}
+	close(d.done)
+	d.wg.Wait()
+	_ = d.watcher.Close()
+}
+
+func (d *fileDiscovery) matches(path string) bool {
+	for _, pattern := range d.cfg.Files {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh reads the files matching the configured patterns. The last targets read from a file
+// are kept if it can't be read or parsed anymore, until it is removed.
+func (d *fileDiscovery) refresh() {
+	groups := map[string][]targetGroup{}
+	for _, pattern := range d.cfg.Files {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			d.logger.Warn("Invalid service discovery file pattern", zap.String("pattern", pattern), zap.Error(err))
+			continue
+		}
+		for _, file := range files {
+			fileGroups, err := readTargetGroups(file)
+			if err != nil {
+				d.logger.Warn("Failed to read service discovery file", zap.String("file", file), zap.Error(err))
+				if previous, ok := d.groups[file]; ok {
+					groups[file] = previous
+				}
+				continue
+			}
+			groups[file] = fileGroups
+		}
+	}
+	d.groups = groups
+
+	files := make([]string, 0, len(groups))
+	for file := range groups {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var all []targetGroup
+	for _, file := range files {
+		all = append(all, groups[file]...)
+	}
+	d.set(all)
+}
+
+// readTargetGroups reads target groups from a JSON or YAML file.
+func readTargetGroups(file string) ([]targetGroup, error) {
+	b, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	var groups []targetGroup
+	if err = yaml.Unmarshal(b, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// httpDiscovery discovers targets from an endpoint serving the Prometheus HTTP service discovery format.
+type httpDiscovery struct {
+	discoveredTargets
+	settings component.TelemetrySettings
+	cfg      *HTTPSDConfig
+	client   *http.Client
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func newHTTPDiscovery(cfg *HTTPSDConfig, settings component.TelemetrySettings) *httpDiscovery {
+	return &httpDiscovery{
+		settings: settings,
+		cfg:      cfg,
+	}
+}
+
+func (d *httpDiscovery) start(_ context.Context, host component.Host) error {
+	var err error
+	if d.client, err = d.cfg.ClientConfig.ToClient(host, d.settings); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	if err = d.refresh(ctx); err != nil {
+		d.settings.Logger.Warn("Failed to discover targets", zap.String("endpoint", d.cfg.Endpoint), zap.Error(err))
+	}
+
+	interval := d.cfg.RefreshInterval
+	if interval == 0 {
+		interval = defaultHTTPSDRefreshInterval
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.refresh(ctx); err != nil {
+					d.settings.Logger.Warn("Failed to discover targets", zap.String("endpoint", d.cfg.Endpoint), zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (d *httpDiscovery) shutdown() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+}
+
+// refresh requests the target groups. The last targets discovered are kept if the request fails.
+func (d *httpDiscovery) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.cfg.Endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("service discovery %s returned status %d: %s", d.cfg.Endpoint, resp.StatusCode, string(body))
+	}
+
+	var groups []targetGroup
+	if err = json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return err
+	}
+	d.set(groups)
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/zap"
+)
+
+func TestTargetsFromGroups(t *testing.T) {
+	targets := targetsFromGroups([]targetGroup{
+		{
+			Targets: []string{"host1:9100", "host2:9100"},
+			Labels:  map[string]string{"job": "node", "__meta_datacenter": "dc1"},
+		},
+		{
+			Targets: []string{"host3:8443"},
+			Labels:  map[string]string{schemeLabel: "https", metricsPathLabel: "/custom"},
+		},
+	})
+	require.Equal(t, []TargetConfig{
+		{Endpoint: "http://host1:9100/metrics", Labels: map[string]string{"job": "node"}},
+		{Endpoint: "http://host2:9100/metrics", Labels: map[string]string{"job": "node"}},
+		{Endpoint: "https://host3:8443/custom", Labels: map[string]string{}},
+	}, targets)
+}
+
+func TestFileDiscovery(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`[{"targets": ["host1:9100"], "labels": {"job": "node"}}]`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte(`not targets`), 0600))
+
+	d := newFileDiscovery(&FileSDConfig{Files: []string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yaml")}}, zap.NewNop())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	defer d.shutdown()
+
+	require.Equal(t, []TargetConfig{
+		{Endpoint: "http://host1:9100/metrics", Labels: map[string]string{"job": "node"}},
+	}, d.targets())
+
+	writeFile(t, filepath.Join(dir, "b.yaml"), `
+- targets:
+    - host2:9100
+  labels:
+    job: app
+`)
+	require.Eventually(t, func() bool {
+		return len(d.targets()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, TargetConfig{Endpoint: "http://host2:9100/metrics", Labels: map[string]string{"job": "app"}}, d.targets()[1])
+
+	// invalid files keep their last targets.
+	writeFile(t, filepath.Join(dir, "a.json"), `[{"targets": `)
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.yaml")))
+	require.Eventually(t, func() bool {
+		return len(d.targets()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "http://host1:9100/metrics", d.targets()[0].Endpoint)
+}
+
+// writeFile replaces a file atomically so that it is never read partially written.
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.WriteFile(path+".tmp", []byte(content), 0600))
+	require.NoError(t, os.Rename(path+".tmp", path))
+}
+
+func TestHTTPDiscovery(t *testing.T) {
+	status := http.StatusOK
+	sd := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+		_, err := rw.Write([]byte(`[{"targets": ["host1:9100"], "labels": {"job": "node"}}]`))
+		require.NoError(t, err)
+	}))
+	defer sd.Close()
+
+	cfg := &HTTPSDConfig{ClientConfig: confighttp.NewDefaultClientConfig()}
+	cfg.Endpoint = sd.URL
+	d := newHTTPDiscovery(cfg, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	defer d.shutdown()
+
+	expected := []TargetConfig{
+		{Endpoint: "http://host1:9100/metrics", Labels: map[string]string{"job": "node"}},
+	}
+	require.Equal(t, expected, d.targets())
+
+	status = http.StatusInternalServerError
+	require.ErrorContains(t, d.refresh(context.Background()), "returned status 500")
+	require.Equal(t, expected, d.targets())
+}
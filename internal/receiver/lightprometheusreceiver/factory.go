@@ -43,7 +43,7 @@ func createMetricsReceiver(
 	c, _ := rConf.(*Config)
 	s := newScraper(params, c)
 
-	scraper, err := scraperhelper.NewScraper(typeStr, s.scrape, scraperhelper.WithStart(s.start))
+	scraper, err := scraperhelper.NewScraper(typeStr, s.scrape, scraperhelper.WithStart(s.start), scraperhelper.WithShutdown(s.shutdown))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,108 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// isNativeHistogramFamily returns true if all the histograms of the family are native histograms.
+// Native histograms are only exposed with the protobuf format.
+func isNativeHistogramFamily(family *dto.MetricFamily) bool {
+	if len(family.GetMetric()) == 0 {
+		return false
+	}
+	for _, fm := range family.GetMetric() {
+		if !isNativeHistogram(fm.GetHistogram()) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNativeHistogram returns true if the histogram has native histogram fields. Native histograms
+// without observations still have a zero threshold or an empty span to tell them apart from classic ones.
+func isNativeHistogram(h *dto.Histogram) bool {
+	return h.GetZeroThreshold() > 0 ||
+		h.GetZeroCount() > 0 ||
+		h.GetZeroCountFloat() > 0 ||
+		len(h.GetPositiveSpan()) > 0 ||
+		len(h.GetNegativeSpan()) > 0
+}
+
+// convertNativeHistogram converts a Prometheus native histogram to an OTLP exponential histogram data point.
+// Both use the same base for a given schema and scale, but Prometheus bucket i covers (base^(i-1), base^i]
+// while OTLP bucket i covers (base^i, base^(i+1)], hence the offsets are shifted by one.
+func convertNativeHistogram(h *dto.Histogram, dp pmetric.ExponentialHistogramDataPoint) {
+	dp.SetScale(h.GetSchema())
+	dp.SetZeroThreshold(h.GetZeroThreshold())
+	dp.SetSum(h.GetSampleSum())
+
+	if h.GetSampleCountFloat() > 0 {
+		dp.SetCount(uint64(h.GetSampleCountFloat()))
+	} else {
+		dp.SetCount(h.GetSampleCount())
+	}
+
+	if h.GetZeroCountFloat() > 0 {
+		dp.SetZeroCount(uint64(h.GetZeroCountFloat()))
+	} else {
+		dp.SetZeroCount(h.GetZeroCount())
+	}
+
+	convertNativeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta(), h.GetPositiveCount(), dp.Positive())
+	convertNativeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta(), h.GetNegativeCount(), dp.Negative())
+}
+
+// convertNativeBuckets converts sparse native histogram buckets, described by spans and either
+// delta-encoded integer counts or absolute float counts, to dense OTLP exponential histogram buckets.
+func convertNativeBuckets(spans []*dto.BucketSpan, deltas []int64, counts []float64, buckets pmetric.ExponentialHistogramDataPointBuckets) {
+	if len(spans) == 0 {
+		return
+	}
+
+	useDeltas := len(deltas) > 0
+	first := true
+	var index int32
+	var count int64
+	bucket := 0
+	for _, span := range spans {
+		if first {
+			index = span.GetOffset()
+			buckets.SetOffset(index - 1)
+			first = false
+		} else {
+			for i := int32(0); i < span.GetOffset(); i++ {
+				buckets.BucketCounts().Append(0)
+			}
+			index += span.GetOffset()
+		}
+
+		for i := uint32(0); i < span.GetLength(); i++ {
+			switch {
+			case useDeltas && bucket < len(deltas):
+				count += deltas[bucket]
+				buckets.BucketCounts().Append(uint64(count))
+			case !useDeltas && bucket < len(counts):
+				buckets.BucketCounts().Append(uint64(counts[bucket]))
+			default:
+				buckets.BucketCounts().Append(0)
+			}
+			bucket++
+			index++
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const openMetricsType = "application/openmetrics-text"
+
+var openMetricsTypes = map[textparse.MetricType]dto.MetricType{
+	textparse.MetricTypeCounter:        dto.MetricType_COUNTER,
+	textparse.MetricTypeGauge:          dto.MetricType_GAUGE,
+	textparse.MetricTypeHistogram:      dto.MetricType_HISTOGRAM,
+	textparse.MetricTypeGaugeHistogram: dto.MetricType_GAUGE_HISTOGRAM,
+	textparse.MetricTypeSummary:        dto.MetricType_SUMMARY,
+	textparse.MetricTypeInfo:           dto.MetricType_GAUGE,
+	textparse.MetricTypeStateset:       dto.MetricType_GAUGE,
+	textparse.MetricTypeUnknown:        dto.MetricType_UNTYPED,
+}
+
+// openMetricsSuffixes are the sample name suffixes of each OpenMetrics metric type.
+var openMetricsSuffixes = map[textparse.MetricType][]string{
+	textparse.MetricTypeCounter:        {"_total", "_created"},
+	textparse.MetricTypeHistogram:      {"_bucket", "_count", "_sum", "_created"},
+	textparse.MetricTypeGaugeHistogram: {"_bucket", "_gcount", "_gsum"},
+	textparse.MetricTypeSummary:        {"", "_count", "_sum", "_created"},
+	textparse.MetricTypeInfo:           {"_info"},
+}
+
+// openMetricsFamily accumulates the samples of an OpenMetrics metric family,
+// grouping them by label set into Prometheus client model metrics.
+type openMetricsFamily struct {
+	family  *dto.MetricFamily
+	metrics map[string]*dto.Metric
+	name    string
+	omType  textparse.MetricType
+}
+
+// suffix returns the suffix of the sample name in the family, and whether the sample belongs to the family.
+func (f *openMetricsFamily) suffix(name string) (string, bool) {
+	if !strings.HasPrefix(name, f.name) {
+		return "", false
+	}
+	suffix := strings.TrimPrefix(name, f.name)
+	suffixes, ok := openMetricsSuffixes[f.omType]
+	if !ok {
+		return suffix, suffix == ""
+	}
+	return suffix, slices.Contains(suffixes, suffix)
+}
+
+// parseOpenMetrics parses an OpenMetrics text exposition into Prometheus client model metric families.
+func parseOpenMetrics(b []byte) ([]*dto.MetricFamily, error) {
+	parser := textparse.NewOpenMetricsParser(b)
+
+	var mfs []*dto.MetricFamily
+	families := map[string]*openMetricsFamily{}
+	getFamily := func(name string) *openMetricsFamily {
+		f, ok := families[name]
+		if !ok {
+			f = &openMetricsFamily{
+				family:  &dto.MetricFamily{Name: strPtr(name), Type: dto.MetricType_UNTYPED.Enum()},
+				metrics: map[string]*dto.Metric{},
+				name:    name,
+				omType:  textparse.MetricTypeUnknown,
+			}
+			families[name] = f
+			mfs = append(mfs, f.family)
+		}
+		return f
+	}
+
+	var current *openMetricsFamily
+	for {
+		entry, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry {
+		case textparse.EntryType:
+			name, omType := parser.Type()
+			current = getFamily(string(name))
+			current.omType = omType
+			if t, ok := openMetricsTypes[omType]; ok {
+				current.family.Type = t.Enum()
+			}
+		case textparse.EntryHelp:
+			name, help := parser.Help()
+			current = getFamily(string(name))
+			current.family.Help = strPtr(string(help))
+		case textparse.EntryUnit:
+			name, unit := parser.Unit()
+			current = getFamily(string(name))
+			current.family.Unit = strPtr(string(unit))
+		case textparse.EntrySeries:
+			_, ts, value := parser.Series()
+			var lset labels.Labels
+			parser.Metric(&lset)
+			var ex exemplar.Exemplar
+			var exPtr *exemplar.Exemplar
+			if parser.Exemplar(&ex) {
+				exPtr = &ex
+			}
+
+			name := lset.Get(labels.MetricName)
+			var suffix string
+			belongs := false
+			if current != nil {
+				suffix, belongs = current.suffix(name)
+			}
+			if !belongs {
+				current = getFamily(name)
+				suffix = ""
+			}
+			if err = current.addSample(suffix, lset, ts, value, exPtr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return mfs, nil
+}
+
+func (f *openMetricsFamily) metric(lset labels.Labels, ts *int64) *dto.Metric {
+	key := string(lset.BytesWithoutLabels(nil, labels.MetricName, labels.BucketLabel, "quantile"))
+	m, ok := f.metrics[key]
+	if !ok {
+		m = &dto.Metric{}
+		lset.Range(func(l labels.Label) {
+			if l.Name == labels.MetricName || l.Name == labels.BucketLabel || l.Name == "quantile" {
+				return
+			}
+			m.Label = append(m.Label, &dto.LabelPair{Name: strPtr(l.Name), Value: strPtr(l.Value)})
+		})
+		f.metrics[key] = m
+		f.family.Metric = append(f.family.Metric, m)
+	}
+	if ts != nil {
+		m.TimestampMs = ts
+	}
+	return m
+}
+
+func (f *openMetricsFamily) addSample(suffix string, lset labels.Labels, ts *int64, value float64, ex *exemplar.Exemplar) error {
+	m := f.metric(lset, ts)
+
+	switch f.omType {
+	case textparse.MetricTypeCounter:
+		if m.Counter == nil {
+			m.Counter = &dto.Counter{}
+		}
+		switch suffix {
+		case "_total":
+			// Counters are named after their samples, as in the Prometheus text format.
+			f.family.Name = strPtr(lset.Get(labels.MetricName))
+			m.Counter.Value = &value
+			m.Counter.Exemplar = convertOpenMetricsExemplar(ex)
+		case "_created":
+			m.Counter.CreatedTimestamp = secondsToTimestamp(value)
+		}
+	case textparse.MetricTypeHistogram, textparse.MetricTypeGaugeHistogram:
+		if m.Histogram == nil {
+			m.Histogram = &dto.Histogram{}
+		}
+		switch suffix {
+		case "_bucket":
+			upperBound, err := strconv.ParseFloat(lset.Get(labels.BucketLabel), 64)
+			if err != nil {
+				return err
+			}
+			m.Histogram.Bucket = append(m.Histogram.Bucket, &dto.Bucket{
+				UpperBound:      &upperBound,
+				CumulativeCount: uint64Ptr(value),
+				Exemplar:        convertOpenMetricsExemplar(ex),
+			})
+		case "_count", "_gcount":
+			m.Histogram.SampleCount = uint64Ptr(value)
+		case "_sum", "_gsum":
+			m.Histogram.SampleSum = &value
+		case "_created":
+			m.Histogram.CreatedTimestamp = secondsToTimestamp(value)
+		}
+	case textparse.MetricTypeSummary:
+		if m.Summary == nil {
+			m.Summary = &dto.Summary{}
+		}
+		switch suffix {
+		case "":
+			quantile, err := strconv.ParseFloat(lset.Get("quantile"), 64)
+			if err != nil {
+				return err
+			}
+			m.Summary.Quantile = append(m.Summary.Quantile, &dto.Quantile{Quantile: &quantile, Value: &value})
+		case "_count":
+			m.Summary.SampleCount = uint64Ptr(value)
+		case "_sum":
+			m.Summary.SampleSum = &value
+		case "_created":
+			m.Summary.CreatedTimestamp = secondsToTimestamp(value)
+		}
+	case textparse.MetricTypeUnknown:
+		m.Untyped = &dto.Untyped{Value: &value}
+	default:
+		if f.omType == textparse.MetricTypeInfo {
+			f.family.Name = strPtr(lset.Get(labels.MetricName))
+		}
+		m.Gauge = &dto.Gauge{Value: &value}
+	}
+	return nil
+}
+
+func convertOpenMetricsExemplar(ex *exemplar.Exemplar) *dto.Exemplar {
+	if ex == nil {
+		return nil
+	}
+	e := &dto.Exemplar{Value: &ex.Value}
+	ex.Labels.Range(func(l labels.Label) {
+		e.Label = append(e.Label, &dto.LabelPair{Name: strPtr(l.Name), Value: strPtr(l.Value)})
+	})
+	if ex.HasTs {
+		e.Timestamp = timestamppb.New(time.UnixMilli(ex.Ts))
+	}
+	return e
+}
+
+func secondsToTimestamp(seconds float64) *timestamppb.Timestamp {
+	sec, frac := math.Modf(seconds)
+	return timestamppb.New(time.Unix(int64(sec), int64(frac*1e9)))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func uint64Ptr(f float64) *uint64 {
+	u := uint64(f)
+	return &u
+}
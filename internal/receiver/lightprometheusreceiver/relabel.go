@@ -0,0 +1,277 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	actionKeep     = "keep"
+	actionDrop     = "drop"
+	actionReplace  = "replace"
+	actionLabelMap = "labelmap"
+
+	metricNameLabel = "__name__"
+
+	defaultSeparator   = ";"
+	defaultRegex       = "(.*)"
+	defaultReplacement = "$1"
+)
+
+// relabeler filters the scraped metric families by name and applies the label rules to their series.
+type relabeler struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	rules   []labelRule
+}
+
+type labelRule struct {
+	regex        *regexp.Regexp
+	action       string
+	separator    string
+	targetLabel  string
+	replacement  string
+	sourceLabels []string
+}
+
+func newRelabeler(cfg *Config) (*relabeler, error) {
+	r := &relabeler{}
+	var err error
+	if r.include, err = compileRegexes(cfg.MetricFilter.Include); err != nil {
+		return nil, fmt.Errorf("invalid metric_filter include: %w", err)
+	}
+	if r.exclude, err = compileRegexes(cfg.MetricFilter.Exclude); err != nil {
+		return nil, fmt.Errorf("invalid metric_filter exclude: %w", err)
+	}
+	for i, ruleCfg := range cfg.LabelRules {
+		rule, err := newLabelRule(ruleCfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label_rules %d: %w", i, err)
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r, nil
+}
+
+func newLabelRule(cfg LabelRuleConfig) (labelRule, error) {
+	rule := labelRule{
+		action:       cfg.Action,
+		separator:    cfg.Separator,
+		targetLabel:  cfg.TargetLabel,
+		replacement:  cfg.Replacement,
+		sourceLabels: cfg.SourceLabels,
+	}
+	if rule.action == "" {
+		rule.action = actionReplace
+	}
+	if rule.separator == "" {
+		rule.separator = defaultSeparator
+	}
+	if rule.replacement == "" {
+		rule.replacement = defaultReplacement
+	}
+	expr := cfg.Regex
+	if expr == "" {
+		expr = defaultRegex
+	}
+	var err error
+	if rule.regex, err = compileRegex(expr); err != nil {
+		return rule, err
+	}
+
+	switch rule.action {
+	case actionKeep, actionDrop:
+		if len(rule.sourceLabels) == 0 {
+			return rule, fmt.Errorf(`"source_labels" is required for the %q action`, rule.action)
+		}
+	case actionReplace:
+		if len(rule.sourceLabels) == 0 || rule.targetLabel == "" {
+			return rule, fmt.Errorf(`"source_labels" and "target_label" are required for the %q action`, rule.action)
+		}
+		if rule.targetLabel == metricNameLabel {
+			return rule, fmt.Errorf("renaming metrics is not supported")
+		}
+	case actionLabelMap:
+	default:
+		return rule, fmt.Errorf("unknown action %q", rule.action)
+	}
+	return rule, nil
+}
+
+// compileRegex compiles a regular expression anchored at both ends, as Prometheus does.
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+func compileRegexes(exprs []string) ([]*regexp.Regexp, error) {
+	var regexes []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := compileRegex(expr)
+		if err != nil {
+			return nil, err
+		}
+		regexes = append(regexes, re)
+	}
+	return regexes, nil
+}
+
+// apply returns the metric families matching the metric filter, with the label rules applied to their series.
+// The families without any series left are dropped.
+func (r *relabeler) apply(families []*dto.MetricFamily) []*dto.MetricFamily {
+	if len(r.include) == 0 && len(r.exclude) == 0 && len(r.rules) == 0 {
+		return families
+	}
+
+	kept := families[:0]
+	for _, family := range families {
+		if !r.includes(family.GetName()) {
+			continue
+		}
+		if len(r.rules) > 0 {
+			metrics := family.Metric[:0]
+			for _, m := range family.Metric {
+				if labels, keep := r.relabel(family.GetName(), m.Label); keep {
+					m.Label = labels
+					metrics = append(metrics, m)
+				}
+			}
+			family.Metric = metrics
+			if len(metrics) == 0 {
+				continue
+			}
+		}
+		kept = append(kept, family)
+	}
+	return kept
+}
+
+func (r *relabeler) includes(name string) bool {
+	if len(r.include) > 0 && !matchesAny(r.include, name) {
+		return false
+	}
+	return !matchesAny(r.exclude, name)
+}
+
+func matchesAny(regexes []*regexp.Regexp, s string) bool {
+	for _, re := range regexes {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// relabel applies the label rules to the labels of a series, returning false if the series is dropped.
+func (r *relabeler) relabel(name string, labels []*dto.LabelPair) ([]*dto.LabelPair, bool) {
+	for _, rule := range r.rules {
+		switch rule.action {
+		case actionKeep:
+			if !rule.regex.MatchString(rule.sourceValue(name, labels)) {
+				return nil, false
+			}
+		case actionDrop:
+			if rule.regex.MatchString(rule.sourceValue(name, labels)) {
+				return nil, false
+			}
+		case actionReplace:
+			value := rule.sourceValue(name, labels)
+			indexes := rule.regex.FindStringSubmatchIndex(value)
+			if indexes == nil {
+				continue
+			}
+			target := string(rule.regex.ExpandString(nil, rule.targetLabel, value, indexes))
+			replacement := string(rule.regex.ExpandString(nil, rule.replacement, value, indexes))
+			labels = setLabel(labels, target, replacement)
+		case actionLabelMap:
+			for _, l := range labels {
+				indexes := rule.regex.FindStringSubmatchIndex(l.GetName())
+				if indexes == nil {
+					continue
+				}
+				target := string(rule.regex.ExpandString(nil, rule.replacement, l.GetName(), indexes))
+				labels = setLabel(labels, target, l.GetValue())
+			}
+		}
+	}
+	return labels, true
+}
+
+// sourceValue joins the values of the source labels, the __name__ label being the metric name.
+func (rule *labelRule) sourceValue(name string, labels []*dto.LabelPair) string {
+	values := make([]string, 0, len(rule.sourceLabels))
+	for _, source := range rule.sourceLabels {
+		if source == metricNameLabel {
+			values = append(values, name)
+			continue
+		}
+		values = append(values, labelValue(labels, source))
+	}
+	return strings.Join(values, rule.separator)
+}
+
+func labelValue(labels []*dto.LabelPair, name string) string {
+	for _, l := range labels {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// setLabel sets the value of a label, removing the label if the value is empty.
+func setLabel(labels []*dto.LabelPair, name string, value string) []*dto.LabelPair {
+	if name == "" || name == metricNameLabel {
+		return labels
+	}
+	for i, l := range labels {
+		if l.GetName() != name {
+			continue
+		}
+		if value == "" {
+			return append(labels[:i:i], labels[i+1:]...)
+		}
+		l.Value = strPtr(value)
+		return labels
+	}
+	if value == "" {
+		return labels
+	}
+	return append(labels, &dto.LabelPair{Name: strPtr(name), Value: strPtr(value)})
+}
+
+// countSamples counts the samples of the metric families as they are exposed in the Prometheus text format.
+func countSamples(families []*dto.MetricFamily) int {
+	count := 0
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch {
+			case m.Histogram != nil && isNativeHistogram(m.Histogram):
+				count++
+			case m.Histogram != nil:
+				count += len(m.Histogram.GetBucket()) + 2
+			case m.Summary != nil:
+				count += len(m.Summary.GetQuantile()) + 2
+			default:
+				count++
+			}
+		}
+	}
+	return count
+}
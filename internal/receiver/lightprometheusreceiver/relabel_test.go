@@ -0,0 +1,208 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+const relabelMetrics = `# TYPE http_requests_total counter
+http_requests_total{code="200",path="/api",pod_name="web-1"} 10
+http_requests_total{code="500",path="/api",pod_name="web-1"} 1
+http_requests_total{code="200",path="/health",pod_name="web-1"} 100
+# TYPE go_goroutines gauge
+go_goroutines 12
+# TYPE go_threads gauge
+go_threads 5
+# TYPE process_cpu_seconds_total counter
+process_cpu_seconds_total 3.5
+`
+
+func TestRelabeler(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      func(cfg *Config)
+		expected map[string][]map[string]any
+	}{
+		{
+			name: "no_rules",
+			cfg:  func(*Config) {},
+			expected: map[string][]map[string]any{
+				"http_requests_total": {
+					{"code": "200", "path": "/api", "pod_name": "web-1"},
+					{"code": "500", "path": "/api", "pod_name": "web-1"},
+					{"code": "200", "path": "/health", "pod_name": "web-1"},
+				},
+				"go_goroutines":             {{}},
+				"go_threads":                {{}},
+				"process_cpu_seconds_total": {{}},
+			},
+		},
+		{
+			name: "metric_filter",
+			cfg: func(cfg *Config) {
+				cfg.MetricFilter.Include = []string{"go_.*", "process_.*"}
+				cfg.MetricFilter.Exclude = []string{"go_threads"}
+			},
+			expected: map[string][]map[string]any{
+				"go_goroutines":             {{}},
+				"process_cpu_seconds_total": {{}},
+			},
+		},
+		{
+			name: "keep_and_drop",
+			cfg: func(cfg *Config) {
+				cfg.LabelRules = []LabelRuleConfig{
+					{Action: actionKeep, SourceLabels: []string{metricNameLabel}, Regex: "http_.*"},
+					{Action: actionDrop, SourceLabels: []string{"code", "path"}, Regex: "200;/health"},
+				}
+			},
+			expected: map[string][]map[string]any{
+				"http_requests_total": {
+					{"code": "200", "path": "/api", "pod_name": "web-1"},
+					{"code": "500", "path": "/api", "pod_name": "web-1"},
+				},
+			},
+		},
+		{
+			name: "replace_and_labelmap",
+			cfg: func(cfg *Config) {
+				cfg.MetricFilter.Include = []string{"http_requests_total"}
+				cfg.LabelRules = []LabelRuleConfig{
+					{SourceLabels: []string{"code"}, Regex: "(\\d)\\d\\d", TargetLabel: "class", Replacement: "${1}xx"},
+					{SourceLabels: []string{"path"}, Regex: "/health", TargetLabel: "path", Replacement: "-"},
+					{Action: actionLabelMap, Regex: "pod_(.*)", Replacement: "k8s_pod_$1"},
+				}
+			},
+			expected: map[string][]map[string]any{
+				"http_requests_total": {
+					{"code": "200", "class": "2xx", "path": "/api", "pod_name": "web-1", "k8s_pod_name": "web-1"},
+					{"code": "500", "class": "5xx", "path": "/api", "pod_name": "web-1", "k8s_pod_name": "web-1"},
+					{"code": "200", "class": "2xx", "path": "-", "pod_name": "web-1", "k8s_pod_name": "web-1"},
+				},
+			},
+		},
+	}
+
+	promMock := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(200)
+		_, err := rw.Write([]byte(relabelMetrics))
+		require.NoError(t, err)
+	}))
+	defer promMock.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.ClientConfig.Endpoint = promMock.URL + "/metrics"
+			tt.cfg(cfg)
+			require.NoError(t, cfg.Validate())
+
+			scraper := newScraper(receivertest.NewNopCreateSettings(), cfg)
+			require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+			md, err := scraper.scrape(context.Background())
+			require.NoError(t, err)
+
+			actual := map[string][]map[string]any{}
+			metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+			for i := 0; i < metrics.Len(); i++ {
+				metric := metrics.At(i)
+				if metric.Name() == upMetricName || strings.HasPrefix(metric.Name(), "scrape_") {
+					continue
+				}
+				var dps pmetric.NumberDataPointSlice
+				switch metric.Type() {
+				case pmetric.MetricTypeSum:
+					dps = metric.Sum().DataPoints()
+				case pmetric.MetricTypeGauge:
+					dps = metric.Gauge().DataPoints()
+				}
+				for j := 0; j < dps.Len(); j++ {
+					actual[metric.Name()] = append(actual[metric.Name()], dps.At(j).Attributes().AsRaw())
+				}
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSampleLimit(t *testing.T) {
+	promMock := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(200)
+		_, err := rw.Write([]byte(relabelMetrics))
+		require.NoError(t, err)
+	}))
+	defer promMock.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = promMock.URL + "/metrics"
+	cfg.SampleLimit = 5
+	scraper := newScraper(receivertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+
+	md, err := scraper.scrape(context.Background())
+	require.ErrorContains(t, err, "exposed 6 samples, exceeding the sample limit of 5")
+	scrapeMetrics := map[string]float64{}
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < metrics.Len(); i++ {
+		scrapeMetrics[metrics.At(i).Name()] = metrics.At(i).Gauge().DataPoints().At(0).DoubleValue()
+	}
+	delete(scrapeMetrics, scrapeDurationMetricName)
+	require.Equal(t, map[string]float64{
+		upMetricName:                          0,
+		scrapeSamplesScrapedMetricName:        6,
+		scrapeSamplesPostRelabelingMetricName: 6,
+	}, scrapeMetrics)
+
+	// the limit applies to the samples left after filtering.
+	cfg.MetricFilter.Exclude = []string{"go_.*"}
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+	md, err = scraper.scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4+4, md.DataPointCount())
+}
+
+func TestInvalidLabelRules(t *testing.T) {
+	tests := []struct {
+		rule LabelRuleConfig
+		err  string
+	}{
+		{rule: LabelRuleConfig{Action: "hashmod"}, err: `unknown action "hashmod"`},
+		{rule: LabelRuleConfig{Action: actionKeep}, err: `"source_labels" is required`},
+		{rule: LabelRuleConfig{SourceLabels: []string{"a"}}, err: `"target_label" are required`},
+		{rule: LabelRuleConfig{SourceLabels: []string{"a"}, TargetLabel: metricNameLabel}, err: "renaming metrics"},
+		{rule: LabelRuleConfig{Action: actionLabelMap, Regex: "("}, err: "missing closing )"},
+	}
+	for _, tt := range tests {
+		cfg := createDefaultConfig().(*Config)
+		cfg.ClientConfig.Endpoint = "http://localhost:9090/metrics"
+		cfg.LabelRules = []LabelRuleConfig{tt.rule}
+		require.ErrorContains(t, cfg.Validate(), tt.err)
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = "http://localhost:9090/metrics"
+	cfg.MetricFilter.Include = []string{"["}
+	require.ErrorContains(t, cfg.Validate(), "invalid metric_filter include")
+}
@@ -0,0 +1,62 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Names of the metrics synthesized for each target on every scrape, as Prometheus does.
+const (
+	upMetricName                          = "up"
+	scrapeDurationMetricName              = "scrape_duration_seconds"
+	scrapeSamplesScrapedMetricName        = "scrape_samples_scraped"
+	scrapeSamplesPostRelabelingMetricName = "scrape_samples_post_metric_relabeling"
+)
+
+// scrapeResult is the outcome of the scrape of a target.
+type scrapeResult struct {
+	duration       time.Duration
+	scraped        int
+	postRelabeling int
+	up             bool
+}
+
+// appendScrapeMetrics appends the gauges describing the scrape of a target, including failed ones.
+func appendScrapeMetrics(sm pmetric.ScopeMetrics, now pcommon.Timestamp, result scrapeResult) {
+	up := 0.0
+	if result.up {
+		up = 1
+	}
+	appendScrapeGauge(sm, now, upMetricName, "The scraping was successful.", "", up)
+	appendScrapeGauge(sm, now, scrapeDurationMetricName, "Duration of the scrape.", "s", result.duration.Seconds())
+	appendScrapeGauge(sm, now, scrapeSamplesScrapedMetricName, "The number of samples the target exposed.", "",
+		float64(result.scraped))
+	appendScrapeGauge(sm, now, scrapeSamplesPostRelabelingMetricName,
+		"The number of samples remaining after metric filtering and relabeling was applied.", "", float64(result.postRelabeling))
+}
+
+func appendScrapeGauge(sm pmetric.ScopeMetrics, now pcommon.Timestamp, name, description, unit string, value float64) {
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName(name)
+	metric.SetDescription(description)
+	metric.SetUnit(unit)
+	dp := metric.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(now)
+	dp.SetDoubleValue(value)
+}
@@ -15,12 +15,18 @@
 package lightprometheusreceiver
 
 import (
+	"bufio"
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	dto "github.com/prometheus/client_model/go"
@@ -29,16 +35,38 @@ import (
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/scrapererror"
 	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
 	"go.uber.org/zap"
 )
 
+const (
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+// acceptHeader prefers the protobuf format, which is the only one carrying native histograms, then the
+// OpenMetrics text format, which carries exemplars and created timestamps, over the Prometheus text format.
+const acceptHeader = "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited," +
+	openMetricsType + ";version=1.0.0;q=0.8," + openMetricsType + ";version=0.0.1;q=0.75," +
+	"text/plain;version=0.0.4;q=0.5,*/*;q=0.1"
+
 type scraper struct {
-	settings  component.TelemetrySettings
-	client    *http.Client
-	cfg       *Config
-	name      string
-	startTime pcommon.Timestamp
+	settings    component.TelemetrySettings
+	client      *http.Client
+	cfg         *Config
+	targets     map[string]*target
+	relabeler   *relabeler
+	discoverers []discoverer
+	name        string
+	startTime   pcommon.Timestamp
+}
+
+// target is a target to scrape, with the state of its series.
+type target struct {
+	labels   map[string]string
+	series   *seriesTracker
+	endpoint string
 }
 
 func newScraper(
@@ -48,27 +76,156 @@ func newScraper(
 	e := &scraper{
 		settings: settings.TelemetrySettings,
 		cfg:      cfg,
+		targets:  map[string]*target{},
 		name:     settings.ID.Name(),
 	}
+	if cfg.FileSD != nil {
+		e.discoverers = append(e.discoverers, newFileDiscovery(cfg.FileSD, settings.Logger))
+	}
+	if cfg.HTTPSD != nil {
+		e.discoverers = append(e.discoverers, newHTTPDiscovery(cfg.HTTPSD, settings.TelemetrySettings))
+	}
 
 	return e
 }
 
-func (s *scraper) start(_ context.Context, host component.Host) error {
+func (s *scraper) start(ctx context.Context, host component.Host) error {
 	s.startTime = pcommon.NewTimestampFromTime(time.Now())
 	var err error
 	s.client, err = s.cfg.ClientConfig.ToClient(host, s.settings)
-	return err
+	if err != nil {
+		return err
+	}
+	if s.relabeler, err = newRelabeler(s.cfg); err != nil {
+		return err
+	}
+	for _, d := range s.discoverers {
+		if err = d.start(ctx, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scraper) shutdown(context.Context) error {
+	for _, d := range s.discoverers {
+		d.shutdown()
+	}
+	return nil
 }
 
 type fetcher func() (io.ReadCloser, expfmt.Format, error)
 
-func (s *scraper) scrape(context.Context) (pmetric.Metrics, error) {
+// scrape scrapes all the targets, at most max_concurrent_scrapes at a time. A partial scrape error is returned
+// along with the metrics of the other targets and the scrape metrics of all targets if some targets fail.
+func (s *scraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
+	targets, removed := s.currentTargets()
+
+	results := make([]pmetric.Metrics, len(targets))
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, s.cfg.MaxConcurrentScrapes)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, t *target) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = s.scrapeTarget(ctx, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	m := pmetric.NewMetrics()
+	for i := range targets {
+		results[i].ResourceMetrics().MoveAndAppendTo(m.ResourceMetrics())
+	}
+	now := pcommon.NewTimestampFromTime(time.Now())
+	for _, t := range removed {
+		// the series of the targets which are gone are stale
+		if len(t.series.series) == 0 {
+			continue
+		}
+		if sm, err := s.appendTargetResource(m, t); err == nil {
+			t.series.markAllStale(sm, now)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		// the scrape metrics of the failed targets are still emitted,
+		// the number of data points which failed to be scraped is unknown.
+		return m, scrapererror.NewPartialScrapeError(err, 0)
+	}
+	return m, nil
+}
+
+// currentTargets returns the endpoint, the configured targets and the discovered targets to scrape,
+// keeping the state of the targets which were already scraped, and the targets which are no longer to scrape.
+func (s *scraper) currentTargets() ([]*target, []*target) {
+	var configs []TargetConfig
+	if s.cfg.ClientConfig.Endpoint != "" {
+		configs = append(configs, TargetConfig{Endpoint: s.cfg.ClientConfig.Endpoint})
+	}
+	configs = append(configs, s.cfg.Targets...)
+	for _, d := range s.discoverers {
+		configs = append(configs, d.targets()...)
+	}
+
+	targets := make([]*target, 0, len(configs))
+	current := make(map[string]*target, len(configs))
+	for _, cfg := range configs {
+		key := targetKey(cfg)
+		if _, ok := current[key]; ok {
+			continue
+		}
+		t, ok := s.targets[key]
+		if !ok {
+			t = &target{
+				labels:   cfg.Labels,
+				series:   newSeriesTracker(),
+				endpoint: cfg.Endpoint,
+			}
+		}
+		current[key] = t
+		targets = append(targets, t)
+	}
+	var removed []*target
+	for key, t := range s.targets {
+		if _, ok := current[key]; !ok {
+			removed = append(removed, t)
+		}
+	}
+	s.targets = current
+	return targets, removed
+}
+
+// targetKey identifies a target by its endpoint and labels.
+func targetKey(cfg TargetConfig) string {
+	names := make([]string, 0, len(cfg.Labels))
+	for name := range cfg.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(cfg.Endpoint)
+	for _, name := range names {
+		b.WriteByte(0xff)
+		b.WriteString(name)
+		b.WriteByte(0xff)
+		b.WriteString(cfg.Labels[name])
+	}
+	return b.String()
+}
+
+func (s *scraper) scrapeTarget(ctx context.Context, t *target) (pmetric.Metrics, error) {
 	fetch := func() (io.ReadCloser, expfmt.Format, error) {
-		req, err := http.NewRequest("GET", s.cfg.ClientConfig.Endpoint, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", t.endpoint, nil)
 		if err != nil {
 			return nil, expfmt.NewFormat(expfmt.TypeUnknown), err
 		}
+		req.Header.Set("Accept", acceptHeader)
 
 		resp, err := s.client.Do(req)
 		if err != nil {
@@ -77,28 +234,50 @@ func (s *scraper) scrape(context.Context) (pmetric.Metrics, error) {
 
 		if resp.StatusCode != 200 {
 			body, _ := io.ReadAll(resp.Body)
-			return nil, expfmt.NewFormat(expfmt.TypeUnknown), fmt.Errorf("light prometheus %s returned status %d: %s", s.cfg.ClientConfig.Endpoint, resp.StatusCode, string(body))
+			return nil, expfmt.NewFormat(expfmt.TypeUnknown), fmt.Errorf("light prometheus %s returned status %d: %s", t.endpoint, resp.StatusCode, string(body))
 		}
-		return resp.Body, expfmt.ResponseFormat(resp.Header), nil
+		return resp.Body, responseFormat(resp.Header), nil
 	}
-	return s.fetchPrometheusMetrics(fetch)
+	return s.fetchPrometheusMetrics(fetch, t)
 }
 
-func (s *scraper) fetchPrometheusMetrics(fetch fetcher) (pmetric.Metrics, error) {
-	metricFamilies, err := s.doFetch(fetch)
+func (s *scraper) fetchPrometheusMetrics(fetch fetcher, t *target) (pmetric.Metrics, error) {
+	start := time.Now()
 	m := pmetric.NewMetrics()
+	sm, err := s.appendTargetResource(m, t)
 	if err != nil {
 		return m, err
 	}
+	scraped, postRelabeling, err := s.convertTarget(fetch, t, sm)
+	now := pcommon.NewTimestampFromTime(time.Now())
+	if err != nil {
+		// as in Prometheus, all the series of a target are stale when it fails to be scraped
+		t.series.markAllStale(sm, now)
+	}
+	appendScrapeMetrics(sm, now, scrapeResult{
+		up:             err == nil,
+		duration:       time.Since(start),
+		scraped:        scraped,
+		postRelabeling: postRelabeling,
+	})
+	return m, err
+}
 
-	u, err := url.Parse(s.cfg.ClientConfig.Endpoint)
+// appendTargetResource appends the resource of a target to the metrics, returning the scope metrics of its data
+// points.
+func (s *scraper) appendTargetResource(m pmetric.Metrics, t *target) (pmetric.ScopeMetrics, error) {
+	u, err := url.Parse(t.endpoint)
 	if err != nil {
-		return m, err
+		return pmetric.ScopeMetrics{}, err
 	}
 	rm := m.ResourceMetrics().AppendEmpty()
 	res := rm.Resource()
 	if s.cfg.ResourceAttributes.ServiceName.Enabled {
-		res.Attributes().PutStr(conventions.AttributeServiceName, s.name)
+		name := s.name
+		if job, ok := t.labels["job"]; ok {
+			name = job
+		}
+		res.Attributes().PutStr(conventions.AttributeServiceName, name)
 	}
 	if s.cfg.ResourceAttributes.NetHostName.Enabled {
 		res.Attributes().PutStr(conventions.AttributeNetHostName, u.Host)
@@ -112,8 +291,29 @@ func (s *scraper) fetchPrometheusMetrics(fetch fetcher) (pmetric.Metrics, error)
 	if s.cfg.ResourceAttributes.HTTPScheme.Enabled {
 		res.Attributes().PutStr(conventions.AttributeHTTPScheme, u.Scheme)
 	}
-	s.convertMetricFamilies(metricFamilies, rm)
-	return m, nil
+	for k, v := range t.labels {
+		res.Attributes().PutStr(k, v)
+	}
+	return rm.ScopeMetrics().AppendEmpty(), nil
+}
+
+// convertTarget fetches the metrics of the target and converts them after filtering and relabeling,
+// returning the number of samples exposed by the target and left after relabeling.
+func (s *scraper) convertTarget(fetch fetcher, t *target, sm pmetric.ScopeMetrics) (int, int, error) {
+	metricFamilies, err := s.doFetch(fetch)
+	if err != nil {
+		return 0, 0, err
+	}
+	scraped := countSamples(metricFamilies)
+
+	metricFamilies = s.relabeler.apply(metricFamilies)
+	postRelabeling := countSamples(metricFamilies)
+	if s.cfg.SampleLimit > 0 && postRelabeling > s.cfg.SampleLimit {
+		return scraped, postRelabeling, fmt.Errorf("light prometheus %s exposed %d samples, exceeding the sample limit of %d", t.endpoint, postRelabeling, s.cfg.SampleLimit)
+	}
+
+	s.convertMetricFamilies(metricFamilies, sm, t.series)
+	return scraped, postRelabeling, nil
 }
 
 func (s *scraper) doFetch(fetch fetcher) ([]*dto.MetricFamily, error) {
@@ -122,12 +322,23 @@ func (s *scraper) doFetch(fetch fetcher) ([]*dto.MetricFamily, error) {
 		return nil, err
 	}
 	defer body.Close()
+
+	if expformat.FormatType() == expfmt.TypeOpenMetrics {
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return parseOpenMetrics(b)
+	}
+
 	var decoder expfmt.Decoder
 	// some "text" responses are missing \n from the last line
 	if expformat != expfmt.NewFormat(expfmt.TypeProtoDelim) {
 		decoder = expfmt.NewDecoder(io.MultiReader(body, strings.NewReader("\n")), expformat)
 	} else {
-		decoder = expfmt.NewDecoder(body, expformat)
+		// the protobuf decoder wraps its reader in a new bufio.Reader on each Decode call,
+		// dropping buffered families unless the reader already is a bufio.Reader.
+		decoder = expfmt.NewDecoder(bufio.NewReader(body), expformat)
 	}
 
 	var mfs []*dto.MetricFamily
@@ -146,14 +357,24 @@ func (s *scraper) doFetch(fetch fetcher) ([]*dto.MetricFamily, error) {
 	}
 }
 
-func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, rm pmetric.ResourceMetrics) {
+// responseFormat extracts the exposition format from the response headers,
+// extending expfmt.ResponseFormat with the OpenMetrics text format.
+func responseFormat(h http.Header) expfmt.Format {
+	if mediatype, _, err := mime.ParseMediaType(h.Get("Content-Type")); err == nil && mediatype == openMetricsType {
+		return expfmt.NewFormat(expfmt.TypeOpenMetrics)
+	}
+	return expfmt.ResponseFormat(h)
+}
+
+func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, sm pmetric.ScopeMetrics, series *seriesTracker) {
 	now := pcommon.NewTimestampFromTime(time.Now())
+	series.begin(s.startTime)
 
-	sm := rm.ScopeMetrics().AppendEmpty()
 	for _, family := range metricFamilies {
 		newMetric := sm.Metrics().AppendEmpty()
 		newMetric.SetName(family.GetName())
 		newMetric.SetDescription(family.GetHelp())
+		newMetric.SetUnit(family.GetUnit())
 		switch *family.Type {
 		case dto.MetricType_COUNTER:
 			sum := newMetric.SetEmptySum()
@@ -162,13 +383,17 @@ func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, rm p
 			for _, fm := range family.GetMetric() {
 				dp := sum.DataPoints().AppendEmpty()
 				dp.SetTimestamp(now)
-				dp.SetStartTimestamp(s.startTime)
 				dp.SetDoubleValue(fm.GetCounter().GetValue())
+				if e := fm.GetCounter().GetExemplar(); e != nil {
+					convertExemplar(e, dp.Exemplars().AppendEmpty(), now)
+				}
 				for _, l := range fm.GetLabel() {
 					if l.GetValue() != "" {
 						dp.Attributes().PutStr(l.GetName(), l.GetValue())
 					}
 				}
+				state := series.observe(newMetric, fm.GetLabel(), dp.Attributes(), now)
+				dp.SetStartTimestamp(state.startTimestamp(fm.GetCounter().GetCreatedTimestamp(), fm.GetCounter().GetValue(), 0, now))
 			}
 		case dto.MetricType_GAUGE:
 			gauge := newMetric.SetEmptyGauge()
@@ -182,14 +407,35 @@ func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, rm p
 						dp.Attributes().PutStr(l.GetName(), l.GetValue())
 					}
 				}
+				series.observe(newMetric, fm.GetLabel(), dp.Attributes(), now)
 			}
 		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			if isNativeHistogramFamily(family) {
+				histogram := newMetric.SetEmptyExponentialHistogram()
+				histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+				for _, fm := range family.Metric {
+					dp := histogram.DataPoints().AppendEmpty()
+					dp.SetTimestamp(now)
+					convertNativeHistogram(fm.GetHistogram(), dp)
+					for _, e := range fm.GetHistogram().GetExemplars() {
+						convertExemplar(e, dp.Exemplars().AppendEmpty(), now)
+					}
+					for _, l := range fm.GetLabel() {
+						if l.GetValue() != "" {
+							dp.Attributes().PutStr(l.GetName(), l.GetValue())
+						}
+					}
+					state := series.observe(newMetric, fm.GetLabel(), dp.Attributes(), now)
+					dp.SetStartTimestamp(state.startTimestamp(fm.GetHistogram().GetCreatedTimestamp(), float64(dp.Count()), dp.Sum(), now))
+				}
+				break
+			}
+
 			histogram := newMetric.SetEmptyHistogram()
 			histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
 			for _, fm := range family.Metric {
 				dp := histogram.DataPoints().AppendEmpty()
 				dp.SetTimestamp(now)
-				dp.SetStartTimestamp(s.startTime)
 
 				// Translate histogram buckets from Prometheus to the OTLP schema.
 				// The bucket counts in Prometheus are cumulative, while in OTLP they are not.
@@ -213,18 +459,28 @@ func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, rm p
 				dp.SetSum(fm.GetHistogram().GetSampleSum())
 				dp.SetCount(fm.GetHistogram().GetSampleCount())
 
+				for _, b := range buckets {
+					if e := b.GetExemplar(); e != nil {
+						convertExemplar(e, dp.Exemplars().AppendEmpty(), now)
+					}
+				}
+				for _, e := range fm.GetHistogram().GetExemplars() {
+					convertExemplar(e, dp.Exemplars().AppendEmpty(), now)
+				}
+
 				for _, l := range fm.GetLabel() {
 					if l.GetValue() != "" {
 						dp.Attributes().PutStr(l.GetName(), l.GetValue())
 					}
 				}
+				state := series.observe(newMetric, fm.GetLabel(), dp.Attributes(), now)
+				dp.SetStartTimestamp(state.startTimestamp(fm.GetHistogram().GetCreatedTimestamp(), float64(dp.Count()), dp.Sum(), now))
 			}
 		case dto.MetricType_SUMMARY:
 			sum := newMetric.SetEmptySummary()
 			for _, fm := range family.Metric {
 				dp := sum.DataPoints().AppendEmpty()
 				dp.SetTimestamp(now)
-				dp.SetStartTimestamp(s.startTime)
 				for _, q := range fm.GetSummary().GetQuantile() {
 					newQ := dp.QuantileValues().AppendEmpty()
 					newQ.SetValue(q.GetValue())
@@ -237,6 +493,8 @@ func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, rm p
 						dp.Attributes().PutStr(l.GetName(), l.GetValue())
 					}
 				}
+				state := series.observe(newMetric, fm.GetLabel(), dp.Attributes(), now)
+				dp.SetStartTimestamp(state.startTimestamp(fm.GetSummary().GetCreatedTimestamp(), float64(dp.Count()), dp.Sum(), now))
 			}
 		case dto.MetricType_UNTYPED:
 			gauge := newMetric.SetEmptyGauge()
@@ -250,9 +508,51 @@ func (s *scraper) convertMetricFamilies(metricFamilies []*dto.MetricFamily, rm p
 						dp.Attributes().PutStr(l.GetName(), l.GetValue())
 					}
 				}
+				series.observe(newMetric, fm.GetLabel(), dp.Attributes(), now)
 			}
 		default:
 			s.settings.Logger.Warn("Unknown metric family", zap.Any("family", family.Type))
 		}
 	}
+	series.markStale(sm, now)
+}
+
+// convertExemplar converts a Prometheus exemplar, mapping the trace_id and span_id labels
+// to the exemplar trace and span IDs and the other labels to filtered attributes.
+func convertExemplar(e *dto.Exemplar, exemplar pmetric.Exemplar, now pcommon.Timestamp) {
+	exemplar.SetDoubleValue(e.GetValue())
+	exemplar.SetTimestamp(now)
+	if e.GetTimestamp() != nil {
+		exemplar.SetTimestamp(pcommon.NewTimestampFromTime(e.GetTimestamp().AsTime()))
+	}
+	for _, l := range e.GetLabel() {
+		switch l.GetName() {
+		case traceIDKey:
+			var traceID pcommon.TraceID
+			if decodeID(l.GetValue(), traceID[:]) {
+				exemplar.SetTraceID(traceID)
+				continue
+			}
+		case spanIDKey:
+			var spanID pcommon.SpanID
+			if decodeID(l.GetValue(), spanID[:]) {
+				exemplar.SetSpanID(spanID)
+				continue
+			}
+		}
+		exemplar.FilteredAttributes().PutStr(l.GetName(), l.GetValue())
+	}
+}
+
+// decodeID decodes a hex encoded trace or span ID, left-padded with zeros to the ID length.
+func decodeID(value string, id []byte) bool {
+	if value == "" || len(value) > 2*len(id) {
+		return false
+	}
+	decoded, err := hex.DecodeString(strings.Repeat("0", 2*len(id)-len(value)) + value)
+	if err != nil {
+		return false
+	}
+	copy(id, decoded)
+	return true
 }
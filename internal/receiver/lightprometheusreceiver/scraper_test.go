@@ -17,20 +17,28 @@ package lightprometheusreceiver
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/pmetrictest"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/collector/receiver/scrapererror"
 	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestScraper(t *testing.T) {
@@ -102,7 +110,8 @@ func TestScraper(t *testing.T) {
 
 			require.NoError(t, pmetrictest.CompareMetrics(expectedMetrics, actualMetrics,
 				pmetrictest.IgnoreMetricDataPointsOrder(), pmetrictest.IgnoreStartTimestamp(),
-				pmetrictest.IgnoreTimestamp(), pmetrictest.IgnoreMetricsOrder()))
+				pmetrictest.IgnoreTimestamp(), pmetrictest.IgnoreMetricsOrder(),
+				pmetrictest.IgnoreMetricValues(scrapeDurationMetricName)))
 		})
 	}
 }
@@ -173,3 +182,240 @@ istio_request_bytes_bucket{response_code="200",reporter="foo-bar-value",source_w
 		rw.WriteHeader(404)
 	}))
 }
+
+func TestScraperOpenMetrics(t *testing.T) {
+	var accept string
+	promMock := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept")
+		rw.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		rw.WriteHeader(200)
+		_, err := rw.Write([]byte(`# HELP http_requests Total HTTP requests.
+# TYPE http_requests counter
+http_requests_total{code="200"} 1027 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736",span_id="00f067aa0ba902b7"} 1 1680652414.123
+http_requests_created{code="200"} 1680652000.5
+# HELP request_duration_seconds Request duration.
+# TYPE request_duration_seconds histogram
+# UNIT request_duration_seconds seconds
+request_duration_seconds_bucket{le="0.1"} 8 # {trace_id="0af7651916cd43dd8448eb211c80319c",span_id="b7ad6b7169203331",route="/users"} 0.05
+request_duration_seconds_bucket{le="1"} 10
+request_duration_seconds_bucket{le="+Inf"} 11
+request_duration_seconds_count 11
+request_duration_seconds_sum 4.5
+request_duration_seconds_created 1680652100
+# TYPE build info
+build_info{version="1.2.3"} 1
+# EOF
+`))
+		require.NoError(t, err)
+	}))
+	defer promMock.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = promMock.URL + "/metrics"
+	scraper := newScraper(receivertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+
+	md, err := scraper.scrape(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, accept, "application/openmetrics-text")
+
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 3+4, metrics.Len())
+
+	counter := metrics.At(0)
+	require.Equal(t, "http_requests_total", counter.Name())
+	require.Equal(t, "Total HTTP requests.", counter.Description())
+	counterDp := counter.Sum().DataPoints().At(0)
+	require.Equal(t, 1027.0, counterDp.DoubleValue())
+	require.Equal(t, time.Unix(1680652000, 5e8).UTC(), counterDp.StartTimestamp().AsTime())
+	require.Equal(t, 1, counterDp.Exemplars().Len())
+	exemplar := counterDp.Exemplars().At(0)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exemplar.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", exemplar.SpanID().String())
+	require.Equal(t, 1.0, exemplar.DoubleValue())
+	require.Equal(t, time.UnixMilli(1680652414123).UTC(), exemplar.Timestamp().AsTime())
+
+	histogram := metrics.At(1)
+	require.Equal(t, "request_duration_seconds", histogram.Name())
+	require.Equal(t, "seconds", histogram.Unit())
+	histogramDp := histogram.Histogram().DataPoints().At(0)
+	require.Equal(t, []float64{0.1, 1}, histogramDp.ExplicitBounds().AsRaw())
+	require.Equal(t, []uint64{8, 2, 1}, histogramDp.BucketCounts().AsRaw())
+	require.Equal(t, uint64(11), histogramDp.Count())
+	require.Equal(t, 4.5, histogramDp.Sum())
+	require.Equal(t, time.Unix(1680652100, 0).UTC(), histogramDp.StartTimestamp().AsTime())
+	require.Equal(t, 1, histogramDp.Exemplars().Len())
+	exemplar = histogramDp.Exemplars().At(0)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", exemplar.TraceID().String())
+	route, ok := exemplar.FilteredAttributes().Get("route")
+	require.True(t, ok)
+	require.Equal(t, "/users", route.Str())
+
+	info := metrics.At(2)
+	require.Equal(t, "build_info", info.Name())
+	require.Equal(t, 1.0, info.Gauge().DataPoints().At(0).DoubleValue())
+}
+
+func TestScraperNativeHistogram(t *testing.T) {
+	families := []*dto.MetricFamily{
+		{
+			Name: strPtr("rpc_duration_seconds"),
+			Help: strPtr("RPC duration."),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Label: []*dto.LabelPair{{Name: strPtr("method"), Value: strPtr("get")}},
+				Histogram: &dto.Histogram{
+					SampleCount:      uint64Ptr(12),
+					SampleSum:        proto.Float64(7.5),
+					Schema:           proto.Int32(3),
+					ZeroThreshold:    proto.Float64(1e-128),
+					ZeroCount:        proto.Uint64(2),
+					CreatedTimestamp: timestamppb.New(time.Unix(1680652100, 0)),
+					PositiveSpan: []*dto.BucketSpan{
+						{Offset: proto.Int32(-2), Length: proto.Uint32(2)},
+						{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+					},
+					PositiveDelta: []int64{3, -1, 2},
+					NegativeSpan:  []*dto.BucketSpan{{Offset: proto.Int32(1), Length: proto.Uint32(1)}},
+					NegativeDelta: []int64{3},
+				},
+			}},
+		},
+		{
+			Name: strPtr("request_duration_seconds"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount: uint64Ptr(3),
+					SampleSum:   proto.Float64(1.5),
+					Bucket: []*dto.Bucket{
+						{UpperBound: proto.Float64(0.5), CumulativeCount: uint64Ptr(2)},
+						{UpperBound: proto.Float64(math.Inf(1)), CumulativeCount: uint64Ptr(3)},
+					},
+				},
+			}},
+		},
+	}
+
+	var accept string
+	promMock := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept")
+		format := expfmt.NewFormat(expfmt.TypeProtoDelim)
+		rw.Header().Set("Content-Type", string(format))
+		rw.WriteHeader(200)
+		encoder := expfmt.NewEncoder(rw, format)
+		for _, mf := range families {
+			require.NoError(t, encoder.Encode(mf))
+		}
+	}))
+	defer promMock.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = promMock.URL + "/metrics"
+	scraper := newScraper(receivertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+
+	md, err := scraper.scrape(context.Background())
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(accept, "application/vnd.google.protobuf"))
+
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2+4, metrics.Len())
+
+	native := metrics.At(0)
+	require.Equal(t, "rpc_duration_seconds", native.Name())
+	require.Equal(t, pmetric.MetricTypeExponentialHistogram, native.Type())
+	require.Equal(t, pmetric.AggregationTemporalityCumulative, native.ExponentialHistogram().AggregationTemporality())
+	dp := native.ExponentialHistogram().DataPoints().At(0)
+	require.Equal(t, int32(3), dp.Scale())
+	require.Equal(t, uint64(12), dp.Count())
+	require.Equal(t, 7.5, dp.Sum())
+	require.Equal(t, uint64(2), dp.ZeroCount())
+	require.Equal(t, 1e-128, dp.ZeroThreshold())
+	require.Equal(t, time.Unix(1680652100, 0).UTC(), dp.StartTimestamp().AsTime())
+	require.Equal(t, int32(-3), dp.Positive().Offset())
+	require.Equal(t, []uint64{3, 2, 0, 4}, dp.Positive().BucketCounts().AsRaw())
+	require.Equal(t, int32(0), dp.Negative().Offset())
+	require.Equal(t, []uint64{3}, dp.Negative().BucketCounts().AsRaw())
+	method, ok := dp.Attributes().Get("method")
+	require.True(t, ok)
+	require.Equal(t, "get", method.Str())
+
+	classic := metrics.At(1)
+	require.Equal(t, "request_duration_seconds", classic.Name())
+	require.Equal(t, pmetric.MetricTypeHistogram, classic.Type())
+	classicDp := classic.Histogram().DataPoints().At(0)
+	require.Equal(t, []float64{0.5}, classicDp.ExplicitBounds().AsRaw())
+	require.Equal(t, []uint64{2, 1}, classicDp.BucketCounts().AsRaw())
+}
+
+func TestScraperTargets(t *testing.T) {
+	newTarget := func(value string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(200)
+			_, err := rw.Write([]byte("# TYPE value gauge\nvalue " + value + "\n"))
+			require.NoError(t, err)
+		}))
+	}
+	target1 := newTarget("1")
+	defer target1.Close()
+	target2 := newTarget("2")
+	defer target2.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = target1.URL + "/metrics"
+	cfg.Targets = []TargetConfig{
+		{Endpoint: target2.URL + "/metrics", Labels: map[string]string{"job": "app", "env": "test"}},
+		{Endpoint: failing.URL + "/metrics"},
+	}
+	cfg.MaxConcurrentScrapes = 1
+	require.NoError(t, component.ValidateConfig(cfg))
+
+	settings := receivertest.NewNopCreateSettings()
+	settings.ID = component.NewIDWithName(typeStr, "receiver")
+	scraper := newScraper(settings, cfg)
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+	defer func() {
+		require.NoError(t, scraper.shutdown(context.Background()))
+	}()
+
+	md, err := scraper.scrape(context.Background())
+	require.True(t, scrapererror.IsPartialScrapeError(err))
+	require.ErrorContains(t, err, "returned status 503")
+	require.Equal(t, 3, md.ResourceMetrics().Len())
+
+	res1 := md.ResourceMetrics().At(0)
+	u1, err := url.Parse(target1.URL)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		conventions.AttributeServiceName:       "receiver",
+		conventions.AttributeServiceInstanceID: u1.Host,
+	}, res1.Resource().Attributes().AsRaw())
+	require.Equal(t, 1.0, res1.ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).DoubleValue())
+
+	res2 := md.ResourceMetrics().At(1)
+	u2, err := url.Parse(target2.URL)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		conventions.AttributeServiceName:       "app",
+		conventions.AttributeServiceInstanceID: u2.Host,
+		"job":                                  "app",
+		"env":                                  "test",
+	}, res2.Resource().Attributes().AsRaw())
+	require.Equal(t, 2.0, res2.ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).DoubleValue())
+
+	res3 := md.ResourceMetrics().At(2)
+	u3, err := url.Parse(failing.URL)
+	require.NoError(t, err)
+	instanceID, ok := res3.Resource().Attributes().Get(conventions.AttributeServiceInstanceID)
+	require.True(t, ok)
+	require.Equal(t, u3.Host, instanceID.Str())
+	require.Equal(t, 4, res3.ScopeMetrics().At(0).Metrics().Len())
+	up := res3.ScopeMetrics().At(0).Metrics().At(0)
+	require.Equal(t, upMetricName, up.Name())
+	require.Equal(t, 0.0, up.Gauge().DataPoints().At(0).DoubleValue())
+}
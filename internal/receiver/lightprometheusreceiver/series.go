@@ -0,0 +1,213 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// series is the state kept between scrapes for a series, identified by its metric name and labels.
+type series struct {
+	attributes  pcommon.Map
+	name        string
+	description string
+	unit        string
+	startTime   pcommon.Timestamp
+	count       float64
+	sum         float64
+	scrape      uint64
+	metricType  pmetric.MetricType
+}
+
+// seriesTracker keeps the state of the scraped series to adjust the start timestamps of cumulative
+// series when they are reset by the target and to emit staleness markers when they disappear.
+type seriesTracker struct {
+	series    map[string]*series
+	startTime pcommon.Timestamp
+	scrape    uint64
+}
+
+func newSeriesTracker() *seriesTracker {
+	return &seriesTracker{series: map[string]*series{}}
+}
+
+// begin starts a new scrape. Series first seen in the first scrape are considered started with the receiver,
+// series first seen later are considered started at the time of the scrape.
+func (t *seriesTracker) begin(startTime pcommon.Timestamp) {
+	if t.scrape == 0 {
+		t.startTime = startTime
+	}
+	t.scrape++
+}
+
+// observe records that the series of the metric with the labels and attributes was seen in the current scrape.
+func (t *seriesTracker) observe(metric pmetric.Metric, labels []*dto.LabelPair, attributes pcommon.Map, now pcommon.Timestamp) *series {
+	key := seriesKey(metric.Name(), labels)
+	s, ok := t.series[key]
+	if !ok || s.metricType != metric.Type() {
+		s = &series{
+			attributes:  pcommon.NewMap(),
+			name:        metric.Name(),
+			description: metric.Description(),
+			unit:        metric.Unit(),
+			startTime:   now,
+			metricType:  metric.Type(),
+		}
+		if t.scrape == 1 {
+			s.startTime = t.startTime
+		}
+		attributes.CopyTo(s.attributes)
+		t.series[key] = s
+	}
+	s.scrape = t.scrape
+	return s
+}
+
+// startTimestamp returns the start timestamp of a cumulative series: the created timestamp exposed by the target
+// if any, otherwise the time the series was first seen, moved to the time of the scrape when the count or the sum
+// decreases as the target was reset in the meantime.
+func (s *series) startTimestamp(created *timestamppb.Timestamp, count, sum float64, now pcommon.Timestamp) pcommon.Timestamp {
+	reset := count < s.count || sum < s.sum
+	s.count = count
+	s.sum = sum
+	if created != nil && !created.AsTime().IsZero() {
+		s.startTime = pcommon.NewTimestampFromTime(created.AsTime())
+	} else if reset {
+		s.startTime = now
+	}
+	return s.startTime
+}
+
+// markStale appends a data point flagged with no recorded value for each series which was not seen in the
+// current scrape, and forgets them. The data points are added to the metric of the scrape with the same name
+// if any, or to a new metric otherwise.
+func (t *seriesTracker) markStale(sm pmetric.ScopeMetrics, now pcommon.Timestamp) {
+	t.markStaleIf(sm, now, func(s *series) bool { return s.scrape != t.scrape })
+}
+
+// markAllStale appends a data point flagged with no recorded value for each series, and forgets them, as
+// Prometheus does when a target fails to be scraped or is no longer a target.
+func (t *seriesTracker) markAllStale(sm pmetric.ScopeMetrics, now pcommon.Timestamp) {
+	t.markStaleIf(sm, now, func(*series) bool { return true })
+}
+
+func (t *seriesTracker) markStaleIf(sm pmetric.ScopeMetrics, now pcommon.Timestamp, stale func(*series) bool) {
+	var metrics map[string]pmetric.Metric
+	for key, s := range t.series {
+		if !stale(s) {
+			continue
+		}
+		delete(t.series, key)
+
+		if metrics == nil {
+			metrics = map[string]pmetric.Metric{}
+			for i := 0; i < sm.Metrics().Len(); i++ {
+				metrics[sm.Metrics().At(i).Name()] = sm.Metrics().At(i)
+			}
+		}
+		metric, ok := metrics[s.name]
+		if !ok || metric.Type() != s.metricType {
+			metric = s.newMetric(sm.Metrics().AppendEmpty())
+			metrics[s.name] = metric
+		}
+		s.appendStaleDataPoint(metric, now)
+	}
+}
+
+func (s *series) newMetric(metric pmetric.Metric) pmetric.Metric {
+	metric.SetName(s.name)
+	metric.SetDescription(s.description)
+	metric.SetUnit(s.unit)
+	switch s.metricType {
+	case pmetric.MetricTypeGauge:
+		metric.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		sum := metric.SetEmptySum()
+		sum.SetIsMonotonic(true)
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	case pmetric.MetricTypeHistogram:
+		metric.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	case pmetric.MetricTypeExponentialHistogram:
+		metric.SetEmptyExponentialHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	case pmetric.MetricTypeSummary:
+		metric.SetEmptySummary()
+	}
+	return metric
+}
+
+func (s *series) appendStaleDataPoint(metric pmetric.Metric, now pcommon.Timestamp) {
+	flags := pmetric.DefaultDataPointFlags.WithNoRecordedValue(true)
+	switch s.metricType {
+	case pmetric.MetricTypeGauge:
+		dp := metric.Gauge().DataPoints().AppendEmpty()
+		dp.SetFlags(flags)
+		dp.SetTimestamp(now)
+		dp.SetStartTimestamp(s.startTime)
+		s.attributes.CopyTo(dp.Attributes())
+	case pmetric.MetricTypeSum:
+		dp := metric.Sum().DataPoints().AppendEmpty()
+		dp.SetFlags(flags)
+		dp.SetTimestamp(now)
+		dp.SetStartTimestamp(s.startTime)
+		s.attributes.CopyTo(dp.Attributes())
+	case pmetric.MetricTypeHistogram:
+		dp := metric.Histogram().DataPoints().AppendEmpty()
+		dp.SetFlags(flags)
+		dp.SetTimestamp(now)
+		dp.SetStartTimestamp(s.startTime)
+		s.attributes.CopyTo(dp.Attributes())
+	case pmetric.MetricTypeExponentialHistogram:
+		dp := metric.ExponentialHistogram().DataPoints().AppendEmpty()
+		dp.SetFlags(flags)
+		dp.SetTimestamp(now)
+		dp.SetStartTimestamp(s.startTime)
+		s.attributes.CopyTo(dp.Attributes())
+	case pmetric.MetricTypeSummary:
+		dp := metric.Summary().DataPoints().AppendEmpty()
+		dp.SetFlags(flags)
+		dp.SetTimestamp(now)
+		dp.SetStartTimestamp(s.startTime)
+		s.attributes.CopyTo(dp.Attributes())
+	}
+}
+
+// seriesKey identifies a series by its metric name and its non-empty labels, regardless of their order.
+func seriesKey(name string, labels []*dto.LabelPair) string {
+	sorted := make([]*dto.LabelPair, 0, len(labels))
+	for _, l := range labels {
+		if l.GetValue() != "" {
+			sorted = append(sorted, l)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range sorted {
+		b.WriteByte(0xff)
+		b.WriteString(l.GetName())
+		b.WriteByte(0xff)
+		b.WriteString(l.GetValue())
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lightprometheusreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestScraperSeriesState(t *testing.T) {
+	responses := []string{
+		`# TYPE requests_total counter
+requests_total{code="200"} 10
+requests_total{code="500"} 2
+# TYPE temperature gauge
+temperature 21.5
+`,
+		`# TYPE requests_total counter
+requests_total{code="200"} 15
+requests_total{code="500"} 3
+# TYPE temperature gauge
+temperature 22
+`,
+		`# TYPE requests_total counter
+requests_total{code="200"} 4
+`,
+		`# TYPE requests_total counter
+requests_total{code="200"} 6
+requests_total{code="404"} 1
+`,
+	}
+	var scrape int
+	promMock := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(200)
+		_, err := rw.Write([]byte(responses[scrape]))
+		require.NoError(t, err)
+		scrape++
+	}))
+	defer promMock.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = promMock.URL + "/metrics"
+	scraper := newScraper(receivertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+
+	scrapeDataPoints := func() map[string]pmetric.NumberDataPoint {
+		md, err := scraper.scrape(context.Background())
+		require.NoError(t, err)
+		dps := map[string]pmetric.NumberDataPoint{}
+		metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := 0; i < metrics.Len(); i++ {
+			metric := metrics.At(i)
+			if metric.Name() == upMetricName || strings.HasPrefix(metric.Name(), "scrape_") {
+				continue
+			}
+			var points pmetric.NumberDataPointSlice
+			switch metric.Type() {
+			case pmetric.MetricTypeSum:
+				points = metric.Sum().DataPoints()
+			case pmetric.MetricTypeGauge:
+				points = metric.Gauge().DataPoints()
+			}
+			for j := 0; j < points.Len(); j++ {
+				key := metric.Name()
+				if code, ok := points.At(j).Attributes().Get("code"); ok {
+					key += "/" + code.Str()
+				}
+				dps[key] = points.At(j)
+			}
+		}
+		return dps
+	}
+
+	first := scrapeDataPoints()
+	require.Len(t, first, 3)
+	for _, dp := range first {
+		require.Equal(t, scraper.startTime, dp.StartTimestamp())
+		require.False(t, dp.Flags().NoRecordedValue())
+	}
+
+	second := scrapeDataPoints()
+	require.Len(t, second, 3)
+	require.Equal(t, scraper.startTime, second["requests_total/200"].StartTimestamp())
+	require.Equal(t, 15.0, second["requests_total/200"].DoubleValue())
+
+	// the target was restarted: the counter is reset and the series which disappeared are stale.
+	third := scrapeDataPoints()
+	require.Len(t, third, 3)
+	reset := third["requests_total/200"]
+	require.Equal(t, 4.0, reset.DoubleValue())
+	require.Equal(t, reset.Timestamp(), reset.StartTimestamp())
+	require.False(t, reset.Flags().NoRecordedValue())
+	require.True(t, third["requests_total/500"].Flags().NoRecordedValue())
+	require.Equal(t, scraper.startTime, third["requests_total/500"].StartTimestamp())
+	require.True(t, third["temperature"].Flags().NoRecordedValue())
+
+	// new series start at the time they are first seen, stale series are only reported once.
+	fourth := scrapeDataPoints()
+	require.Len(t, fourth, 2)
+	require.Equal(t, reset.StartTimestamp(), fourth["requests_total/200"].StartTimestamp())
+	require.Equal(t, fourth["requests_total/404"].Timestamp(), fourth["requests_total/404"].StartTimestamp())
+}
+
+func TestScraperStaleTargets(t *testing.T) {
+	var failing atomic.Bool
+	promMock := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(200)
+		_, err := rw.Write([]byte("# TYPE requests_total counter\nrequests_total{code=\"200\"} 10\n# TYPE temperature gauge\ntemperature 21.5\n"))
+		require.NoError(t, err)
+	}))
+	defer promMock.Close()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Targets = []TargetConfig{{Endpoint: promMock.URL + "/metrics"}}
+	scraper := newScraper(receivertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, scraper.start(context.Background(), componenttest.NewNopHost()))
+
+	// staleDataPoints returns the names of the metrics with stale data points
+	staleDataPoints := func(md pmetric.Metrics) []string {
+		var names []string
+		for i := 0; i < md.ResourceMetrics().Len(); i++ {
+			metrics := md.ResourceMetrics().At(i).ScopeMetrics().At(0).Metrics()
+			for j := 0; j < metrics.Len(); j++ {
+				metric := metrics.At(j)
+				var points pmetric.NumberDataPointSlice
+				switch metric.Type() {
+				case pmetric.MetricTypeSum:
+					points = metric.Sum().DataPoints()
+				case pmetric.MetricTypeGauge:
+					points = metric.Gauge().DataPoints()
+				}
+				for k := 0; k < points.Len(); k++ {
+					if points.At(k).Flags().NoRecordedValue() {
+						names = append(names, metric.Name())
+					}
+				}
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	md, err := scraper.scrape(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, staleDataPoints(md))
+
+	// all the series of a target failing to be scraped are stale, once
+	failing.Store(true)
+	md, err = scraper.scrape(context.Background())
+	require.Error(t, err)
+	require.Equal(t, []string{"requests_total", "temperature"}, staleDataPoints(md))
+	md, err = scraper.scrape(context.Background())
+	require.Error(t, err)
+	require.Empty(t, staleDataPoints(md))
+
+	// all the series of a target which is gone are stale, once
+	failing.Store(false)
+	_, err = scraper.scrape(context.Background())
+	require.NoError(t, err)
+	cfg.Targets = nil
+	md, err = scraper.scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, md.ResourceMetrics().Len())
+	require.Equal(t, []string{"requests_total", "temperature"}, staleDataPoints(md))
+	md, err = scraper.scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, md.ResourceMetrics().Len())
+}
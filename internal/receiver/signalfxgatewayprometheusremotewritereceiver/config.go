@@ -27,7 +27,16 @@ var _ component.Config = (*Config)(nil)
 type Config struct {
 	confighttp.ServerConfig `mapstructure:",squash"`
 	ListenPath              string `mapstructure:"path"`
-	BufferSize              int    `mapstructure:"buffer_size"`
+	// TenantHeader is the request header holding the tenant of the sender, such as X-Scope-OrgID.
+	TenantHeader string `mapstructure:"tenant_header"`
+	// TenantAttribute is the resource attribute set to the tenant.
+	TenantAttribute string `mapstructure:"tenant_attribute"`
+	BufferSize      int    `mapstructure:"buffer_size"`
+	// MaxDecompressedSize is the maximum size in bytes of a decompressed write request, the compressed size being
+	// limited by max_request_body_size.
+	MaxDecompressedSize int64 `mapstructure:"max_decompressed_size"`
+	// MaxSeriesPerRequest is the maximum number of series of a write request.
+	MaxSeriesPerRequest int `mapstructure:"max_series_per_request"`
 }
 
 func (c *Config) Validate() error {
@@ -38,6 +47,18 @@ func (c *Config) Validate() error {
 	if c.BufferSize < 0 {
 		errs = append(errs, errors.New("buffer size must be non-negative"))
 	}
+	if c.MaxRequestBodySize < 0 {
+		errs = append(errs, errors.New("max request body size must be non-negative"))
+	}
+	if c.MaxDecompressedSize < 0 {
+		errs = append(errs, errors.New("max decompressed size must be non-negative"))
+	}
+	if c.MaxSeriesPerRequest < 0 {
+		errs = append(errs, errors.New("max series per request must be non-negative"))
+	}
+	if c.TenantHeader != "" && c.TenantAttribute == "" {
+		errs = append(errs, errors.New("tenant attribute must not be empty when a tenant header is set"))
+	}
 	if errs != nil {
 		return multierr.Combine(errs...)
 	}
@@ -33,6 +33,18 @@ func TestValidateConfigAndDefaults(t *testing.T) {
 	assert.Equal(t, "localhost:19291", cfg.ServerConfig.Endpoint)
 	assert.Equal(t, "/metrics", cfg.ListenPath)
 	assert.Equal(t, 100, cfg.BufferSize)
+	assert.Equal(t, "tenant", cfg.TenantAttribute)
+	assert.Equal(t, int64(10<<20), cfg.MaxRequestBodySize)
+	assert.Equal(t, int64(64<<20), cfg.MaxDecompressedSize)
+	assert.Equal(t, 50000, cfg.MaxSeriesPerRequest)
+
+	cfg.MaxDecompressedSize = -1
+	assert.ErrorContains(t, cfg.Validate(), "max decompressed size")
+	cfg.MaxDecompressedSize = 0
+
+	cfg.TenantHeader = "X-Scope-OrgID"
+	cfg.TenantAttribute = ""
+	assert.ErrorContains(t, cfg.Validate(), "tenant attribute")
 }
 
 func TestLoadConfigFromFactory(t *testing.T) {
@@ -46,9 +46,14 @@ func createMetricsReceiver(
 func createDefaultConfig() component.Config {
 	return &Config{
 		ServerConfig: confighttp.ServerConfig{
-			Endpoint: "localhost:19291", // While not IANA registered, convention is 19291 as a common PRW port
+			Endpoint:           "localhost:19291", // While not IANA registered, convention is 19291 as a common PRW port
+			MaxRequestBodySize: 10 << 20,
 		},
-		ListenPath: "/metrics",
-		BufferSize: 100,
+		ListenPath:      "/metrics",
+		TenantAttribute: "tenant",
+		BufferSize:      100,
+		// senders split their queues into requests of up to 2000 samples by default, far below these limits
+		MaxDecompressedSize: 64 << 20,
+		MaxSeriesPerRequest: 50000,
 	}
 }
@@ -0,0 +1,414 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalfxgatewayprometheusremotewritereceiver
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	leLabel       = "le"
+	quantileLabel = "quantile"
+
+	bucketSuffix = "_bucket"
+	sumSuffix    = "_sum"
+	countSuffix  = "_count"
+	gsumSuffix   = "_gsum"
+	gcountSuffix = "_gcount"
+)
+
+// classicFamily gathers the series of a classic histogram or summary, which are written as separate
+// _bucket (or quantile), _sum and _count series, back into a single metric.
+type classicFamily struct {
+	points    map[string]*classicPoint
+	name      string
+	keys      []string
+	series    []metricData
+	metadata  prompb.MetricMetadata
+	nans      int64
+	isSummary bool
+}
+
+// classicPoint is a single histogram or summary data point, identified by its labels and timestamp.
+type classicPoint struct {
+	labels    []prompb.Label
+	bounds    []bucketBound
+	timestamp int64
+	created   int64
+	sum       float64
+	count     float64
+	hasSum    bool
+	hasCount  bool
+}
+
+// bucketBound is the cumulative count of a histogram bucket, or the value of a summary quantile.
+type bucketBound struct {
+	bound float64
+	value float64
+}
+
+// collectClassicFamilies removes the series making up classic histograms and summaries from the partitions,
+// returning them grouped by family. Series which can't be reassembled are left in the partitions.
+//
+// Senders shard the series of a family across write requests, so a request may only hold some of them.
+// Families with an incomplete data point are left in the partitions as well, to be reported series by
+// series, instead of being reassembled into a histogram or summary with made up buckets or counts.
+func (prwParser *prometheusRemoteOtelParser) collectClassicFamilies(partitions map[prompb.MetricMetadata_MetricType][]metricData) []*classicFamily {
+	families := map[string]*classicFamily{}
+	var names []string
+	family := func(name string, isSummary bool, metadata prompb.MetricMetadata) *classicFamily {
+		f, ok := families[name]
+		if !ok {
+			f = &classicFamily{name: name, isSummary: isSummary, metadata: metadata, points: map[string]*classicPoint{}}
+			families[name] = f
+			names = append(names, name)
+		}
+		return f
+	}
+
+	// The buckets and quantiles identify the families, their _sum and _count series are attached afterwards.
+	for _, metricType := range []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_GAUGEHISTOGRAM} {
+		partitions[metricType] = prwParser.filterSeries(partitions[metricType], func(md metricData) bool {
+			bound, ok := parseBound(md, leLabel)
+			if !ok || len(md.Histograms) > 0 || !strings.HasSuffix(md.MetricName, bucketSuffix) {
+				return false
+			}
+			prwParser.addBounds(family(strings.TrimSuffix(md.MetricName, bucketSuffix), false, md.MetricMetadata), md, bound)
+			return true
+		})
+	}
+	partitions[prompb.MetricMetadata_SUMMARY] = prwParser.filterSeries(partitions[prompb.MetricMetadata_SUMMARY], func(md metricData) bool {
+		bound, ok := parseBound(md, quantileLabel)
+		if !ok || md.MetricName == "" {
+			return false
+		}
+		if f, ok := families[md.MetricName]; ok && !f.isSummary {
+			return false
+		}
+		prwParser.addBounds(family(md.MetricName, true, md.MetricMetadata), md, bound)
+		return true
+	})
+	if len(families) == 0 {
+		return nil
+	}
+
+	for metricType, metrics := range partitions {
+		partitions[metricType] = prwParser.filterSeries(metrics, func(md metricData) bool {
+			if len(md.Histograms) > 0 {
+				return false
+			}
+			for _, suffix := range []string{sumSuffix, gsumSuffix, countSuffix, gcountSuffix} {
+				if base, ok := strings.CutSuffix(md.MetricName, suffix); ok && families[base] != nil {
+					prwParser.addSumOrCount(families[base], md, suffix == sumSuffix || suffix == gsumSuffix)
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	result := make([]*classicFamily, 0, len(names))
+	for _, name := range names {
+		f := families[name]
+		if !f.complete() {
+			for _, md := range f.series {
+				partitions[md.MetricMetadata.Type] = append(partitions[md.MetricMetadata.Type], md)
+			}
+			continue
+		}
+		prwParser.totalNans.Add(f.nans)
+		result = append(result, f)
+	}
+	return result
+}
+
+// complete returns whether every data point of the family can be reassembled: histogram points need their
+// _sum and either their +Inf bucket or _count, summary points their _sum and _count.
+func (f *classicFamily) complete() bool {
+	for _, p := range f.points {
+		if len(p.bounds) == 0 || !p.hasSum {
+			return false
+		}
+		if f.isSummary && !p.hasCount {
+			return false
+		}
+		if !f.isSummary && !p.hasCount && !p.hasInfBound() {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *classicPoint) hasInfBound() bool {
+	for _, b := range p.bounds {
+		if math.IsInf(b.bound, 1) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSeries returns the series for which consume returns false.
+func (prwParser *prometheusRemoteOtelParser) filterSeries(metrics []metricData, consume func(md metricData) bool) []metricData {
+	var remaining []metricData
+	for _, md := range metrics {
+		if !consume(md) {
+			remaining = append(remaining, md)
+		}
+	}
+	return remaining
+}
+
+// parseBound returns the value of the le or quantile label of a series, which must be a valid number.
+func parseBound(md metricData, boundLabel string) (float64, bool) {
+	for _, label := range md.Labels {
+		if label.Name != boundLabel {
+			continue
+		}
+		bound, err := strconv.ParseFloat(label.Value, 64)
+		return bound, err == nil && !math.IsNaN(bound)
+	}
+	return 0, false
+}
+
+// addBounds adds the samples of a bucket or quantile series to its family. NaN samples are only counted
+// once the family is known to be complete, they are counted by the per-series path otherwise.
+func (prwParser *prometheusRemoteOtelParser) addBounds(f *classicFamily, md metricData, bound float64) {
+	f.series = append(f.series, md)
+	for _, sample := range md.Samples {
+		if math.IsNaN(sample.Value) {
+			f.nans++
+			continue
+		}
+		p := f.point(md, sample.Timestamp)
+		p.bounds = append(p.bounds, bucketBound{bound: bound, value: sample.Value})
+	}
+}
+
+func (prwParser *prometheusRemoteOtelParser) addSumOrCount(f *classicFamily, md metricData, isSum bool) {
+	f.series = append(f.series, md)
+	for _, sample := range md.Samples {
+		if math.IsNaN(sample.Value) {
+			f.nans++
+			continue
+		}
+		p := f.point(md, sample.Timestamp)
+		if isSum {
+			p.sum, p.hasSum = sample.Value, true
+		} else {
+			p.count, p.hasCount = sample.Value, true
+		}
+	}
+}
+
+// point returns the data point of the family for the labels of a series and a timestamp, ignoring the labels
+// which differ between the series of a family.
+func (f *classicFamily) point(md metricData, timestamp int64) *classicPoint {
+	var pointLabels []prompb.Label
+	for _, label := range md.Labels {
+		if label.Name != "__name__" && label.Name != leLabel && label.Name != quantileLabel {
+			pointLabels = append(pointLabels, label)
+		}
+	}
+	sort.Slice(pointLabels, func(i, j int) bool { return pointLabels[i].Name < pointLabels[j].Name })
+
+	var key strings.Builder
+	for _, label := range pointLabels {
+		key.WriteString(label.Name)
+		key.WriteByte(0xff)
+		key.WriteString(label.Value)
+		key.WriteByte(0xff)
+	}
+	key.WriteString(strconv.FormatInt(timestamp, 10))
+
+	p, ok := f.points[key.String()]
+	if !ok {
+		p = &classicPoint{labels: pointLabels, timestamp: timestamp}
+		f.points[key.String()] = p
+		f.keys = append(f.keys, key.String())
+	}
+	if md.CreatedTimestamp > 0 {
+		p.created = md.CreatedTimestamp
+	}
+	return p
+}
+
+// addClassicFamilies adds the reassembled histograms and summaries to the scope.
+func (prwParser *prometheusRemoteOtelParser) addClassicFamilies(ilm pmetric.ScopeMetrics, families []*classicFamily) {
+	for _, f := range families {
+		nm := prwParser.scaffoldNewMetric(ilm, f.name, f.metadata)
+		if f.isSummary {
+			summary := nm.SetEmptySummary()
+			for _, key := range f.keys {
+				prwParser.setSummaryDataPoint(summary.DataPoints().AppendEmpty(), f.points[key])
+			}
+			continue
+		}
+		histogram := nm.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		for _, key := range f.keys {
+			prwParser.setHistogramDataPoint(histogram.DataPoints().AppendEmpty(), f.points[key])
+		}
+	}
+}
+
+// setHistogramDataPoint converts the cumulative bucket counts of a complete classic histogram point to the
+// bucket counts of an OTLP histogram. The +Inf bucket is derived from the _count series when it wasn't written.
+func (prwParser *prometheusRemoteOtelParser) setHistogramDataPoint(dp pmetric.HistogramDataPoint, p *classicPoint) {
+	dp.SetTimestamp(prometheusToOtelTimestamp(p.timestamp))
+	dp.SetStartTimestamp(startTimestamp(p.created, p.timestamp))
+	prwParser.setAttributes(dp.Attributes(), p.labels)
+	dp.SetSum(p.sum)
+
+	sort.Slice(p.bounds, func(i, j int) bool { return p.bounds[i].bound < p.bounds[j].bound })
+	count := p.count
+	previous := 0.0
+	for _, b := range p.bounds {
+		if math.IsInf(b.bound, 1) {
+			if !p.hasCount {
+				count = b.value
+			}
+			break
+		}
+		dp.ExplicitBounds().Append(b.bound)
+		dp.BucketCounts().Append(toCount(b.value - previous))
+		previous = b.value
+	}
+	dp.BucketCounts().Append(toCount(count - previous))
+	dp.SetCount(toCount(count))
+}
+
+func (prwParser *prometheusRemoteOtelParser) setSummaryDataPoint(dp pmetric.SummaryDataPoint, p *classicPoint) {
+	dp.SetTimestamp(prometheusToOtelTimestamp(p.timestamp))
+	dp.SetStartTimestamp(startTimestamp(p.created, p.timestamp))
+	prwParser.setAttributes(dp.Attributes(), p.labels)
+	dp.SetSum(p.sum)
+	dp.SetCount(toCount(p.count))
+
+	sort.Slice(p.bounds, func(i, j int) bool { return p.bounds[i].bound < p.bounds[j].bound })
+	for _, b := range p.bounds {
+		q := dp.QuantileValues().AppendEmpty()
+		q.SetQuantile(b.bound)
+		q.SetValue(b.value)
+	}
+}
+
+// addHistogramMetrics converts native histograms to exponential histograms. The series without native
+// histograms are the bucket series which couldn't be reassembled, and are still reported as counters.
+func (prwParser *prometheusRemoteOtelParser) addHistogramMetrics(ilm pmetric.ScopeMetrics, metrics []metricData) {
+	var bucketSeries []metricData
+	for _, metricsData := range metrics {
+		if len(metricsData.Histograms) == 0 {
+			bucketSeries = append(bucketSeries, metricsData)
+			continue
+		}
+		if metricsData.MetricName == "" {
+			prwParser.totalBadMetrics.Add(1)
+			continue
+		}
+		nm := prwParser.scaffoldNewMetric(ilm, metricsData.MetricName, metricsData.MetricMetadata)
+		histogram := nm.SetEmptyExponentialHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		for _, h := range metricsData.Histograms {
+			if value.IsStaleNaN(h.Sum) {
+				prwParser.totalNans.Add(1)
+				continue
+			}
+			dp := histogram.DataPoints().AppendEmpty()
+			setExponentialHistogramDataPoint(dp, h, metricsData.CreatedTimestamp)
+			prwParser.setAttributes(dp.Attributes(), metricsData.Labels)
+		}
+	}
+	prwParser.addCounterMetrics(ilm, bucketSeries)
+}
+
+// setExponentialHistogramDataPoint converts a native histogram. Both use base 2 exponential buckets, the
+// Prometheus schema being the OTLP scale, but Prometheus buckets are upper-inclusive and start at index 1.
+func setExponentialHistogramDataPoint(dp pmetric.ExponentialHistogramDataPoint, h prompb.Histogram, created int64) {
+	dp.SetTimestamp(prometheusToOtelTimestamp(h.Timestamp))
+	dp.SetStartTimestamp(startTimestamp(created, h.Timestamp))
+	dp.SetScale(h.Schema)
+	dp.SetZeroThreshold(h.ZeroThreshold)
+	if !math.IsNaN(h.Sum) {
+		dp.SetSum(h.Sum)
+	}
+	if h.IsFloatHistogram() {
+		dp.SetCount(toCount(h.GetCountFloat()))
+		dp.SetZeroCount(toCount(h.GetZeroCountFloat()))
+		setExponentialBuckets(dp.Positive(), h.PositiveSpans, nil, h.PositiveCounts)
+		setExponentialBuckets(dp.Negative(), h.NegativeSpans, nil, h.NegativeCounts)
+		return
+	}
+	dp.SetCount(h.GetCountInt())
+	dp.SetZeroCount(h.GetZeroCountInt())
+	setExponentialBuckets(dp.Positive(), h.PositiveSpans, h.PositiveDeltas, nil)
+	setExponentialBuckets(dp.Negative(), h.NegativeSpans, h.NegativeDeltas, nil)
+}
+
+// setExponentialBuckets expands the spans of a native histogram into contiguous buckets. Integer histograms
+// encode each bucket count as a delta to the previous one, float histograms as absolute counts.
+func setExponentialBuckets(buckets pmetric.ExponentialHistogramDataPointBuckets, spans []prompb.BucketSpan, deltas []int64, counts []float64) {
+	if len(spans) == 0 {
+		return
+	}
+	buckets.SetOffset(spans[0].Offset - 1)
+
+	var current int64
+	position := 0
+	for i, span := range spans {
+		if i > 0 {
+			for gap := int32(0); gap < span.Offset; gap++ {
+				buckets.BucketCounts().Append(0)
+			}
+		}
+		for j := uint32(0); j < span.Length; j++ {
+			if counts != nil {
+				if position < len(counts) {
+					buckets.BucketCounts().Append(toCount(counts[position]))
+				}
+			} else if position < len(deltas) {
+				current += deltas[position]
+				buckets.BucketCounts().Append(uint64(current))
+			}
+			position++
+		}
+	}
+}
+
+// toCount converts a count written as a float to an unsigned integer, negative counts being invalid.
+func toCount(v float64) uint64 {
+	if v <= 0 || math.IsNaN(v) {
+		return 0
+	}
+	return uint64(math.Round(v))
+}
+
+// nativeHistogramType returns the type of a series of native histograms, which are gauge histograms when
+// all of them are hinted as such.
+func nativeHistogramType(histograms []prompb.Histogram) prompb.MetricMetadata_MetricType {
+	for _, h := range histograms {
+		if h.ResetHint != prompb.Histogram_GAUGE {
+			return prompb.MetricMetadata_HISTOGRAM
+		}
+	}
+	return prompb.MetricMetadata_GAUGEHISTOGRAM
+}
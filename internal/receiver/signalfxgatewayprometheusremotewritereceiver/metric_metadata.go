@@ -0,0 +1,89 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalfxgatewayprometheusremotewritereceiver
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// familySuffixes are the suffixes of the series making up a metric family, with the family types they apply to.
+var familySuffixes = []struct {
+	suffix string
+	types  []prompb.MetricMetadata_MetricType
+}{
+	{bucketSuffix, []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_GAUGEHISTOGRAM}},
+	{sumSuffix, []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_SUMMARY}},
+	{countSuffix, []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_SUMMARY}},
+	{gsumSuffix, []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_GAUGEHISTOGRAM}},
+	{gcountSuffix, []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_GAUGEHISTOGRAM}},
+	{"_total", []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_COUNTER}},
+	{"_info", []prompb.MetricMetadata_MetricType{prompb.MetricMetadata_INFO}},
+}
+
+// metadataCache holds the metadata of the metric families written so far. Remote Write 1.0 senders
+// send the metadata periodically, in requests separate from the samples.
+type metadataCache struct {
+	families map[string]prompb.MetricMetadata
+	mu       sync.RWMutex
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{families: map[string]prompb.MetricMetadata{}}
+}
+
+func (c *metadataCache) update(metadata []prompb.MetricMetadata) {
+	if len(metadata) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, md := range metadata {
+		if md.MetricFamilyName != "" {
+			c.families[md.MetricFamilyName] = md
+		}
+	}
+}
+
+// lookup returns the metadata of the family of a series, either named after the series or after the series
+// name without the suffix used by the family type.
+func (c *metadataCache) lookup(metricName string) (prompb.MetricMetadata, bool) {
+	if metricName == "" {
+		return prompb.MetricMetadata{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if md, ok := c.families[metricName]; ok {
+		return md, true
+	}
+	for _, fs := range familySuffixes {
+		base, ok := strings.CutSuffix(metricName, fs.suffix)
+		if !ok {
+			continue
+		}
+		md, ok := c.families[base]
+		if !ok {
+			continue
+		}
+		for _, t := range fs.types {
+			if md.Type == t {
+				return md, true
+			}
+		}
+	}
+	return prompb.MetricMetadata{}, false
+}
@@ -54,6 +54,8 @@ func newMockReporter() *mockReporter {
 		OpsSuccess:          &sync.WaitGroup{},
 		OpsFailed:           &sync.WaitGroup{},
 		OpsStarted:          &sync.WaitGroup{},
+		Errors:              make(chan error, 100),
+		ErrorLocation:       make(chan string, 100),
 		TotalErrorMetrics:   &atomic.Int32{},
 		TotalSuccessMetrics: &atomic.Int32{},
 	}
@@ -190,118 +190,103 @@ func expectedGauge() pmetric.Metrics {
 	return result
 }
 
-func expectedSfxCompatibleHistogram() pmetric.Metrics {
+func expectedHistogram() pmetric.Metrics {
 	result := pmetric.NewMetrics()
 	resourceMetrics := result.ResourceMetrics().AppendEmpty()
 	scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
 	scopeMetrics.Scope().SetName("otelcol/signalfxgatewayprometheusremotewrite")
 	scopeMetrics.Scope().SetVersion("0.1")
-
-	// set bucket sizes
-	pairs := []struct {
-		bucket    string
-		value     int64
-		timestamp int64
-	}{
-		{
-			bucket:    "0.1",
-			value:     500,
-			timestamp: jan20.UnixNano(),
-		},
-		{
-			bucket:    "0.2",
-			value:     1500,
-			timestamp: jan20.UnixNano(),
-		},
-	}
-	for _, values := range pairs {
-		metric := scopeMetrics.Metrics().AppendEmpty()
-		metric.SetName("api_request_duration_seconds_bucket")
-		counter := metric.SetEmptySum()
-		counter.SetIsMonotonic(true)
-		counter.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
-		dp := counter.DataPoints().AppendEmpty()
-		dp.Attributes().PutStr("le", values.bucket)
-		dp.SetTimestamp(pcommon.Timestamp(values.timestamp))
-		dp.SetStartTimestamp(pcommon.Timestamp(values.timestamp))
-		dp.SetIntValue(values.value)
-	}
-
 	metric := scopeMetrics.Metrics().AppendEmpty()
-	metric.SetName("api_request_duration_seconds_count")
-	counter := metric.SetEmptySum()
-	counter.SetIsMonotonic(true)
-	counter.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
-	dp := counter.DataPoints().AppendEmpty()
-	dp.SetTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetStartTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetIntValue(2500)
-
-	metric = scopeMetrics.Metrics().AppendEmpty()
-	metric.SetName("api_request_duration_seconds_sum")
-	gauge := metric.SetEmptyGauge()
-	dp = gauge.DataPoints().AppendEmpty()
-
-	dp.SetTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetStartTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetIntValue(350)
+	metric.SetName("api_request_duration_seconds")
+	histogram := metric.SetEmptyHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := histogram.DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.ExplicitBounds().FromRaw([]float64{0.1, 0.2})
+	// The +Inf bucket isn't written, its count is derived from the _count series.
+	dp.BucketCounts().FromRaw([]uint64{500, 1000, 1000})
+	dp.SetCount(2500)
+	dp.SetSum(350)
 
 	return result
 }
 
-func expectedSfxCompatibleQuantile() pmetric.Metrics {
+func expectedSummary() pmetric.Metrics {
 	result := pmetric.NewMetrics()
 	resourceMetrics := result.ResourceMetrics().AppendEmpty()
 	scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
 	scopeMetrics.Scope().SetName("otelcol/signalfxgatewayprometheusremotewrite")
 	scopeMetrics.Scope().SetVersion("0.1")
+	metric := scopeMetrics.Metrics().AppendEmpty()
+	metric.SetName("request_duration_seconds")
+	summary := metric.SetEmptySummary()
+	dp := summary.DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetCount(1500)
+	dp.SetSum(123.5)
+	quantile := dp.QuantileValues().AppendEmpty()
+	quantile.SetQuantile(0.5)
+	quantile.SetValue(0.25)
+	quantile = dp.QuantileValues().AppendEmpty()
+	quantile.SetQuantile(0.9)
+	quantile.SetValue(0.35)
 
-	// set bucket sizes
-	pairs := []struct {
-		bucket    string
-		value     float64
-		timestamp int64
-	}{
-		{
-			bucket:    "0.5",
-			value:     .25,
-			timestamp: jan20.UnixNano(),
-		},
-		{
-			bucket:    "0.9",
-			value:     .35,
-			timestamp: jan20.UnixNano(),
+	return result
+}
+
+func sampleNativeHistogramWq() *prompb.WriteRequest {
+	return &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "rpc_duration_seconds"},
+					{Name: "service", Value: "api"},
+				},
+				Histograms: []prompb.Histogram{
+					{
+						Count:         &prompb.Histogram_CountInt{CountInt: 12},
+						Sum:           18.4,
+						Schema:        1,
+						ZeroThreshold: 0.001,
+						ZeroCount:     &prompb.Histogram_ZeroCountInt{ZeroCountInt: 2},
+						// Buckets 0 and 1, then 4 after a gap of two buckets.
+						PositiveSpans:  []prompb.BucketSpan{{Offset: 0, Length: 2}, {Offset: 2, Length: 1}},
+						PositiveDeltas: []int64{2, 1, -2},
+						NegativeSpans:  []prompb.BucketSpan{{Offset: -1, Length: 1}},
+						NegativeDeltas: []int64{3},
+						Timestamp:      jan20.UnixMilli(),
+					},
+				},
+			},
 		},
 	}
-	for _, values := range pairs {
-		metric := scopeMetrics.Metrics().AppendEmpty()
-		metric.SetName("request_duration_seconds")
-		gauge := metric.SetEmptyGauge()
-		dp := gauge.DataPoints().AppendEmpty()
-		dp.Attributes().PutStr("quantile", values.bucket)
-		dp.SetTimestamp(pcommon.Timestamp(values.timestamp))
-		dp.SetStartTimestamp(pcommon.Timestamp(values.timestamp))
-		dp.SetDoubleValue(values.value)
-	}
+}
 
+func expectedNativeHistogram() pmetric.Metrics {
+	result := pmetric.NewMetrics()
+	resourceMetrics := result.ResourceMetrics().AppendEmpty()
+	scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
+	scopeMetrics.Scope().SetName("otelcol/signalfxgatewayprometheusremotewrite")
+	scopeMetrics.Scope().SetVersion("0.1")
 	metric := scopeMetrics.Metrics().AppendEmpty()
-	metric.SetName("request_duration_seconds_count")
-	sum := metric.SetEmptySum()
-	sum.SetIsMonotonic(true)
-	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
-	dp := sum.DataPoints().AppendEmpty()
-	dp.SetTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetStartTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetIntValue(1500)
-
-	metric = scopeMetrics.Metrics().AppendEmpty()
-	metric.SetName("request_duration_seconds_sum")
-	gauge := metric.SetEmptyGauge()
-	dp = gauge.DataPoints().AppendEmpty()
-
-	dp.SetTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetStartTimestamp(pcommon.Timestamp(jan20.UnixNano()))
-	dp.SetDoubleValue(123.5)
+	metric.SetName("rpc_duration_seconds")
+	histogram := metric.SetEmptyExponentialHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := histogram.DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetCount(12)
+	dp.SetSum(18.4)
+	dp.SetScale(1)
+	dp.SetZeroThreshold(0.001)
+	dp.SetZeroCount(2)
+	dp.Positive().SetOffset(-1)
+	dp.Positive().BucketCounts().FromRaw([]uint64{2, 3, 0, 0, 1})
+	dp.Negative().SetOffset(-2)
+	dp.Negative().BucketCounts().FromRaw([]uint64{3})
+	dp.Attributes().PutStr("service", "api")
 
 	return result
 }
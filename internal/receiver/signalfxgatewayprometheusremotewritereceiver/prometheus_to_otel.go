@@ -30,18 +30,21 @@ import (
 )
 
 type metricData struct {
-	MetricName     string
-	Labels         []prompb.Label
-	Samples        []prompb.Sample
-	Exemplars      []prompb.Exemplar
-	Histograms     []prompb.Histogram
-	MetricMetadata prompb.MetricMetadata
+	MetricName       string
+	Labels           []prompb.Label
+	Samples          []prompb.Sample
+	Exemplars        []prompb.Exemplar
+	Histograms       []prompb.Histogram
+	MetricMetadata   prompb.MetricMetadata
+	CreatedTimestamp int64
 }
 
 type prometheusRemoteOtelParser struct {
 	totalNans            *atomic.Int64
 	totalInvalidRequests *atomic.Int64
 	totalBadMetrics      *atomic.Int64
+	metadata             *metadataCache
+	targetInfo           *targetInfoCache
 }
 
 func newPrometheusRemoteOtelParser() *prometheusRemoteOtelParser {
@@ -49,55 +52,136 @@ func newPrometheusRemoteOtelParser() *prometheusRemoteOtelParser {
 		totalNans:            &atomic.Int64{},
 		totalInvalidRequests: &atomic.Int64{},
 		totalBadMetrics:      &atomic.Int64{},
+		metadata:             newMetadataCache(),
+		targetInfo:           newTargetInfoCache(),
 	}
 }
 
 func (prwParser *prometheusRemoteOtelParser) fromPrometheusWriteRequestMetrics(request *prompb.WriteRequest) (pmetric.Metrics, error) {
 	metricFamiliesAndData, err := prwParser.partitionWriteRequest(request)
+	return prwParser.toOtelMetrics(metricFamiliesAndData), err
+}
+
+func (prwParser *prometheusRemoteOtelParser) fromPrometheusWriteRequestV2Metrics(request *writeRequestV2) (pmetric.Metrics, error) {
+	metricFamiliesAndData, err := prwParser.partitionWriteRequestV2(request)
+	return prwParser.toOtelMetrics(metricFamiliesAndData), err
+}
+
+func (prwParser *prometheusRemoteOtelParser) toOtelMetrics(metricFamiliesAndData map[prompb.MetricMetadata_MetricType][]metricData) pmetric.Metrics {
+	startTime, endTime := getTimestampBounds(metricFamiliesAndData)
 	otelMetrics := prwParser.transformPrometheusRemoteWriteToOtel(metricFamiliesAndData)
-	startTime, endTime := getWriteRequestTimestampBounds(request)
 	scope := otelMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0)
 	prwParser.addBadRequests(scope, startTime, endTime)
 	prwParser.addNanDataPoints(scope, startTime, endTime)
 	prwParser.addMetricsWithMissingName(scope, startTime, endTime)
-	return otelMetrics, err
+	return otelMetrics
 }
 
+// transformPrometheusRemoteWriteToOtel creates a resource for each target, identified by the job and instance
+// labels of the series. The first resource, without attributes, holds the series of no target and the
+// compatibility metrics.
 func (prwParser *prometheusRemoteOtelParser) transformPrometheusRemoteWriteToOtel(parsedPrwMetrics map[prompb.MetricMetadata_MetricType][]metricData) pmetric.Metrics {
 	metric := pmetric.NewMetrics()
-	rm := metric.ResourceMetrics().AppendEmpty()
-	ilm := rm.ScopeMetrics().AppendEmpty()
-	ilm.Scope().SetName("otelcol/" + metadata.Type.String())
-	ilm.Scope().SetVersion("0.1")
-	for metricType, metrics := range parsedPrwMetrics {
-		prwParser.addMetrics(ilm, metricType, metrics)
+	resources := prwParser.partitionByResource(parsedPrwMetrics)
+	for _, key := range resources.keys {
+		rm := metric.ResourceMetrics().AppendEmpty()
+		prwParser.setResourceAttributes(rm.Resource(), key)
+		ilm := rm.ScopeMetrics().AppendEmpty()
+		ilm.Scope().SetName("otelcol/" + metadata.Type.String())
+		ilm.Scope().SetVersion("0.1")
+		partitions := resources.partitions[key]
+		families := prwParser.collectClassicFamilies(partitions)
+		for metricType, metrics := range partitions {
+			prwParser.addMetrics(ilm, metricType, metrics)
+		}
+		prwParser.addClassicFamilies(ilm, families)
 	}
 	return metric
 }
 
+// partitionWriteRequest partitions the series of a Remote Write 1.0 request, using the metadata of their family
+// when it was sent in this request or a previous one.
 func (prwParser *prometheusRemoteOtelParser) partitionWriteRequest(writeReq *prompb.WriteRequest) (map[prompb.MetricMetadata_MetricType][]metricData, error) {
-	partitions := make(map[prompb.MetricMetadata_MetricType][]metricData)
+	prwParser.metadata.update(writeReq.Metadata)
+	series := make([]metricData, 0, len(writeReq.Timeseries))
 	var translationErrors error
 	for index, ts := range writeReq.Timeseries {
 		metricName, err := internal.ExtractMetricNameLabel(ts.Labels)
 		if err != nil {
 			translationErrors = multierr.Append(translationErrors, err)
 		}
-
-		metricType := internal.DetermineMetricTypeByConvention(metricName, ts.Labels)
-		metricMetadata := prompb.MetricMetadata{
-			Type: metricType,
-		}
-		md := metricData{
+		metricMetadata, _ := prwParser.metadata.lookup(metricName)
+		series = append(series, metricData{
 			Labels:         ts.Labels,
 			Samples:        writeReq.Timeseries[index].Samples,
 			Exemplars:      writeReq.Timeseries[index].Exemplars,
 			Histograms:     writeReq.Timeseries[index].Histograms,
 			MetricName:     metricName,
 			MetricMetadata: metricMetadata,
+		})
+	}
+	return prwParser.partition(series, translationErrors)
+}
+
+// partitionWriteRequestV2 partitions the series of a Remote Write 2.0 request, which carry their own metadata.
+func (prwParser *prometheusRemoteOtelParser) partitionWriteRequestV2(writeReq *writeRequestV2) (map[prompb.MetricMetadata_MetricType][]metricData, error) {
+	series := make([]metricData, 0, len(writeReq.Timeseries))
+	var translationErrors error
+	for _, ts := range writeReq.Timeseries {
+		labels, labelsErr := writeReq.labels(ts.LabelsRefs)
+		help, helpErr := writeReq.symbol(ts.Metadata.HelpRef)
+		unit, unitErr := writeReq.symbol(ts.Metadata.UnitRef)
+		if err := multierr.Combine(labelsErr, helpErr, unitErr); err != nil {
+			translationErrors = multierr.Append(translationErrors, err)
+			prwParser.totalInvalidRequests.Add(1)
+			continue
 		}
-		if len(md.Samples) < 1 {
-			translationErrors = multierr.Append(translationErrors, fmt.Errorf("no samples found for  %s", metricName))
+		metricName, err := internal.ExtractMetricNameLabel(labels)
+		if err != nil {
+			translationErrors = multierr.Append(translationErrors, err)
+		}
+		exemplars := make([]prompb.Exemplar, 0, len(ts.Exemplars))
+		for _, e := range ts.Exemplars {
+			exemplarLabels, err := writeReq.labels(e.LabelsRefs)
+			if err != nil {
+				translationErrors = multierr.Append(translationErrors, err)
+				continue
+			}
+			exemplars = append(exemplars, prompb.Exemplar{Labels: exemplarLabels, Value: e.Value, Timestamp: e.Timestamp})
+		}
+		series = append(series, metricData{
+			Labels:     labels,
+			Samples:    ts.Samples,
+			Exemplars:  exemplars,
+			Histograms: ts.Histograms,
+			MetricName: metricName,
+			MetricMetadata: prompb.MetricMetadata{
+				Type:             ts.Metadata.Type,
+				MetricFamilyName: metricName,
+				Help:             help,
+				Unit:             unit,
+			},
+			CreatedTimestamp: ts.CreatedTimestamp,
+		})
+	}
+	return prwParser.partition(series, translationErrors)
+}
+
+// partition groups the series by metric type. The type sent in the metadata is used when known, and
+// determined from the series name and labels otherwise.
+func (prwParser *prometheusRemoteOtelParser) partition(series []metricData, translationErrors error) (map[prompb.MetricMetadata_MetricType][]metricData, error) {
+	partitions := make(map[prompb.MetricMetadata_MetricType][]metricData)
+	for _, md := range series {
+		metricType := md.MetricMetadata.Type
+		if metricType == prompb.MetricMetadata_UNKNOWN {
+			metricType = internal.DetermineMetricTypeByConvention(md.MetricName, md.Labels)
+		}
+		if len(md.Histograms) > 0 {
+			metricType = nativeHistogramType(md.Histograms)
+		}
+		md.MetricMetadata.Type = metricType
+		if len(md.Samples) < 1 && len(md.Histograms) < 1 {
+			translationErrors = multierr.Append(translationErrors, fmt.Errorf("no samples found for  %s", md.MetricName))
 			prwParser.totalInvalidRequests.Add(1)
 		}
 		partitions[metricType] = append(partitions[metricType], md)
@@ -111,16 +195,20 @@ func (prwParser *prometheusRemoteOtelParser) partitionWriteRequest(writeReq *pro
 func (prwParser *prometheusRemoteOtelParser) addMetrics(ilm pmetric.ScopeMetrics, metricType prompb.MetricMetadata_MetricType, metrics []metricData) {
 
 	switch metricType {
-	case prompb.MetricMetadata_COUNTER, prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_GAUGEHISTOGRAM:
+	case prompb.MetricMetadata_COUNTER:
 		prwParser.addCounterMetrics(ilm, metrics)
+	case prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_GAUGEHISTOGRAM:
+		prwParser.addHistogramMetrics(ilm, metrics)
 	default:
 		prwParser.addGaugeMetrics(ilm, metrics)
 	}
 }
 
-func (prwParser *prometheusRemoteOtelParser) scaffoldNewMetric(ilm pmetric.ScopeMetrics, name string) pmetric.Metric {
+func (prwParser *prometheusRemoteOtelParser) scaffoldNewMetric(ilm pmetric.ScopeMetrics, name string, metadata prompb.MetricMetadata) pmetric.Metric {
 	nm := ilm.Metrics().AppendEmpty()
 	nm.SetName(name)
+	nm.SetDescription(metadata.Help)
+	nm.SetUnit(metadata.Unit)
 	return nm
 }
 
@@ -171,7 +259,7 @@ func (prwParser *prometheusRemoteOtelParser) addGaugeMetrics(ilm pmetric.ScopeMe
 			prwParser.totalBadMetrics.Add(1)
 			continue
 		}
-		nm := prwParser.scaffoldNewMetric(ilm, metricsData.MetricName)
+		nm := prwParser.scaffoldNewMetric(ilm, metricsData.MetricName, metricsData.MetricMetadata)
 		nm.SetName(metricsData.MetricName)
 		gauge := nm.SetEmptyGauge()
 		for _, sample := range metricsData.Samples {
@@ -183,7 +271,7 @@ func (prwParser *prometheusRemoteOtelParser) addGaugeMetrics(ilm pmetric.ScopeMe
 			dp.SetTimestamp(prometheusToOtelTimestamp(sample.GetTimestamp()))
 			dp.SetStartTimestamp(prometheusToOtelTimestamp(sample.GetTimestamp()))
 			prwParser.setFloatOrInt(dp, sample)
-			prwParser.setAttributes(dp, metricsData.Labels)
+			prwParser.setAttributes(dp.Attributes(), metricsData.Labels)
 		}
 	}
 }
@@ -195,7 +283,7 @@ func (prwParser *prometheusRemoteOtelParser) addCounterMetrics(ilm pmetric.Scope
 			prwParser.totalBadMetrics.Add(1)
 			continue
 		}
-		nm := prwParser.scaffoldNewMetric(ilm, metricsData.MetricName)
+		nm := prwParser.scaffoldNewMetric(ilm, metricsData.MetricName, metricsData.MetricMetadata)
 		sumMetric := nm.SetEmptySum()
 		sumMetric.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
 		sumMetric.SetIsMonotonic(true)
@@ -206,40 +294,34 @@ func (prwParser *prometheusRemoteOtelParser) addCounterMetrics(ilm pmetric.Scope
 			}
 			dp := nm.Sum().DataPoints().AppendEmpty()
 			dp.SetTimestamp(prometheusToOtelTimestamp(sample.GetTimestamp()))
-			dp.SetStartTimestamp(prometheusToOtelTimestamp(sample.GetTimestamp()))
+			dp.SetStartTimestamp(startTimestamp(metricsData.CreatedTimestamp, sample.GetTimestamp()))
 			prwParser.setFloatOrInt(dp, sample)
-			prwParser.setAttributes(dp, metricsData.Labels)
+			prwParser.setAttributes(dp.Attributes(), metricsData.Labels)
 		}
 	}
 }
 
-func getSampleTimestampBounds(samples []prompb.Sample) (int64, int64) {
-	if len(samples) < 1 {
-		return -1, -1
-	}
+// getTimestampBounds returns the earliest and latest timestamps of the samples and
+// native histograms of the partitioned series of a write request.
+func getTimestampBounds(metricFamiliesAndData map[prompb.MetricMetadata_MetricType][]metricData) (time.Time, time.Time) {
 	minTimestamp := int64(math.MaxInt64)
 	maxTimestamp := int64(math.MinInt64)
-	for _, sample := range samples {
-		if minTimestamp > sample.Timestamp {
-			minTimestamp = sample.Timestamp
+	observe := func(timestamp int64) {
+		if timestamp < minTimestamp {
+			minTimestamp = timestamp
 		}
-		if maxTimestamp < sample.GetTimestamp() {
-			maxTimestamp = sample.GetTimestamp()
+		if timestamp > maxTimestamp {
+			maxTimestamp = timestamp
 		}
 	}
-	return minTimestamp, maxTimestamp
-}
-
-func getWriteRequestTimestampBounds(request *prompb.WriteRequest) (time.Time, time.Time) {
-	minTimestamp := int64(math.MaxInt64)
-	maxTimestamp := int64(math.MinInt64)
-	for _, ts := range request.Timeseries {
-		sampleMin, sampleMax := getSampleTimestampBounds(ts.Samples)
-		if sampleMin < minTimestamp {
-			minTimestamp = sampleMin
-		}
-		if sampleMax > maxTimestamp {
-			maxTimestamp = sampleMax
+	for _, metrics := range metricFamiliesAndData {
+		for _, md := range metrics {
+			for _, sample := range md.Samples {
+				observe(sample.GetTimestamp())
+			}
+			for _, histogram := range md.Histograms {
+				observe(histogram.GetTimestamp())
+			}
 		}
 	}
 	return time.UnixMilli(minTimestamp), time.UnixMilli(maxTimestamp)
@@ -261,10 +343,21 @@ func prometheusToOtelTimestamp(ts int64) pcommon.Timestamp {
 	return pcommon.Timestamp(ts * int64(time.Millisecond))
 }
 
-func (prwParser *prometheusRemoteOtelParser) setAttributes(dp pmetric.NumberDataPoint, labels []prompb.Label) {
+// startTimestamp returns the created timestamp of a cumulative series when it was sent, the timestamp of the
+// sample otherwise.
+func startTimestamp(created int64, ts int64) pcommon.Timestamp {
+	if created > 0 && created <= ts {
+		return prometheusToOtelTimestamp(created)
+	}
+	return prometheusToOtelTimestamp(ts)
+}
+
+// setAttributes sets the labels of a series as data point attributes, but for the job and instance labels
+// which identify its resource.
+func (prwParser *prometheusRemoteOtelParser) setAttributes(attributes pcommon.Map, labels []prompb.Label) {
 	for _, attr := range labels {
-		if attr.Name != "__name__" {
-			dp.Attributes().PutStr(attr.Name, attr.Value)
+		if attr.Name != "__name__" && attr.Name != jobLabel && attr.Name != instanceLabel {
+			attributes.PutStr(attr.Name, attr.Value)
 		}
 	}
 }
@@ -16,7 +16,9 @@ package signalfxgatewayprometheusremotewritereceiver
 
 import (
 	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/pmetrictest"
 	"github.com/prometheus/prometheus/prompb"
@@ -62,8 +64,10 @@ func TestParseAndPartitionPrometheusRemoteWriteRequest(t *testing.T) {
 		}
 	}
 	expectedTypesSeen := map[pmetric.MetricType][]string{
-		pmetric.MetricTypeSum:   {"http_requests_total", "api_request_duration_seconds_bucket", "api_request_duration_seconds_bucket", "api_request_duration_seconds_count", "request_duration_seconds_count"},
-		pmetric.MetricTypeGauge: {"i_am_a_gauge", "request_duration_seconds", "request_duration_seconds", "request_duration_seconds_sum", "api_request_duration_seconds_sum"},
+		pmetric.MetricTypeSum:       {"http_requests_total"},
+		pmetric.MetricTypeGauge:     {"i_am_a_gauge"},
+		pmetric.MetricTypeHistogram: {"api_request_duration_seconds"},
+		pmetric.MetricTypeSummary:   {"request_duration_seconds"},
 	}
 	require.ElementsMatch(t, maps.Keys(expectedTypesSeen), maps.Keys(typesSeen))
 	for key, values := range typesSeen {
@@ -93,12 +97,67 @@ func TestAddMetrics(t *testing.T) {
 		{
 			name:     "test histograms",
 			sample:   sampleHistogramWq(),
-			expected: addSfxCompatibilityMetrics(expectedSfxCompatibleHistogram(), 0, 0, 0),
+			expected: addSfxCompatibilityMetrics(expectedHistogram(), 0, 0, 0),
 		},
 		{
 			name:     "test quantiles",
 			sample:   sampleSummaryWq(),
-			expected: addSfxCompatibilityMetrics(expectedSfxCompatibleQuantile(), 0, 0, 0),
+			expected: addSfxCompatibilityMetrics(expectedSummary(), 0, 0, 0),
+		},
+		{
+			name: "test histograms with +Inf bucket",
+			sample: &prompb.WriteRequest{
+				Timeseries: []prompb.TimeSeries{
+					{
+						Labels:  []prompb.Label{{Name: "__name__", Value: "foo_bucket"}, {Name: "le", Value: "+Inf"}, {Name: "path", Value: "/a"}},
+						Samples: []prompb.Sample{{Value: 7, Timestamp: jan20.UnixMilli()}},
+					},
+					{
+						Labels:  []prompb.Label{{Name: "__name__", Value: "foo_bucket"}, {Name: "le", Value: "1"}, {Name: "path", Value: "/a"}},
+						Samples: []prompb.Sample{{Value: 4, Timestamp: jan20.UnixMilli()}},
+					},
+					{
+						Labels:  []prompb.Label{{Name: "__name__", Value: "foo_bucket"}, {Name: "le", Value: "+Inf"}, {Name: "path", Value: "/b"}},
+						Samples: []prompb.Sample{{Value: 1, Timestamp: jan20.UnixMilli()}},
+					},
+					{
+						Labels:  []prompb.Label{{Name: "__name__", Value: "foo_sum"}, {Name: "path", Value: "/a"}},
+						Samples: []prompb.Sample{{Value: 12, Timestamp: jan20.UnixMilli()}},
+					},
+					{
+						Labels:  []prompb.Label{{Name: "__name__", Value: "foo_sum"}, {Name: "path", Value: "/b"}},
+						Samples: []prompb.Sample{{Value: 0.5, Timestamp: jan20.UnixMilli()}},
+					},
+				},
+			},
+			expected: addSfxCompatibilityMetrics(func() pmetric.Metrics {
+				result := pmetric.NewMetrics()
+				resourceMetrics := result.ResourceMetrics().AppendEmpty()
+				scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
+				scopeMetrics.Scope().SetName("otelcol/signalfxgatewayprometheusremotewrite")
+				scopeMetrics.Scope().SetVersion("0.1")
+				m := scopeMetrics.Metrics().AppendEmpty()
+				m.SetName("foo")
+				histogram := m.SetEmptyHistogram()
+				histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+				dp := histogram.DataPoints().AppendEmpty()
+				dp.ExplicitBounds().FromRaw([]float64{1})
+				dp.BucketCounts().FromRaw([]uint64{4, 3})
+				dp.SetCount(7)
+				dp.SetSum(12)
+				dp.Attributes().PutStr("path", "/a")
+				dp = histogram.DataPoints().AppendEmpty()
+				dp.BucketCounts().FromRaw([]uint64{1})
+				dp.SetCount(1)
+				dp.SetSum(0.5)
+				dp.Attributes().PutStr("path", "/b")
+				return result
+			}(), 0, 0, 0),
+		},
+		{
+			name:     "test native histograms",
+			sample:   sampleNativeHistogramWq(),
+			expected: addSfxCompatibilityMetrics(expectedNativeHistogram(), 0, 0, 0),
 		},
 		{
 			name: "test missing",
@@ -196,3 +255,180 @@ func TestAddMetrics(t *testing.T) {
 
 	}
 }
+
+func TestMetadata(t *testing.T) {
+	parser := newPrometheusRemoteOtelParser()
+
+	// Remote Write 1.0 senders send the metadata in separate requests.
+	_, err := parser.fromPrometheusWriteRequestMetrics(&prompb.WriteRequest{
+		Metadata: []prompb.MetricMetadata{
+			{MetricFamilyName: "queue_length_total", Type: prompb.MetricMetadata_GAUGE, Help: "Queued items.", Unit: "items"},
+			{MetricFamilyName: "api_request_duration_seconds", Type: prompb.MetricMetadata_HISTOGRAM, Help: "API latency."},
+			{MetricFamilyName: "http_requests", Type: prompb.MetricMetadata_COUNTER, Help: "Total requests."},
+		},
+	})
+	require.NoError(t, err)
+
+	request := flattenWriteRequests([]*prompb.WriteRequest{sampleCounterWq(), sampleHistogramWq()})
+	request.Timeseries = append(request.Timeseries, prompb.TimeSeries{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "queue_length_total"}},
+		Samples: []prompb.Sample{{Value: 3, Timestamp: jan20.UnixMilli()}},
+	})
+	actual, err := parser.fromPrometheusWriteRequestMetrics(request)
+	require.NoError(t, err)
+
+	expected := expectedHistogram()
+	scopeMetrics := expected.ResourceMetrics().At(0).ScopeMetrics().At(0)
+	scopeMetrics.Metrics().At(0).SetDescription("API latency.")
+	expectedCounter().ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().MoveAndAppendTo(scopeMetrics.Metrics())
+	scopeMetrics.Metrics().At(1).SetDescription("Total requests.")
+	metric := scopeMetrics.Metrics().AppendEmpty()
+	metric.SetName("queue_length_total")
+	metric.SetDescription("Queued items.")
+	metric.SetUnit("items")
+	metric.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(3)
+
+	require.NoError(t, pmetrictest.CompareMetrics(addSfxCompatibilityMetrics(expected, 0, 0, 0), actual,
+		pmetrictest.IgnoreMetricsOrder(),
+		pmetrictest.IgnoreTimestamp(),
+		pmetrictest.IgnoreStartTimestamp()))
+}
+
+func TestResources(t *testing.T) {
+	parser := newPrometheusRemoteOtelParser()
+
+	// target_info series may be sent in another request than the series of their target.
+	_, err := parser.fromPrometheusWriteRequestMetrics(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "target_info"},
+					{Name: "job", Value: "api"},
+					{Name: "instance", Value: "10.0.0.1:9090"},
+					{Name: "k8s_pod_name", Value: "api-0"},
+				},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: jan20.UnixMilli()}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	request := sampleGaugeWq()
+	for _, instance := range []string{"10.0.0.1:9090", "10.0.0.2:9090"} {
+		for _, ts := range sampleCounterTs() {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: "job", Value: "api"}, prompb.Label{Name: "instance", Value: instance})
+			request.Timeseries = append(request.Timeseries, ts)
+		}
+	}
+	actual, err := parser.fromPrometheusWriteRequestMetrics(request)
+	require.NoError(t, err)
+
+	expected := addSfxCompatibilityMetrics(expectedGauge(), 0, 0, 0)
+	for _, instance := range []string{"10.0.0.1:9090", "10.0.0.2:9090"} {
+		rm := expectedCounter().ResourceMetrics().At(0)
+		rm.Resource().Attributes().PutStr("service.name", "api")
+		rm.Resource().Attributes().PutStr("service.instance.id", instance)
+		if instance == "10.0.0.1:9090" {
+			rm.Resource().Attributes().PutStr("k8s_pod_name", "api-0")
+		}
+		rm.MoveTo(expected.ResourceMetrics().AppendEmpty())
+	}
+
+	require.NoError(t, pmetrictest.CompareMetrics(expected, actual,
+		pmetrictest.IgnoreMetricsOrder(),
+		pmetrictest.IgnoreTimestamp(),
+		pmetrictest.IgnoreStartTimestamp()))
+}
+
+func TestResourcesOrder(t *testing.T) {
+	parser := newPrometheusRemoteOtelParser()
+	request := &prompb.WriteRequest{}
+	for _, instance := range []string{"10.0.0.3:9090", "10.0.0.2:9090"} {
+		for _, ts := range append(sampleGaugeTs(), sampleCounterTs()...) {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: "job", Value: "api"}, prompb.Label{Name: "instance", Value: instance})
+			request.Timeseries = append(request.Timeseries, ts)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		actual, err := parser.fromPrometheusWriteRequestMetrics(request)
+		require.NoError(t, err)
+		var instances []string
+		for j := 0; j < actual.ResourceMetrics().Len(); j++ {
+			instance, _ := actual.ResourceMetrics().At(j).Resource().Attributes().Get("service.instance.id")
+			instances = append(instances, instance.Str())
+		}
+		require.Equal(t, []string{"", "10.0.0.3:9090", "10.0.0.2:9090"}, instances)
+	}
+}
+
+func TestTimestampBounds(t *testing.T) {
+	startTime, endTime := getTimestampBounds(map[prompb.MetricMetadata_MetricType][]metricData{
+		prompb.MetricMetadata_GAUGE: {
+			{Samples: []prompb.Sample{{Timestamp: 2000}, {Timestamp: 1000}}},
+			{},
+		},
+		prompb.MetricMetadata_HISTOGRAM: {
+			{Histograms: []prompb.Histogram{{Timestamp: 500}, {Timestamp: 3000}}},
+		},
+	})
+	assert.Equal(t, time.UnixMilli(500), startTime)
+	assert.Equal(t, time.UnixMilli(3000), endTime)
+}
+
+func TestClassicFamiliesAcrossRequests(t *testing.T) {
+	metricTypes := func(md pmetric.Metrics) map[string]pmetric.MetricType {
+		types := map[string]pmetric.MetricType{}
+		metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := 0; i < metrics.Len(); i++ {
+			if !strings.HasPrefix(metrics.At(i).Name(), "prometheus.") {
+				types[metrics.At(i).Name()] = metrics.At(i).Type()
+			}
+		}
+		return types
+	}
+
+	parser := newPrometheusRemoteOtelParser()
+	histogramTs := sampleHistogramTs()
+
+	// The sender sharded the buckets and the _sum and _count series into separate requests.
+	buckets, err := parser.fromPrometheusWriteRequestMetrics(&prompb.WriteRequest{Timeseries: histogramTs[:2]})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]pmetric.MetricType{
+		"api_request_duration_seconds_bucket": pmetric.MetricTypeSum,
+	}, metricTypes(buckets))
+
+	sumAndCount, err := parser.fromPrometheusWriteRequestMetrics(&prompb.WriteRequest{Timeseries: histogramTs[2:]})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]pmetric.MetricType{
+		"api_request_duration_seconds_count": pmetric.MetricTypeSum,
+		"api_request_duration_seconds_sum":   pmetric.MetricTypeGauge,
+	}, metricTypes(sumAndCount))
+
+	// A single point missing its _sum is enough to report the whole family series by series.
+	partial := append(sampleHistogramTs(), prompb.TimeSeries{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "api_request_duration_seconds_bucket"}, {Name: "le", Value: "+Inf"}, {Name: "path", Value: "/b"}},
+		Samples: []prompb.Sample{{Value: 3, Timestamp: jan20.UnixMilli()}},
+	})
+	actual, err := parser.fromPrometheusWriteRequestMetrics(&prompb.WriteRequest{Timeseries: partial})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]pmetric.MetricType{
+		"api_request_duration_seconds_bucket": pmetric.MetricTypeSum,
+		"api_request_duration_seconds_count":  pmetric.MetricTypeSum,
+		"api_request_duration_seconds_sum":    pmetric.MetricTypeGauge,
+	}, metricTypes(actual))
+
+	// Summaries need both their _sum and _count.
+	actual, err = parser.fromPrometheusWriteRequestMetrics(&prompb.WriteRequest{Timeseries: sampleSummaryTs()[:3]})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]pmetric.MetricType{
+		"request_duration_seconds":     pmetric.MetricTypeGauge,
+		"request_duration_seconds_sum": pmetric.MetricTypeGauge,
+	}, metricTypes(actual))
+
+	// Once complete, the same family is reassembled.
+	actual, err = parser.fromPrometheusWriteRequestMetrics(sampleHistogramWq())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]pmetric.MetricType{
+		"api_request_duration_seconds": pmetric.MetricTypeHistogram,
+	}, metricTypes(actual))
+}
@@ -20,7 +20,6 @@ import (
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
-	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/receiver"
 
 	"github.com/signalfx/splunk-otel-collector/internal/receiver/signalfxgatewayprometheusremotewritereceiver/internal/metadata"
@@ -33,7 +32,6 @@ type prometheusRemoteWriteReceiver struct {
 	server       *prometheusRemoteWriteServer
 	reporter     reporter
 	nextConsumer consumer.Metrics
-	cancel       context.CancelFunc
 	config       *Config
 	settings     receiver.CreateSettings
 }
@@ -62,16 +60,20 @@ func newReceiver(
 }
 
 // Start starts an HTTP server that can process Prometheus Remote Write Requests
-func (receiver *prometheusRemoteWriteReceiver) Start(ctx context.Context, host component.Host) error {
-	metricsChannel := make(chan pmetric.Metrics, receiver.config.BufferSize)
+func (receiver *prometheusRemoteWriteReceiver) Start(_ context.Context, host component.Host) error {
 	cfg := &serverConfig{
-		ServerConfig:      receiver.config.ServerConfig,
-		Path:              receiver.config.ListenPath,
-		Mc:                metricsChannel,
-		TelemetrySettings: receiver.settings.TelemetrySettings,
-		Reporter:          receiver.reporter,
-		Host:              host,
-		Parser:            newPrometheusRemoteOtelParser(),
+		ServerConfig:        receiver.config.ServerConfig,
+		Path:                receiver.config.ListenPath,
+		Consumer:            receiver.nextConsumer,
+		MaxInFlight:         receiver.config.BufferSize,
+		TelemetrySettings:   receiver.settings.TelemetrySettings,
+		Reporter:            receiver.reporter,
+		Host:                host,
+		Parser:              newPrometheusRemoteOtelParser(),
+		TenantHeader:        receiver.config.TenantHeader,
+		TenantAttribute:     receiver.config.TenantAttribute,
+		MaxDecompressedSize: receiver.config.MaxDecompressedSize,
+		MaxSeriesPerRequest: receiver.config.MaxSeriesPerRequest,
 	}
 	if receiver.server != nil {
 		err := receiver.server.close()
@@ -79,10 +81,6 @@ func (receiver *prometheusRemoteWriteReceiver) Start(ctx context.Context, host c
 			return err
 		}
 	}
-	if receiver.cancel != nil {
-		receiver.cancel()
-	}
-	ctx, receiver.cancel = context.WithCancel(ctx)
 	server, err := newPrometheusRemoteWriteServer(cfg)
 	if err != nil {
 		return err
@@ -90,7 +88,6 @@ func (receiver *prometheusRemoteWriteReceiver) Start(ctx context.Context, host c
 	receiver.server = server
 
 	go receiver.startServer()
-	go receiver.manageServerLifecycle(ctx, metricsChannel)
 
 	return nil
 }
@@ -107,39 +104,10 @@ func (receiver *prometheusRemoteWriteReceiver) startServer() {
 	}
 }
 
-func (receiver *prometheusRemoteWriteReceiver) manageServerLifecycle(ctx context.Context, metricsChannel <-chan pmetric.Metrics) {
-	for {
-		select {
-		case metrics, stillOpen := <-metricsChannel:
-			if !stillOpen {
-				return
-			}
-			metricContext := receiver.reporter.StartMetricsOp(ctx)
-			err := receiver.flush(metricContext, metrics)
-			if err != nil {
-				receiver.reporter.OnError(metricContext, "flush_error", err)
-				return
-			}
-		case <-ctx.Done():
-			return
-		}
-	}
-}
-
 // Shutdown stops the PrometheusSimpleRemoteWrite receiver.
 func (receiver *prometheusRemoteWriteReceiver) Shutdown(context.Context) error {
-	if receiver.cancel == nil {
-		return nil
-	}
-	defer receiver.cancel()
 	if receiver.server != nil {
 		return receiver.server.close()
 	}
 	return nil
 }
-
-func (receiver *prometheusRemoteWriteReceiver) flush(ctx context.Context, metrics pmetric.Metrics) error {
-	err := receiver.nextConsumer.ConsumeMetrics(ctx, metrics)
-	receiver.reporter.OnMetricsProcessed(ctx, metrics.DataPointCount(), err)
-	return err
-}
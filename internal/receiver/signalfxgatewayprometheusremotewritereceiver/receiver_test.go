@@ -54,7 +54,6 @@ func TestEmptySend(t *testing.T) {
 	require.NotNil(t, remoteWriteReceiver)
 	require.NoError(t, remoteWriteReceiver.Start(ctx, nopHost))
 	require.NotEmpty(t, remoteWriteReceiver.server)
-	require.NotEmpty(t, remoteWriteReceiver.cancel)
 	require.NotEmpty(t, remoteWriteReceiver.config)
 	require.Equal(t, remoteWriteReceiver.config.ServerConfig.Endpoint, fmt.Sprintf("localhost:%d", freePort))
 	require.NotEmpty(t, remoteWriteReceiver.settings)
@@ -103,7 +102,6 @@ func TestSuccessfulSend(t *testing.T) {
 		require.NoError(t, remoteWriteReceiver.Shutdown(context.Background()))
 	})
 	require.NotEmpty(t, remoteWriteReceiver.server)
-	require.NotEmpty(t, remoteWriteReceiver.cancel)
 	require.NotEmpty(t, remoteWriteReceiver.config)
 	require.Equal(t, remoteWriteReceiver.config.ServerConfig.Endpoint, fmt.Sprintf("localhost:%d", freePort))
 	require.NotEmpty(t, remoteWriteReceiver.settings)
@@ -0,0 +1,248 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalfxgatewayprometheusremotewritereceiver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/prometheus/prometheus/prompb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// writeRequestV2 is the io.prometheus.write.v2.Request message of the Remote Write 2.0 specification.
+// See https://prometheus.io/docs/specs/remote_write_spec_2_0/#protocol
+// Labels, help and unit strings are references to the interned symbols table of the request.
+type writeRequestV2 struct {
+	Symbols    []string
+	Timeseries []timeSeriesV2
+}
+
+type timeSeriesV2 struct {
+	LabelsRefs       []uint32
+	Samples          []prompb.Sample
+	Histograms       []prompb.Histogram
+	Exemplars        []exemplarV2
+	Metadata         metadataV2
+	CreatedTimestamp int64
+}
+
+type exemplarV2 struct {
+	LabelsRefs []uint32
+	Value      float64
+	Timestamp  int64
+}
+
+// metadataV2 is the metadata of a series. Its metric types share the values of prompb.MetricMetadata_MetricType.
+type metadataV2 struct {
+	Type    prompb.MetricMetadata_MetricType
+	HelpRef uint32
+	UnitRef uint32
+}
+
+var errInvalidSymbolRef = errors.New("invalid symbol reference")
+
+// symbol returns an interned string of the request. The first symbol is always the empty string.
+func (req *writeRequestV2) symbol(ref uint32) (string, error) {
+	if ref == 0 && len(req.Symbols) == 0 {
+		return "", nil
+	}
+	if int(ref) >= len(req.Symbols) {
+		return "", fmt.Errorf("%w %d", errInvalidSymbolRef, ref)
+	}
+	return req.Symbols[ref], nil
+}
+
+// labels resolves label references, which are pairs of name and value references.
+func (req *writeRequestV2) labels(refs []uint32) ([]prompb.Label, error) {
+	if len(refs)%2 != 0 {
+		return nil, fmt.Errorf("odd number of label references: %d", len(refs))
+	}
+	labels := make([]prompb.Label, 0, len(refs)/2)
+	for i := 0; i < len(refs); i += 2 {
+		name, err := req.symbol(refs[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := req.symbol(refs[i+1])
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, prompb.Label{Name: name, Value: value})
+	}
+	return labels, nil
+}
+
+// The protobuf messages are decoded by hand with protowire, as the prometheus version in use doesn't ship the
+// generated io.prometheus.write.v2 types. The Sample and Histogram messages are identical to their Remote Write 1.0
+// counterparts and are decoded with the prompb types.
+
+func (req *writeRequestV2) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 4 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n >= 0 {
+				req.Symbols = append(req.Symbols, string(v))
+			}
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var ts timeSeriesV2
+			if err := ts.unmarshal(v); err != nil {
+				return n, err
+			}
+			req.Timeseries = append(req.Timeseries, ts)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func (ts *timeSeriesV2) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1:
+			return consumeUint32s(num, typ, b, &ts.LabelsRefs), nil
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var sample prompb.Sample
+			if err := sample.Unmarshal(v); err != nil {
+				return n, err
+			}
+			ts.Samples = append(ts.Samples, sample)
+			return n, nil
+		case num == 3 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var histogram prompb.Histogram
+			if err := histogram.Unmarshal(v); err != nil {
+				return n, err
+			}
+			ts.Histograms = append(ts.Histograms, histogram)
+			return n, nil
+		case num == 4 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var exemplar exemplarV2
+			if err := exemplar.unmarshal(v); err != nil {
+				return n, err
+			}
+			ts.Exemplars = append(ts.Exemplars, exemplar)
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			return n, ts.Metadata.unmarshal(v)
+		case num == 6 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			ts.CreatedTimestamp = int64(v)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func (e *exemplarV2) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1:
+			return consumeUint32s(num, typ, b, &e.LabelsRefs), nil
+		case num == 2 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			e.Value = math.Float64frombits(v)
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			e.Timestamp = int64(v)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func (m *metadataV2) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if typ != protowire.VarintType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+		v, n := protowire.ConsumeVarint(b)
+		switch num {
+		case 1:
+			m.Type = prompb.MetricMetadata_MetricType(v)
+		case 3:
+			m.HelpRef = uint32(v)
+		case 4:
+			m.UnitRef = uint32(v)
+		}
+		return n, nil
+	})
+}
+
+// unmarshalFields calls consume with the number, type and encoded value of each field of a message.
+// consume returns the length of the value, negative if it is malformed.
+func unmarshalFields(b []byte, consume func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := consume(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// consumeUint32s decodes a repeated uint32 field, either packed or not.
+func consumeUint32s(num protowire.Number, typ protowire.Type, b []byte, values *[]uint32) int {
+	if typ == protowire.VarintType {
+		v, n := protowire.ConsumeVarint(b)
+		*values = append(*values, uint32(v))
+		return n
+	}
+	if typ != protowire.BytesType {
+		return protowire.ConsumeFieldValue(num, typ, b)
+	}
+	packed, n := protowire.ConsumeBytes(b)
+	for len(packed) > 0 {
+		v, m := protowire.ConsumeVarint(packed)
+		if m < 0 {
+			return m
+		}
+		*values = append(*values, uint32(v))
+		packed = packed[m:]
+	}
+	return n
+}
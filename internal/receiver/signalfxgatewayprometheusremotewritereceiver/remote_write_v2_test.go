@@ -0,0 +1,198 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalfxgatewayprometheusremotewritereceiver
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/pmetrictest"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// marshal encodes the request as a Remote Write 2.0 sender would.
+func (req *writeRequestV2) marshal(t *testing.T) []byte {
+	var b []byte
+	for _, symbol := range req.Symbols {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendString(b, symbol)
+	}
+	for _, ts := range req.Timeseries {
+		var tsb []byte
+		tsb = appendPackedUint32s(tsb, 1, ts.LabelsRefs)
+		for _, sample := range ts.Samples {
+			encoded, err := sample.Marshal()
+			require.NoError(t, err)
+			tsb = protowire.AppendTag(tsb, 2, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, encoded)
+		}
+		for _, histogram := range ts.Histograms {
+			encoded, err := histogram.Marshal()
+			require.NoError(t, err)
+			tsb = protowire.AppendTag(tsb, 3, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, encoded)
+		}
+		for _, exemplar := range ts.Exemplars {
+			var eb []byte
+			eb = appendPackedUint32s(eb, 1, exemplar.LabelsRefs)
+			eb = protowire.AppendTag(eb, 2, protowire.Fixed64Type)
+			eb = protowire.AppendFixed64(eb, math.Float64bits(exemplar.Value))
+			eb = protowire.AppendTag(eb, 3, protowire.VarintType)
+			eb = protowire.AppendVarint(eb, uint64(exemplar.Timestamp))
+			tsb = protowire.AppendTag(tsb, 4, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, eb)
+		}
+		var mb []byte
+		mb = protowire.AppendTag(mb, 1, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, uint64(ts.Metadata.Type))
+		mb = protowire.AppendTag(mb, 3, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, uint64(ts.Metadata.HelpRef))
+		mb = protowire.AppendTag(mb, 4, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, uint64(ts.Metadata.UnitRef))
+		tsb = protowire.AppendTag(tsb, 5, protowire.BytesType)
+		tsb = protowire.AppendBytes(tsb, mb)
+		if ts.CreatedTimestamp != 0 {
+			tsb = protowire.AppendTag(tsb, 6, protowire.VarintType)
+			tsb = protowire.AppendVarint(tsb, uint64(ts.CreatedTimestamp))
+		}
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, tsb)
+	}
+	return snappy.Encode(nil, b)
+}
+
+func appendPackedUint32s(b []byte, num protowire.Number, values []uint32) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = protowire.AppendVarint(packed, uint64(v))
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, packed)
+}
+
+func sampleWriteRequestV2() *writeRequestV2 {
+	return &writeRequestV2{
+		Symbols: []string{"", "__name__", "http_requests_total", "method", "GET", "Total requests.", "queue_length_total", "Queued items.", "items", "rpc_duration_seconds", "trace_id", "abc"},
+		Timeseries: []timeSeriesV2{
+			{
+				LabelsRefs:       []uint32{1, 2, 3, 4},
+				Samples:          []prompb.Sample{{Value: 1024, Timestamp: jan20.UnixMilli()}},
+				Exemplars:        []exemplarV2{{LabelsRefs: []uint32{10, 11}, Value: 1, Timestamp: jan20.UnixMilli()}},
+				Metadata:         metadataV2{Type: prompb.MetricMetadata_COUNTER, HelpRef: 5},
+				CreatedTimestamp: jan20.UnixMilli() - 60000,
+			},
+			{
+				// A gauge named like a counter.
+				LabelsRefs: []uint32{1, 6},
+				Samples:    []prompb.Sample{{Value: 3, Timestamp: jan20.UnixMilli()}},
+				Metadata:   metadataV2{Type: prompb.MetricMetadata_GAUGE, HelpRef: 7, UnitRef: 8},
+			},
+			{
+				LabelsRefs: []uint32{1, 9},
+				Histograms: []prompb.Histogram{
+					{
+						Count:          &prompb.Histogram_CountInt{CountInt: 3},
+						Sum:            1.5,
+						ZeroCount:      &prompb.Histogram_ZeroCountInt{ZeroCountInt: 1},
+						PositiveSpans:  []prompb.BucketSpan{{Offset: 1, Length: 1}},
+						PositiveDeltas: []int64{2},
+						Timestamp:      jan20.UnixMilli(),
+					},
+				},
+				Metadata:         metadataV2{Type: prompb.MetricMetadata_HISTOGRAM},
+				CreatedTimestamp: jan20.UnixMilli() - 60000,
+			},
+		},
+	}
+}
+
+func TestUnmarshalWriteRequestV2(t *testing.T) {
+	expected := sampleWriteRequestV2()
+	buf, err := snappy.Decode(nil, expected.marshal(t))
+	require.NoError(t, err)
+	actual := &writeRequestV2{}
+	require.NoError(t, actual.unmarshal(buf))
+	require.Equal(t, expected, actual)
+
+	require.Error(t, (&writeRequestV2{}).unmarshal([]byte{0x2a, 0xff}))
+}
+
+func TestWriteRequestV2Metrics(t *testing.T) {
+	parser := newPrometheusRemoteOtelParser()
+	actual, err := parser.fromPrometheusWriteRequestV2Metrics(sampleWriteRequestV2())
+	require.NoError(t, err)
+
+	expected := pmetric.NewMetrics()
+	scopeMetrics := expected.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	scopeMetrics.Scope().SetName("otelcol/signalfxgatewayprometheusremotewrite")
+	scopeMetrics.Scope().SetVersion("0.1")
+
+	metric := scopeMetrics.Metrics().AppendEmpty()
+	metric.SetName("http_requests_total")
+	metric.SetDescription("Total requests.")
+	sum := metric.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(jan20.Add(-time.Minute)))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetIntValue(1024)
+	dp.Attributes().PutStr("method", "GET")
+
+	metric = scopeMetrics.Metrics().AppendEmpty()
+	metric.SetName("queue_length_total")
+	metric.SetDescription("Queued items.")
+	metric.SetUnit("items")
+	dp = metric.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(jan20))
+	dp.SetIntValue(3)
+
+	metric = scopeMetrics.Metrics().AppendEmpty()
+	metric.SetName("rpc_duration_seconds")
+	histogram := metric.SetEmptyExponentialHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	hdp := histogram.DataPoints().AppendEmpty()
+	hdp.SetStartTimestamp(pcommon.NewTimestampFromTime(jan20.Add(-time.Minute)))
+	hdp.SetTimestamp(pcommon.NewTimestampFromTime(jan20))
+	hdp.SetCount(3)
+	hdp.SetSum(1.5)
+	hdp.SetZeroCount(1)
+	hdp.Positive().BucketCounts().FromRaw([]uint64{2})
+
+	require.NoError(t, pmetrictest.CompareMetrics(addSfxCompatibilityMetrics(expected, 0, 0, 0), actual,
+		pmetrictest.IgnoreMetricsOrder(),
+		pmetrictest.IgnoreMetricDataPointsOrder(),
+		pmetrictest.IgnoreTimestamp()))
+}
+
+func TestWriteRequestV2InvalidRefs(t *testing.T) {
+	parser := newPrometheusRemoteOtelParser()
+	req := sampleWriteRequestV2()
+	req.Timeseries[0].LabelsRefs = []uint32{1, 42}
+	req.Timeseries[1].Metadata.UnitRef = 42
+	actual, err := parser.fromPrometheusWriteRequestV2Metrics(req)
+	assert.ErrorIs(t, err, errInvalidSymbolRef)
+	assert.Equal(t, int64(2), parser.totalInvalidRequests.Load())
+	// the remaining histogram and the three compatibility metrics
+	assert.Equal(t, 4, actual.MetricCount())
+}
@@ -0,0 +1,95 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalfxgatewayprometheusremotewritereceiver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	snappyEncoding = "snappy"
+	zstdEncoding   = "zstd"
+)
+
+// unsupportedEncodings are the encodings confighttp decompresses by default, without any limit on the decompressed
+// size. They are left encoded for the handler to reject them.
+var unsupportedEncodings = []string{"gzip", "zlib", "deflate"}
+
+var errRequestTooLarge = errors.New("request too large")
+
+// leaveEncoded is registered as the confighttp decoder of the encodings the receiver decompresses itself, so that
+// the decompressed size of requests can be limited. Returning no body keeps the Content-Encoding header.
+func leaveEncoded(io.ReadCloser) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+// bodyDecoder reads and decompresses write requests. Remote write senders compress requests with snappy in the
+// block format, which is assumed when the Content-Encoding header is missing.
+type bodyDecoder struct {
+	zstd                *zstd.Decoder
+	maxDecompressedSize int64
+}
+
+func newBodyDecoder(maxDecompressedSize int64) (*bodyDecoder, error) {
+	// requests are decoded with DecodeAll, which doesn't start any goroutine
+	options := []zstd.DOption{zstd.WithDecoderConcurrency(0)}
+	if maxDecompressedSize > 0 {
+		options = append(options, zstd.WithDecoderMaxMemory(uint64(maxDecompressedSize)))
+	}
+	decoder, err := zstd.NewReader(nil, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &bodyDecoder{zstd: decoder, maxDecompressedSize: maxDecompressedSize}, nil
+}
+
+// decode returns the decompressed body of a request. Bodies larger than the max_request_body_size of the server
+// have been cut short by http.MaxBytesReader.
+func (d *bodyDecoder) decode(r *http.Request) ([]byte, error) {
+	encoding := r.Header.Get("Content-Encoding")
+	if encoding != "" && encoding != snappyEncoding && encoding != zstdEncoding {
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("%w: compressed size exceeds %d bytes", errRequestTooLarge, maxBytesErr.Limit)
+		}
+		return nil, err
+	}
+	if encoding == zstdEncoding {
+		buf, err := d.zstd.DecodeAll(compressed, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, fmt.Errorf("%w: decompressed size exceeds %d bytes", errRequestTooLarge, d.maxDecompressedSize)
+		}
+		return buf, err
+	}
+	// the decompressed size is read from the snappy header, before allocating anything
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, err
+	}
+	if d.maxDecompressedSize > 0 && int64(size) > d.maxDecompressedSize {
+		return nil, fmt.Errorf("%w: decompressed size %d exceeds %d bytes", errRequestTooLarge, size, d.maxDecompressedSize)
+	}
+	return snappy.Decode(nil, compressed)
+}
@@ -0,0 +1,166 @@
+// Copyright Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalfxgatewayprometheusremotewritereceiver
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+const (
+	jobLabel             = "job"
+	instanceLabel        = "instance"
+	targetInfoMetricName = "target_info"
+
+	// targetInfoTTL is how long the target_info labels of a target are kept after they were last written.
+	targetInfoTTL = 10 * time.Minute
+)
+
+// resourceKey identifies the target which produced a series, as the Prometheus receiver does.
+type resourceKey struct {
+	job      string
+	instance string
+}
+
+func resourceKeyOf(labels []prompb.Label) resourceKey {
+	var key resourceKey
+	for _, label := range labels {
+		switch label.Name {
+		case jobLabel:
+			key.job = label.Value
+		case instanceLabel:
+			key.instance = label.Value
+		}
+	}
+	return key
+}
+
+// resourcePartitions are the partitioned series of each target, in the order the targets were first seen when
+// walking the series of each metric type in turn.
+type resourcePartitions struct {
+	partitions map[resourceKey]map[prompb.MetricMetadata_MetricType][]metricData
+	keys       []resourceKey
+}
+
+// partitionByResource splits the partitions by target, the series without job and instance labels being the first.
+// The target_info series are not partitioned, their labels are kept as the resource attributes of their target.
+func (prwParser *prometheusRemoteOtelParser) partitionByResource(parsedPrwMetrics map[prompb.MetricMetadata_MetricType][]metricData) resourcePartitions {
+	resources := resourcePartitions{
+		partitions: map[resourceKey]map[prompb.MetricMetadata_MetricType][]metricData{{}: {}},
+		keys:       []resourceKey{{}},
+	}
+	// walk the metric types in order so that the resources of a request are always in the same order
+	metricTypes := make([]prompb.MetricMetadata_MetricType, 0, len(parsedPrwMetrics))
+	for metricType := range parsedPrwMetrics {
+		metricTypes = append(metricTypes, metricType)
+	}
+	sort.Slice(metricTypes, func(i, j int) bool { return metricTypes[i] < metricTypes[j] })
+	now := time.Now()
+	for _, metricType := range metricTypes {
+		for _, md := range parsedPrwMetrics[metricType] {
+			key := resourceKeyOf(md.Labels)
+			if md.MetricName == targetInfoMetricName {
+				prwParser.targetInfo.update(key, md.Labels, now)
+				continue
+			}
+			partitions, ok := resources.partitions[key]
+			if !ok {
+				partitions = map[prompb.MetricMetadata_MetricType][]metricData{}
+				resources.partitions[key] = partitions
+				resources.keys = append(resources.keys, key)
+			}
+			partitions[metricType] = append(partitions[metricType], md)
+		}
+	}
+	return resources
+}
+
+// setResourceAttributes maps the job and instance of a target to the service.name and service.instance.id
+// attributes, and adds the labels of its target_info series.
+func (prwParser *prometheusRemoteOtelParser) setResourceAttributes(resource pcommon.Resource, key resourceKey) {
+	attributes := resource.Attributes()
+	if key.job != "" {
+		attributes.PutStr(conventions.AttributeServiceName, key.job)
+	}
+	if key.instance != "" {
+		attributes.PutStr(conventions.AttributeServiceInstanceID, key.instance)
+	}
+	if key == (resourceKey{}) {
+		return
+	}
+	for _, label := range prwParser.targetInfo.get(key) {
+		if _, ok := attributes.Get(label.Name); !ok {
+			attributes.PutStr(label.Name, label.Value)
+		}
+	}
+}
+
+// targetInfoCache holds the labels of the target_info series of each target. Remote write senders shard series
+// across requests, so the target_info series of a target is usually not sent along with its other series.
+type targetInfoCache struct {
+	targets   map[resourceKey]targetInfo
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+type targetInfo struct {
+	updated time.Time
+	labels  []prompb.Label
+}
+
+func newTargetInfoCache() *targetInfoCache {
+	return &targetInfoCache{targets: map[resourceKey]targetInfo{}}
+}
+
+func (c *targetInfoCache) update(key resourceKey, labels []prompb.Label, now time.Time) {
+	var attributes []prompb.Label
+	for _, label := range labels {
+		if label.Name != "__name__" && label.Name != jobLabel && label.Name != instanceLabel {
+			attributes = append(attributes, label)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.targets[key] = targetInfo{labels: attributes, updated: now}
+	if now.Sub(c.lastSweep) < targetInfoTTL {
+		return
+	}
+	for k, info := range c.targets {
+		if now.Sub(info.updated) > targetInfoTTL {
+			delete(c.targets, k)
+		}
+	}
+	c.lastSweep = now
+}
+
+func (c *targetInfoCache) get(key resourceKey) []prompb.Label {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.targets[key].labels
+}
+
+// setTenant adds the tenant of a request to the attributes of all its resources.
+func setTenant(metrics pmetric.Metrics, attribute string, tenant string) {
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		metrics.ResourceMetrics().At(i).Resource().Attributes().PutStr(attribute, tenant)
+	}
+}
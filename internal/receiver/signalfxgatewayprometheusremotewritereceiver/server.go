@@ -16,22 +16,43 @@ package signalfxgatewayprometheusremotewritereceiver
 
 import (
 	"errors"
-	"io"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/prompb"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
+const (
+	protobufMediaType        = "application/x-protobuf"
+	protoWriteRequestV1      = "prometheus.WriteRequest"
+	protoWriteRequestV2      = "io.prometheus.write.v2.Request"
+	remoteWriteVersionHeader = "X-Prometheus-Remote-Write-Version"
+	samplesWrittenHeader     = "X-Prometheus-Remote-Write-Samples-Written"
+	histogramsWrittenHeader  = "X-Prometheus-Remote-Write-Histograms-Written"
+	exemplarsWrittenHeader   = "X-Prometheus-Remote-Write-Exemplars-Written"
+
+	// retryAfterSeconds is the delay suggested to senders before retrying requests which couldn't be consumed.
+	retryAfterSeconds = "5"
+
+	// maxTenants is the number of tenants whose metadata, target_info series and compatibility metrics are kept.
+	maxTenants = 1000
+)
+
 type prometheusRemoteWriteServer struct {
 	*http.Server
 	*serverConfig
-	closeChannel *sync.Once
-	listening    *sync.WaitGroup
+	listening *sync.WaitGroup
 }
 
 type serverConfig struct {
@@ -39,29 +60,43 @@ type serverConfig struct {
 	component.TelemetrySettings
 	Reporter reporter
 	component.Host
-	Mc     chan<- pmetric.Metrics
-	Parser *prometheusRemoteOtelParser
-	Path   string
+	Consumer        consumer.Metrics
+	Parser          *prometheusRemoteOtelParser
+	Path            string
+	TenantHeader    string
+	TenantAttribute string
+	// MaxInFlight is the number of requests which can be decoded and consumed concurrently, without limit if 0.
+	MaxInFlight int
+	// MaxDecompressedSize is the maximum size in bytes of a decompressed request, without limit if 0.
+	MaxDecompressedSize int64
+	// MaxSeriesPerRequest is the maximum number of series of a request, without limit if 0.
+	MaxSeriesPerRequest int
 }
 
 func newPrometheusRemoteWriteServer(config *serverConfig) (*prometheusRemoteWriteServer, error) {
 	mx := mux.NewRouter()
-	handler := newHandler(config.Parser, config, config.Mc)
+	handler, err := newHandler(config.Parser, config)
+	if err != nil {
+		return nil, err
+	}
 	mx.HandleFunc(config.Path, handler)
 	mx.Host(config.ServerConfig.Endpoint)
-	server, err := config.ServerConfig.ToServer(config.Host, config.TelemetrySettings, mx,
-		// ensure we support the snappy Content-Encoding, but leave it to the prometheus remotewrite lib to decompress.
-		confighttp.WithDecoder("snappy", func(body io.ReadCloser) (io.ReadCloser, error) {
-			return body, nil
-		}))
-	server.Addr = config.ServerConfig.Endpoint
+	// support the snappy and zstd Content-Encodings, but leave it to the handler to decompress within limits.
+	options := []confighttp.ToServerOption{
+		confighttp.WithDecoder(snappyEncoding, leaveEncoded),
+		confighttp.WithDecoder(zstdEncoding, leaveEncoded),
+	}
+	for _, encoding := range unsupportedEncodings {
+		options = append(options, confighttp.WithDecoder(encoding, leaveEncoded))
+	}
+	server, err := config.ServerConfig.ToServer(config.Host, config.TelemetrySettings, mx, options...)
 	if err != nil {
 		return nil, err
 	}
+	server.Addr = config.ServerConfig.Endpoint
 	prwServer := &prometheusRemoteWriteServer{
 		Server:       server,
 		serverConfig: config,
-		closeChannel: &sync.Once{},
 		listening:    &sync.WaitGroup{},
 	}
 	prwServer.listening.Add(1)
@@ -69,7 +104,6 @@ func newPrometheusRemoteWriteServer(config *serverConfig) (*prometheusRemoteWrit
 }
 
 func (prw *prometheusRemoteWriteServer) close() error {
-	defer prw.closeChannel.Do(func() { close(prw.Mc) })
 	return prw.Server.Close()
 }
 
@@ -93,25 +127,222 @@ func (prw *prometheusRemoteWriteServer) listenAndServe() error {
 	return err
 }
 
-func newHandler(parser *prometheusRemoteOtelParser, sc *serverConfig, mc chan<- pmetric.Metrics) http.HandlerFunc {
+// newHandler returns the handler of write requests, which are consumed before responding so that the errors
+// of the next consumer propagate to the sender.
+func newHandler(parser *prometheusRemoteOtelParser, sc *serverConfig) (http.HandlerFunc, error) {
+	var inFlight chan struct{}
+	if sc.MaxInFlight > 0 {
+		inFlight = make(chan struct{}, sc.MaxInFlight)
+	}
+	decoder, err := newBodyDecoder(sc.MaxDecompressedSize)
+	if err != nil {
+		return nil, err
+	}
+	parsers := newTenantParsers(parser)
 	return func(w http.ResponseWriter, r *http.Request) {
 		sc.Reporter.OnDebugf("Processing write request %s", r.RequestURI)
-		req, err := remote.DecodeWriteRequest(r.Body)
+		// requests are rejected before being decoded when overloaded, so that they cost next to nothing
+		if inFlight != nil {
+			select {
+			case inFlight <- struct{}{}:
+				defer func() { <-inFlight }()
+			default:
+				w.Header().Set("Retry-After", retryAfterSeconds)
+				reject(w, r, sc.Reporter, "too_many_requests", errors.New("too many requests in flight"), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		proto, err := remoteWriteProto(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			reject(w, r, sc.Reporter, "unsupported_media_type", err, http.StatusUnsupportedMediaType)
 			return
 		}
-		if len(req.Timeseries) == 0 && len(req.Metadata) == 0 {
-			w.WriteHeader(http.StatusNoContent)
+		buf, err := decoder.decode(r)
+		switch {
+		case errors.Is(err, errRequestTooLarge):
+			reject(w, r, sc.Reporter, "request_too_large", err, http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			reject(w, r, sc.Reporter, "decode", err, http.StatusBadRequest)
 			return
 		}
-		results, err := parser.fromPrometheusWriteRequestMetrics(req)
-		if nil != err {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			sc.Reporter.OnDebugf("prometheus_translation", err)
+		var tenant string
+		if sc.TenantHeader != "" {
+			tenant = r.Header.Get(sc.TenantHeader)
+		}
+		parser := parsers.get(tenant)
+		var results pmetric.Metrics
+		var reqV2 *writeRequestV2
+		if proto == protoWriteRequestV2 {
+			req := &writeRequestV2{}
+			if err = req.unmarshal(buf); err != nil {
+				reject(w, r, sc.Reporter, "decode", err, http.StatusBadRequest)
+				return
+			}
+			if len(req.Timeseries) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if err = checkSeriesCount(len(req.Timeseries), sc.MaxSeriesPerRequest); err != nil {
+				reject(w, r, sc.Reporter, "request_too_large", err, http.StatusRequestEntityTooLarge)
+				return
+			}
+			if results, err = parser.fromPrometheusWriteRequestV2Metrics(req); err != nil {
+				reject(w, r, sc.Reporter, "prometheus_translation", err, http.StatusBadRequest)
+				return
+			}
+			reqV2 = req
+		} else {
+			req := &prompb.WriteRequest{}
+			if err = req.Unmarshal(buf); err != nil {
+				reject(w, r, sc.Reporter, "decode", err, http.StatusBadRequest)
+				return
+			}
+			if len(req.Timeseries) == 0 && len(req.Metadata) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if err = checkSeriesCount(len(req.Timeseries), sc.MaxSeriesPerRequest); err != nil {
+				reject(w, r, sc.Reporter, "request_too_large", err, http.StatusRequestEntityTooLarge)
+				return
+			}
+			if results, err = parser.fromPrometheusWriteRequestMetrics(req); err != nil {
+				reject(w, r, sc.Reporter, "prometheus_translation", err, http.StatusBadRequest)
+				return
+			}
+		}
+		if tenant != "" {
+			setTenant(results, sc.TenantAttribute, tenant)
+		}
+
+		ctx := sc.Reporter.StartMetricsOp(r.Context())
+		err = sc.Consumer.ConsumeMetrics(ctx, results)
+		sc.Reporter.OnMetricsProcessed(ctx, results.DataPointCount(), err)
+		if err != nil {
+			writeConsumerError(w, err)
 			return
 		}
-		mc <- results
+		if reqV2 != nil {
+			setWrittenHeaders(w, reqV2)
+		}
 		w.WriteHeader(http.StatusAccepted)
+	}, nil
+}
+
+// reject responds to a request which can't be consumed, and reports the reason through the reporter.
+func reject(w http.ResponseWriter, r *http.Request, rep reporter, reason string, err error, status int) {
+	ctx := rep.StartMetricsOp(r.Context())
+	rep.OnError(ctx, reason, err)
+	http.Error(w, err.Error(), status)
+}
+
+func checkSeriesCount(count int, max int) error {
+	if max > 0 && count > max {
+		return fmt.Errorf("%w: %d series exceed the limit of %d", errRequestTooLarge, count, max)
+	}
+	return nil
+}
+
+// writeConsumerError responds with the error of the next consumer. Permanent errors are the sender's fault and
+// must not be retried, others such as memory limiter refusals or full exporter queues are worth retrying.
+func writeConsumerError(w http.ResponseWriter, err error) {
+	if consumererror.IsPermanent(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Retry-After", retryAfterSeconds)
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+}
+
+// tenantParsers holds a parser for each tenant, so that the metadata, target_info series and compatibility
+// metrics of tenants don't mix. The tenant is set by senders, so at most maxTenants parsers are kept, the least
+// recently used being dropped beyond that. The parser of requests without tenant is never dropped.
+type tenantParsers struct {
+	defaultParser *prometheusRemoteOtelParser
+	parsers       map[string]*tenantParser
+	mu            sync.Mutex
+}
+
+type tenantParser struct {
+	parser   *prometheusRemoteOtelParser
+	lastUsed time.Time
+}
+
+func newTenantParsers(defaultParser *prometheusRemoteOtelParser) *tenantParsers {
+	return &tenantParsers{defaultParser: defaultParser, parsers: map[string]*tenantParser{}}
+}
+
+func (t *tenantParsers) get(tenant string) *prometheusRemoteOtelParser {
+	if tenant == "" {
+		return t.defaultParser
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tp, ok := t.parsers[tenant]; ok {
+		tp.lastUsed = now
+		return tp.parser
+	}
+	if len(t.parsers) >= maxTenants {
+		t.evictLeastRecentlyUsed()
+	}
+	tp := &tenantParser{parser: newPrometheusRemoteOtelParser(), lastUsed: now}
+	t.parsers[tenant] = tp
+	return tp.parser
+}
+
+func (t *tenantParsers) evictLeastRecentlyUsed() {
+	var oldest string
+	var oldestUsed time.Time
+	for tenant, tp := range t.parsers {
+		if oldestUsed.IsZero() || tp.lastUsed.Before(oldestUsed) {
+			oldest, oldestUsed = tenant, tp.lastUsed
+		}
+	}
+	delete(t.parsers, oldest)
+}
+
+// remoteWriteProto returns the protobuf message of a request from its Content-Type. When the Content-Type doesn't
+// specify it, the message is determined from the X-Prometheus-Remote-Write-Version header.
+// See https://prometheus.io/docs/specs/remote_write_spec_2_0/#content-type
+func remoteWriteProto(r *http.Request) (string, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return versionProto(r), nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	if mediaType != protobufMediaType {
+		return "", fmt.Errorf("unsupported content type %q", contentType)
+	}
+	switch proto := params["proto"]; proto {
+	case "":
+		return versionProto(r), nil
+	case protoWriteRequestV1, protoWriteRequestV2:
+		return proto, nil
+	default:
+		return "", fmt.Errorf("unsupported remote write message %q", proto)
+	}
+}
+
+func versionProto(r *http.Request) string {
+	if strings.HasPrefix(r.Header.Get(remoteWriteVersionHeader), "2.") {
+		return protoWriteRequestV2
+	}
+	return protoWriteRequestV1
+}
+
+// setWrittenHeaders reports the number of samples and histograms written, as Remote Write 2.0 senders expect.
+// Exemplars are not converted.
+func setWrittenHeaders(w http.ResponseWriter, req *writeRequestV2) {
+	samples, histograms := 0, 0
+	for _, ts := range req.Timeseries {
+		samples += len(ts.Samples)
+		histograms += len(ts.Histograms)
 	}
+	w.Header().Set(samplesWrittenHeader, strconv.Itoa(samples))
+	w.Header().Set(histogramsWrittenHeader, strconv.Itoa(histograms))
+	w.Header().Set(exemplarsWrittenHeader, "0")
 }
@@ -15,21 +15,35 @@
 package signalfxgatewayprometheusremotewritereceiver
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
 func TestWriteEmpty(t *testing.T) {
-	mc := make(chan<- pmetric.Metrics)
 	mockReporter := newMockReporter()
 	freePort, err := getFreePort()
 	require.NoError(t, err)
@@ -39,7 +53,7 @@ func TestWriteEmpty(t *testing.T) {
 	cfg := &serverConfig{
 		Path:     "/metrics",
 		Reporter: mockReporter,
-		Mc:       mc,
+		Consumer: consumertest.NewNop(),
 		ServerConfig: confighttp.ServerConfig{
 			Endpoint: expectedEndpoint,
 		},
@@ -80,7 +94,6 @@ func TestWriteEmpty(t *testing.T) {
 }
 
 func TestWriteMany(t *testing.T) {
-	mc := make(chan<- pmetric.Metrics, 1000)
 	mockReporter := newMockReporter()
 	freePort, err := getFreePort()
 	require.NoError(t, err)
@@ -90,7 +103,7 @@ func TestWriteMany(t *testing.T) {
 	cfg := &serverConfig{
 		Path:     "/metrics",
 		Reporter: mockReporter,
-		Mc:       mc,
+		Consumer: consumertest.NewNop(),
 		ServerConfig: confighttp.ServerConfig{
 			Endpoint: expectedEndpoint,
 		},
@@ -120,6 +133,8 @@ func TestWriteMany(t *testing.T) {
 	require.NotNil(t, client)
 	time.Sleep(100 * time.Millisecond)
 	wqs := getWriteRequestsOfAllTypesWithoutMetadata()
+	mockReporter.AddExpectedStart(len(wqs))
+	mockReporter.AddExpectedSuccess(len(wqs))
 	for _, wq := range wqs {
 		require.NoError(t, client.sendWriteRequest(wq))
 	}
@@ -128,3 +143,290 @@ func TestWriteMany(t *testing.T) {
 	require.NoError(t, remoteWriteServer.Shutdown(ctx))
 	require.Eventually(t, func() bool { serverLifecycle.Wait(); return true }, time.Second*2, 100*time.Millisecond)
 }
+
+func TestRemoteWriteProto(t *testing.T) {
+	testCases := []struct {
+		name          string
+		contentType   string
+		version       string
+		expected      string
+		expectedError string
+	}{
+		{name: "no content type", expected: protoWriteRequestV1},
+		{name: "no content type with version 2", version: "2.0.0", expected: protoWriteRequestV2},
+		{name: "protobuf", contentType: "application/x-protobuf", version: "0.1.0", expected: protoWriteRequestV1},
+		{name: "protobuf with version 2", contentType: "application/x-protobuf", version: "2.0.0", expected: protoWriteRequestV2},
+		{name: "v1 message", contentType: "application/x-protobuf;proto=prometheus.WriteRequest", expected: protoWriteRequestV1},
+		{name: "v2 message", contentType: "application/x-protobuf;proto=io.prometheus.write.v2.Request", version: "2.0.0", expected: protoWriteRequestV2},
+		{name: "unknown message", contentType: "application/x-protobuf;proto=io.prometheus.write.v3.Request", expectedError: "unsupported remote write message"},
+		{name: "unknown content type", contentType: "application/json", expectedError: "unsupported content type"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/metrics", nil)
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			if tc.version != "" {
+				r.Header.Set(remoteWriteVersionHeader, tc.version)
+			}
+			proto, err := remoteWriteProto(r)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, proto)
+		})
+	}
+}
+
+func TestWriteV2(t *testing.T) {
+	sink := &consumertest.MetricsSink{}
+	mockReporter := newMockReporter()
+	mockReporter.AddExpectedStart(1)
+	mockReporter.AddExpectedSuccess(1)
+	cfg := &serverConfig{Reporter: mockReporter, Consumer: sink}
+	handler, err := newHandler(newPrometheusRemoteOtelParser(), cfg)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/metrics", bytes.NewReader(sampleWriteRequestV2().marshal(t)))
+	r.Header.Set("Content-Type", "application/x-protobuf;proto=io.prometheus.write.v2.Request")
+	r.Header.Set("Content-Encoding", "snappy")
+	r.Header.Set(remoteWriteVersionHeader, "2.0.0")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "2", w.Header().Get(samplesWrittenHeader))
+	require.Equal(t, "1", w.Header().Get(histogramsWrittenHeader))
+	require.Equal(t, "0", w.Header().Get(exemplarsWrittenHeader))
+	// the three series and the compatibility metrics
+	require.Equal(t, 6, sink.AllMetrics()[0].MetricCount())
+
+	mockReporter.AddExpectedStart(1)
+	mockReporter.AddExpectedError(1)
+	r = httptest.NewRequest(http.MethodPost, "/metrics", bytes.NewReader(nil))
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	require.Equal(t, "unsupported_media_type", <-mockReporter.ErrorLocation)
+	require.NoError(t, mockReporter.WaitAllOnMetricsProcessedCalls(time.Second))
+}
+
+func TestWriteConsumerErrors(t *testing.T) {
+	testCases := []struct {
+		name               string
+		err                error
+		maxInFlight        int
+		expectedStatus     int
+		expectedRetryAfter string
+	}{
+		{name: "success", expectedStatus: http.StatusAccepted},
+		{name: "retryable", err: errors.New("data refused due to high memory usage"), expectedStatus: http.StatusServiceUnavailable, expectedRetryAfter: retryAfterSeconds},
+		{name: "permanent", err: consumererror.NewPermanent(errors.New("invalid data")), expectedStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockReporter := newMockReporter()
+			mockReporter.AddExpectedStart(2)
+			mockReporter.AddExpectedSuccess(2)
+			cfg := &serverConfig{Reporter: mockReporter, Consumer: consumertest.NewErr(tc.err)}
+			handler, err := newHandler(newPrometheusRemoteOtelParser(), cfg)
+			require.NoError(t, err)
+
+			// the handler keeps on consuming after an error
+			for i := 0; i < 2; i++ {
+				w := httptest.NewRecorder()
+				handler(w, newWriteRequest(t, sampleCounterWq()))
+				require.Equal(t, tc.expectedStatus, w.Code)
+				require.Equal(t, tc.expectedRetryAfter, w.Header().Get("Retry-After"))
+			}
+			require.NoError(t, mockReporter.WaitAllOnMetricsProcessedCalls(time.Second))
+		})
+	}
+}
+
+func TestWriteMaxInFlight(t *testing.T) {
+	blocked := make(chan struct{})
+	consumed := make(chan struct{})
+	blocking, err := consumer.NewMetrics(func(context.Context, pmetric.Metrics) error {
+		consumed <- struct{}{}
+		<-blocked
+		return nil
+	})
+	require.NoError(t, err)
+	mockReporter := newMockReporter()
+	mockReporter.AddExpectedStart(2)
+	mockReporter.AddExpectedSuccess(1)
+	mockReporter.AddExpectedError(1)
+	cfg := &serverConfig{Reporter: mockReporter, Consumer: blocking, MaxInFlight: 1}
+	handler, err := newHandler(newPrometheusRemoteOtelParser(), cfg)
+	require.NoError(t, err)
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler(first, newWriteRequest(t, sampleCounterWq()))
+		close(done)
+	}()
+	<-consumed
+
+	// the request is rejected before being decoded
+	w := httptest.NewRecorder()
+	r := newWriteRequest(t, sampleCounterWq())
+	r.Body = io.NopCloser(strings.NewReader("not snappy"))
+	handler(w, r)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "too_many_requests", <-mockReporter.ErrorLocation)
+	require.Equal(t, retryAfterSeconds, w.Header().Get("Retry-After"))
+
+	close(blocked)
+	<-done
+	require.Equal(t, http.StatusAccepted, first.Code)
+	require.NoError(t, mockReporter.WaitAllOnMetricsProcessedCalls(time.Second))
+}
+
+func newWriteRequest(t *testing.T, wq *prompb.WriteRequest) *http.Request {
+	data, err := proto.Marshal(wq)
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, "/metrics", bytes.NewReader(snappy.Encode(nil, data)))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	r.Header.Set("Content-Encoding", "snappy")
+	return r
+}
+
+func TestWriteTenant(t *testing.T) {
+	sink := &consumertest.MetricsSink{}
+	mockReporter := newMockReporter()
+	mockReporter.AddExpectedStart(2)
+	mockReporter.AddExpectedSuccess(2)
+	cfg := &serverConfig{Reporter: mockReporter, Consumer: sink, TenantHeader: "X-Scope-OrgID", TenantAttribute: "tenant"}
+	handler, err := newHandler(newPrometheusRemoteOtelParser(), cfg)
+	require.NoError(t, err)
+
+	for _, tenant := range []string{"team-a", ""} {
+		r := newWriteRequest(t, sampleCounterWq())
+		if tenant != "" {
+			r.Header.Set("X-Scope-OrgID", tenant)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		require.Equal(t, http.StatusAccepted, w.Code)
+	}
+
+	require.Len(t, sink.AllMetrics(), 2)
+	attributes := sink.AllMetrics()[0].ResourceMetrics().At(0).Resource().Attributes()
+	tenant, ok := attributes.Get("tenant")
+	require.True(t, ok)
+	require.Equal(t, "team-a", tenant.Str())
+	require.Equal(t, 0, sink.AllMetrics()[1].ResourceMetrics().At(0).Resource().Attributes().Len())
+}
+
+func TestTenantParsers(t *testing.T) {
+	defaultParser := newPrometheusRemoteOtelParser()
+	parsers := newTenantParsers(defaultParser)
+	require.Same(t, defaultParser, parsers.get(""))
+
+	first := parsers.get("tenant-0")
+	require.Same(t, first, parsers.get("tenant-0"))
+	for i := 1; i < maxTenants; i++ {
+		parsers.get(fmt.Sprintf("tenant-%d", i))
+	}
+	require.Len(t, parsers.parsers, maxTenants)
+	// the least recently used tenant is dropped, not the ones written again
+	parsers.parsers["tenant-1"].lastUsed = time.Time{}.Add(time.Second)
+	parsers.parsers["tenant-0"].lastUsed = time.Now()
+	parsers.get("tenant-new")
+	require.Len(t, parsers.parsers, maxTenants)
+	require.NotContains(t, parsers.parsers, "tenant-1")
+	require.Same(t, first, parsers.get("tenant-0"))
+	require.Same(t, defaultParser, parsers.get(""))
+}
+
+func TestWriteLimits(t *testing.T) {
+	wq := &prompb.WriteRequest{Timeseries: append(sampleCounterTs(), sampleGaugeTs()...)}
+	data, err := proto.Marshal(wq)
+	require.NoError(t, err)
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstdData := encoder.EncodeAll(data, nil)
+	require.NoError(t, encoder.Close())
+	// 64 MiB of zeros compress to about 64 KiB
+	var gzipBomb bytes.Buffer
+	gz := gzip.NewWriter(&gzipBomb)
+	_, err = gz.Write(make([]byte, 64<<20))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	var zlibData bytes.Buffer
+	zw := zlib.NewWriter(&zlibData)
+	_, err = zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	testCases := []struct {
+		name                string
+		encoding            string
+		body                []byte
+		maxRequestBodySize  int64
+		maxDecompressedSize int64
+		maxSeriesPerRequest int
+		expectedStatus      int
+		expectedReason      string
+	}{
+		{name: "snappy", encoding: "snappy", body: snappy.Encode(nil, data), expectedStatus: http.StatusAccepted},
+		{name: "no encoding", body: snappy.Encode(nil, data), expectedStatus: http.StatusAccepted},
+		{name: "zstd", encoding: "zstd", body: zstdData, expectedStatus: http.StatusAccepted},
+		{name: "within limits", encoding: "zstd", body: zstdData, maxRequestBodySize: int64(len(zstdData)), maxDecompressedSize: 1024, maxSeriesPerRequest: 2, expectedStatus: http.StatusAccepted},
+		{name: "compressed too large", encoding: "snappy", body: snappy.Encode(nil, data), maxRequestBodySize: 10, expectedStatus: http.StatusRequestEntityTooLarge, expectedReason: "request_too_large"},
+		{name: "snappy decompressed too large", encoding: "snappy", body: snappy.Encode(nil, data), maxDecompressedSize: 10, expectedStatus: http.StatusRequestEntityTooLarge, expectedReason: "request_too_large"},
+		{name: "zstd decompressed too large", encoding: "zstd", body: zstdData, maxDecompressedSize: 10, expectedStatus: http.StatusRequestEntityTooLarge, expectedReason: "request_too_large"},
+		{name: "too many series", encoding: "zstd", body: zstdData, maxSeriesPerRequest: 1, expectedStatus: http.StatusRequestEntityTooLarge, expectedReason: "request_too_large"},
+		{name: "corrupt", encoding: "zstd", body: data, expectedStatus: http.StatusBadRequest, expectedReason: "decode"},
+		{name: "gzip bomb", encoding: "gzip", body: gzipBomb.Bytes(), maxDecompressedSize: 1024, expectedStatus: http.StatusBadRequest, expectedReason: "decode"},
+		{name: "zlib", encoding: "zlib", body: zlibData.Bytes(), expectedStatus: http.StatusBadRequest, expectedReason: "decode"},
+		{name: "deflate", encoding: "deflate", body: zlibData.Bytes(), expectedStatus: http.StatusBadRequest, expectedReason: "decode"},
+		// rejected by confighttp before reaching the handler
+		{name: "unsupported encoding", encoding: "br", body: data, expectedStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockReporter := newMockReporter()
+			if tc.expectedStatus == http.StatusAccepted {
+				mockReporter.AddExpectedStart(1)
+				mockReporter.AddExpectedSuccess(1)
+			}
+			if tc.expectedReason != "" {
+				mockReporter.AddExpectedStart(1)
+				mockReporter.AddExpectedError(1)
+			}
+			cfg := &serverConfig{
+				ServerConfig:        confighttp.ServerConfig{Endpoint: "localhost:0", MaxRequestBodySize: tc.maxRequestBodySize},
+				TelemetrySettings:   componenttest.NewNopTelemetrySettings(),
+				Host:                componenttest.NewNopHost(),
+				Reporter:            mockReporter,
+				Consumer:            consumertest.NewNop(),
+				Parser:              newPrometheusRemoteOtelParser(),
+				Path:                "/metrics",
+				MaxDecompressedSize: tc.maxDecompressedSize,
+				MaxSeriesPerRequest: tc.maxSeriesPerRequest,
+			}
+			remoteWriteServer, err := newPrometheusRemoteWriteServer(cfg)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, "/metrics", bytes.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/x-protobuf")
+			if tc.encoding != "" {
+				r.Header.Set("Content-Encoding", tc.encoding)
+			}
+			w := httptest.NewRecorder()
+			remoteWriteServer.Handler.ServeHTTP(w, r)
+			require.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+			if tc.expectedReason != "" {
+				require.Equal(t, tc.expectedReason, <-mockReporter.ErrorLocation)
+			}
+			require.NoError(t, mockReporter.WaitAllOnMetricsProcessedCalls(time.Second))
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package timestampprocessor
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -29,6 +30,56 @@ const (
 type Config struct {
 	// the time offset to apply
 	Offset string `mapstructure:"offset"`
+	// the rules selecting telemetry to apply their own offset to, instead of Offset. The first matching rule applies.
+	Rules []Rule `mapstructure:"rules"`
+	// the handling of timestamps still out of range once offset
+	OutOfRange OutOfRange `mapstructure:"out_of_range"`
+	// the rebasing of replayed telemetry onto the current time, applied before any offset
+	Rebase Rebase `mapstructure:"rebase"`
+	// whether offsets also apply to the observed timestamps of log records, the time they were received. Observed
+	// timestamps are never rebased nor checked for being out of range.
+	ShiftObservedTimestamp bool `mapstructure:"shift_observed_timestamp"`
+}
+
+// Rule selects telemetry by the attributes of its resource and record, and by an OTTL condition. A rule without
+// selectors matches all telemetry.
+type Rule struct {
+	// the resource attribute values to match
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes"`
+	// the span, data point or log record attribute values to match
+	Attributes map[string]string `mapstructure:"attributes"`
+	// the OTTL condition to match, evaluated in the span, data point or log context
+	Condition string `mapstructure:"condition"`
+	// the time offset to apply
+	Offset string `mapstructure:"offset"`
+	// the record or resource attribute holding the time offset to apply, either as a duration string or as a
+	// number of OffsetUnit. Offset applies when the attribute is missing or invalid.
+	OffsetAttribute string `mapstructure:"offset_attribute"`
+	// the unit of numeric offset attribute values, one second by default
+	OffsetUnit string `mapstructure:"offset_unit"`
+}
+
+// OutOfRange handles the telemetry with timestamps outside of the [now-max_age, now+max_future] window, such as
+// telemetry from devices with wildly wrong clocks. The reference timestamp of a span is its end timestamp, the
+// timestamp of data points and log records otherwise.
+type OutOfRange struct {
+	// clamp to shift records to the closest bound of the window, replace to shift records to the time they were
+	// received, or drop to drop records. Out of range timestamps are kept when empty.
+	Mode string `mapstructure:"mode"`
+	// the maximum age of timestamps, without limit if 0
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// the maximum time timestamps can be ahead of the current time, without limit if 0
+	MaxFuture time.Duration `mapstructure:"max_future"`
+}
+
+// Rebase shifts replayed telemetry so that an anchor timestamp maps to the time the first batch is processed,
+// preserving the spacing of all timestamps.
+type Rebase struct {
+	// whether to rebase telemetry
+	Enabled bool `mapstructure:"enabled"`
+	// the RFC 3339 recorded timestamp mapped to the current time. The earliest span start, data point or log
+	// record timestamp of the first batch is the anchor when empty.
+	Anchor string `mapstructure:"anchor"`
 }
 
 var _ component.Config = (*Config)(nil)
@@ -39,5 +90,55 @@ func (cfg *Config) Validate() error {
 	if err != nil {
 		return fmt.Errorf("invalid offset format %s: %w", cfg.Offset, err)
 	}
+	if err = cfg.OutOfRange.validate(); err != nil {
+		return fmt.Errorf("out_of_range: %w", err)
+	}
+	if cfg.Rebase.Anchor != "" {
+		if _, err = time.Parse(time.RFC3339Nano, cfg.Rebase.Anchor); err != nil {
+			return fmt.Errorf("invalid rebase anchor format %s: %w", cfg.Rebase.Anchor, err)
+		}
+	}
+	for i, rule := range cfg.Rules {
+		if err = rule.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) validate() error {
+	if r.Offset == "" && r.OffsetAttribute == "" {
+		return errors.New("either offset or offset_attribute must be set")
+	}
+	if r.Offset != "" {
+		if _, err := time.ParseDuration(r.Offset); err != nil {
+			return fmt.Errorf("invalid offset format %s: %w", r.Offset, err)
+		}
+	}
+	if r.OffsetUnit != "" {
+		if r.OffsetAttribute == "" {
+			return errors.New("offset_unit requires offset_attribute")
+		}
+		if _, err := time.ParseDuration(r.OffsetUnit); err != nil {
+			return fmt.Errorf("invalid offset unit format %s: %w", r.OffsetUnit, err)
+		}
+	}
+	return nil
+}
+
+func (o *OutOfRange) validate() error {
+	switch o.Mode {
+	case "":
+		return nil
+	case modeClamp, modeReplace, modeDrop:
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of %s, %s or %s", o.Mode, modeClamp, modeReplace, modeDrop)
+	}
+	if o.MaxAge < 0 || o.MaxFuture < 0 {
+		return errors.New("max_age and max_future must be non-negative")
+	}
+	if o.MaxAge == 0 && o.MaxFuture == 0 {
+		return errors.New("either max_age or max_future must be set")
+	}
 	return nil
 }
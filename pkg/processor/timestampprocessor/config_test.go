@@ -36,7 +36,7 @@ func TestConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, configs)
 
-	assert.Equal(t, 3, len(configs.ToStringMap()))
+	assert.Equal(t, 6, len(configs.ToStringMap()))
 
 	cm, err := configs.Sub(typeStr)
 	require.NoError(t, err)
@@ -62,6 +62,69 @@ func TestConfig(t *testing.T) {
 	offset, _ = time.ParseDuration(r2.Offset)
 	offset2 := offsetFn(offset)(ts)
 	require.Equal(t, now.Add(-3*time.Hour), offset2.AsTime())
+
+	cm, err = configs.Sub(fmt.Sprintf("%s/rules", typeStr))
+	require.NoError(t, err)
+	r3 := NewFactory().CreateDefaultConfig().(*Config)
+	err = component.UnmarshalConfig(cm, r3)
+	require.NoError(t, err)
+	require.NoError(t, r3.Validate())
+	assert.Equal(t, []Rule{
+		{ResourceAttributes: map[string]string{"host.name": "skewed"}, Offset: "-90s"},
+		{Condition: `attributes["device.type"] == "sensor"`, OffsetAttribute: "clock.drift", OffsetUnit: "1ms"},
+	}, r3.Rules)
+
+	cm, err = configs.Sub(fmt.Sprintf("%s/out_of_range", typeStr))
+	require.NoError(t, err)
+	r4 := NewFactory().CreateDefaultConfig().(*Config)
+	err = component.UnmarshalConfig(cm, r4)
+	require.NoError(t, err)
+	require.NoError(t, r4.Validate())
+	assert.Equal(t, OutOfRange{Mode: modeClamp, MaxAge: 24 * time.Hour, MaxFuture: 5 * time.Minute}, r4.OutOfRange)
+
+	cm, err = configs.Sub(fmt.Sprintf("%s/rebase", typeStr))
+	require.NoError(t, err)
+	r5 := NewFactory().CreateDefaultConfig().(*Config)
+	err = component.UnmarshalConfig(cm, r5)
+	require.NoError(t, err)
+	require.NoError(t, r5.Validate())
+	assert.Equal(t, Rebase{Enabled: true, Anchor: "2024-03-01T12:00:00Z"}, r5.Rebase)
+	assert.False(t, r5.ShiftObservedTimestamp)
+}
+
+func TestValidateOutOfRange(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		outOfRange OutOfRange
+		err        string
+	}{
+		{name: "invalid mode", outOfRange: OutOfRange{Mode: "shift", MaxAge: time.Hour}, err: `invalid mode "shift"`},
+		{name: "no window", outOfRange: OutOfRange{Mode: modeDrop}, err: "either max_age or max_future must be set"},
+		{name: "negative", outOfRange: OutOfRange{Mode: modeDrop, MaxAge: -time.Hour}, err: "must be non-negative"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Offset: "0h", OutOfRange: tc.outOfRange}
+			require.ErrorContains(t, cfg.Validate(), tc.err)
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{name: "no offset", rule: Rule{Attributes: map[string]string{"a": "b"}}, err: "either offset or offset_attribute must be set"},
+		{name: "invalid offset", rule: Rule{Offset: "1x"}, err: "invalid offset format 1x"},
+		{name: "unit without attribute", rule: Rule{Offset: "1h", OffsetUnit: "1ms"}, err: "offset_unit requires offset_attribute"},
+		{name: "invalid unit", rule: Rule{OffsetAttribute: "drift", OffsetUnit: "ms"}, err: "invalid offset unit format ms"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Offset: "0h", Rules: []Rule{tc.rule}}
+			require.ErrorContains(t, cfg.Validate(), tc.err)
+		})
+	}
 }
 
 func TestOffsetFnZero(t *testing.T) {
@@ -18,6 +18,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -47,7 +51,8 @@ func NewFactory() processor.Factory {
 // Note: This isn't a valid configuration because the processor would do no work.
 func createDefaultConfig() component.Config {
 	return &Config{
-		Offset: "0h",
+		Offset:                 "0h",
+		ShiftObservedTimestamp: true,
 	}
 }
 
@@ -58,15 +63,28 @@ func createTracesProcessor(
 	nextConsumer consumer.Traces,
 ) (processor.Traces, error) {
 	oCfg := cfg.(*Config)
-	offset, _ := time.ParseDuration(oCfg.Offset)
+	parser, err := ottlspan.NewParser(ottlfuncs.StandardConverters[ottlspan.TransformContext](), set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := newRules(oCfg, parser)
+	if err != nil {
+		return nil, err
+	}
+	outOfRange, err := newOutOfRange(oCfg.OutOfRange, set.TelemetrySettings, set.ID, "traces")
+	if err != nil {
+		return nil, err
+	}
 
+	rebaser := getOrCreateRebaser(oCfg)
 	return processorhelper.NewTracesProcessor(
 		ctx,
 		set,
 		cfg,
 		nextConsumer,
-		newSpanAttributesProcessor(set.Logger, offsetFn(offset)),
-		processorhelper.WithCapabilities(processorCapabilities))
+		newSpanAttributesProcessor(set.Logger, rules, outOfRange, rebaser),
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithShutdown(shutdownFn(oCfg, rebaser)))
 }
 
 func createLogsProcessor(
@@ -76,15 +94,28 @@ func createLogsProcessor(
 	nextConsumer consumer.Logs,
 ) (processor.Logs, error) {
 	oCfg := cfg.(*Config)
-	offset, _ := time.ParseDuration(oCfg.Offset)
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[ottllog.TransformContext](), set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := newRules(oCfg, parser)
+	if err != nil {
+		return nil, err
+	}
+	outOfRange, err := newOutOfRange(oCfg.OutOfRange, set.TelemetrySettings, set.ID, "logs")
+	if err != nil {
+		return nil, err
+	}
 
+	rebaser := getOrCreateRebaser(oCfg)
 	return processorhelper.NewLogsProcessor(
 		ctx,
 		set,
 		cfg,
 		nextConsumer,
-		newLogAttributesProcessor(set.Logger, offsetFn(offset)),
-		processorhelper.WithCapabilities(processorCapabilities))
+		newLogAttributesProcessor(set.Logger, rules, outOfRange, rebaser, oCfg.ShiftObservedTimestamp),
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithShutdown(shutdownFn(oCfg, rebaser)))
 }
 
 func createMetricsProcessor(
@@ -94,15 +125,38 @@ func createMetricsProcessor(
 	nextConsumer consumer.Metrics,
 ) (processor.Metrics, error) {
 	oCfg := cfg.(*Config)
-	offset, _ := time.ParseDuration(oCfg.Offset)
+	parser, err := ottldatapoint.NewParser(ottlfuncs.StandardConverters[ottldatapoint.TransformContext](), set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := newRules(oCfg, parser)
+	if err != nil {
+		return nil, err
+	}
+	outOfRange, err := newOutOfRange(oCfg.OutOfRange, set.TelemetrySettings, set.ID, "metrics")
+	if err != nil {
+		return nil, err
+	}
 
+	rebaser := getOrCreateRebaser(oCfg)
 	return processorhelper.NewMetricsProcessor(
 		ctx,
 		set,
 		cfg,
 		nextConsumer,
-		newMetricAttributesProcessor(set.Logger, offsetFn(offset)),
-		processorhelper.WithCapabilities(processorCapabilities))
+		newMetricAttributesProcessor(set.Logger, rules, outOfRange, rebaser),
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithShutdown(shutdownFn(oCfg, rebaser)))
+}
+
+// shutdownFn releases the rebaser of a processor, if any.
+func shutdownFn(cfg *Config, rebaser *rebaser) component.ShutdownFunc {
+	return func(context.Context) error {
+		if rebaser != nil {
+			releaseRebaser(cfg)
+		}
+		return nil
+	}
 }
 
 func offsetFn(offset time.Duration) func(pcommon.Timestamp) pcommon.Timestamp {
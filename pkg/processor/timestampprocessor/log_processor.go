@@ -17,22 +17,50 @@ package timestampprocessor
 import (
 	"context"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/processor/processorhelper"
 	"go.uber.org/zap"
 )
 
-func newLogAttributesProcessor(_ *zap.Logger, offsetFn func(timestamp pcommon.Timestamp) pcommon.Timestamp) processorhelper.ProcessLogsFunc {
+func newLogAttributesProcessor(_ *zap.Logger, rules *rules[ottllog.TransformContext], outOfRange *outOfRange, rebaser *rebaser, shiftObserved bool) processorhelper.ProcessLogsFunc {
 	return func(ctx context.Context, logs plog.Logs) (plog.Logs, error) {
+		rebaseFn := rebaser.shiftFn(func() pcommon.Timestamp { return earliestLogTimestamp(logs) })
+		check := outOfRange.start()
+		defer check.record(ctx)
+		var err error
 		for i := 0; i < logs.ResourceLogs().Len(); i++ {
 			rs := logs.ResourceLogs().At(i)
 			for j := 0; j < rs.ScopeLogs().Len(); j++ {
 				ss := rs.ScopeLogs().At(j)
-				for k := 0; k < ss.LogRecords().Len(); k++ {
-					log := ss.LogRecords().At(k)
+				ss.LogRecords().RemoveIf(func(log plog.LogRecord) bool {
+					if err != nil {
+						return false
+					}
+					if rebaseFn != nil {
+						log.SetTimestamp(rebaseFn(log.Timestamp()))
+					}
+					var offsetFn func(pcommon.Timestamp) pcommon.Timestamp
+					offsetFn, err = rules.offsetFn(ctx, rs.Resource(), log.Attributes(), func() ottllog.TransformContext {
+						return ottllog.NewTransformContext(log, ss.Scope(), rs.Resource())
+					})
+					if err != nil {
+						return false
+					}
 					log.SetTimestamp(offsetFn(log.Timestamp()))
-					log.SetObservedTimestamp(offsetFn(log.ObservedTimestamp()))
+					if shiftObserved {
+						log.SetObservedTimestamp(offsetFn(log.ObservedTimestamp()))
+					}
+					// the observed timestamp is the time the record was received, only the timestamp is checked
+					shiftFn, drop := check.check(log.Timestamp(), log.ObservedTimestamp())
+					if shiftFn != nil {
+						log.SetTimestamp(shiftFn(log.Timestamp()))
+					}
+					return drop
+				})
+				if err != nil {
+					return logs, err
 				}
 			}
 		}
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -30,7 +31,7 @@ func Test_newLogAttributesProcessor(t *testing.T) {
 	logs := plog.NewLogs()
 	lr := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
 	lr.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newLogAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newLogAttributesProcessor(zap.NewNop(), offsetRules[ottllog.TransformContext](1*time.Hour), nil, nil, true)
 	newLogs, err := proc(context.Background(), logs)
 	require.NoError(t, err)
 	require.Equal(t, 1, newLogs.LogRecordCount())
@@ -38,3 +39,23 @@ func Test_newLogAttributesProcessor(t *testing.T) {
 	require.True(t, result.ObservedTimestamp() == pcommon.Timestamp(0))
 	require.Equal(t, now.Add(1*time.Hour), result.Timestamp().AsTime())
 }
+
+func Test_newLogAttributesProcessor_ObservedTimestamp(t *testing.T) {
+	now := time.Now().UTC()
+	for _, shiftObserved := range []bool{true, false} {
+		logs := plog.NewLogs()
+		lr := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(now))
+		lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(now))
+		proc := newLogAttributesProcessor(zap.NewNop(), offsetRules[ottllog.TransformContext](1*time.Hour), nil, nil, shiftObserved)
+		newLogs, err := proc(context.Background(), logs)
+		require.NoError(t, err)
+		result := newLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+		require.Equal(t, now.Add(1*time.Hour), result.Timestamp().AsTime())
+		if shiftObserved {
+			require.Equal(t, now.Add(1*time.Hour), result.ObservedTimestamp().AsTime())
+		} else {
+			require.Equal(t, now, result.ObservedTimestamp().AsTime())
+		}
+	}
+}
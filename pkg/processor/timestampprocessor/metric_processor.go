@@ -18,74 +18,149 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/processor/processorhelper"
 	"go.uber.org/zap"
 )
 
-func newMetricAttributesProcessor(_ *zap.Logger, offsetFn func(timestamp pcommon.Timestamp) pcommon.Timestamp) processorhelper.ProcessMetricsFunc {
+// dataPoint holds the methods shared by all data point types.
+type dataPoint interface {
+	Attributes() pcommon.Map
+	StartTimestamp() pcommon.Timestamp
+	SetStartTimestamp(pcommon.Timestamp)
+	Timestamp() pcommon.Timestamp
+	SetTimestamp(pcommon.Timestamp)
+}
+
+// exemplarsDataPoint is implemented by all data point types but summaries.
+type exemplarsDataPoint interface {
+	dataPoint
+	Exemplars() pmetric.ExemplarSlice
+}
+
+func newMetricAttributesProcessor(_ *zap.Logger, rules *rules[ottldatapoint.TransformContext], outOfRange *outOfRange, rebaser *rebaser) processorhelper.ProcessMetricsFunc {
 	return func(ctx context.Context, metrics pmetric.Metrics) (pmetric.Metrics, error) {
+		rebaseFn := rebaser.shiftFn(func() pcommon.Timestamp { return earliestDataPointTimestamp(metrics) })
+		check := outOfRange.start()
+		defer check.record(ctx)
+		var err error
 		for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
 			rs := metrics.ResourceMetrics().At(i)
 			for j := 0; j < rs.ScopeMetrics().Len(); j++ {
 				ss := rs.ScopeMetrics().At(j)
-				for k := 0; k < ss.Metrics().Len(); k++ {
-					metric := ss.Metrics().At(k)
+				// metrics are removed once all their data points are dropped
+				ss.Metrics().RemoveIf(func(metric pmetric.Metric) bool {
+					if err != nil {
+						return false
+					}
+					cumulative := isCumulative(metric)
+					processDataPoint := func(dp dataPoint) bool {
+						if err != nil {
+							return false
+						}
+						if rebaseFn != nil {
+							offsetDataPoint(dp, rebaseFn)
+						}
+						var offsetFn func(pcommon.Timestamp) pcommon.Timestamp
+						offsetFn, err = rules.offsetFn(ctx, rs.Resource(), dp.Attributes(), func() ottldatapoint.TransformContext {
+							return ottldatapoint.NewTransformContext(dp, metric, ss.Metrics(), ss.Scope(), rs.Resource())
+						})
+						if err != nil {
+							return false
+						}
+						offsetDataPoint(dp, offsetFn)
+						shiftFn, drop := check.check(dp.Timestamp(), zeroTs)
+						switch {
+						case shiftFn == nil:
+						case cumulative:
+							shiftCumulativeDataPoint(dp, shiftFn)
+						default:
+							offsetDataPoint(dp, shiftFn)
+						}
+						return drop
+					}
 					switch metric.Type() {
 					case pmetric.MetricTypeGauge:
-						for l := 0; l < metric.Gauge().DataPoints().Len(); l++ {
-							dp := metric.Gauge().DataPoints().At(l)
-							dp.SetStartTimestamp(offsetFn(dp.StartTimestamp()))
-							dp.SetTimestamp(offsetFn(dp.Timestamp()))
-							for m := 0; m < dp.Exemplars().Len(); m++ {
-								e := dp.Exemplars().At(m)
-								e.SetTimestamp(offsetFn(e.Timestamp()))
-							}
-						}
+						return removeDataPoints(metric.Gauge().DataPoints(), processDataPoint)
 					case pmetric.MetricTypeHistogram:
-						for l := 0; l < metric.Histogram().DataPoints().Len(); l++ {
-							dp := metric.Histogram().DataPoints().At(l)
-							dp.SetStartTimestamp(offsetFn(dp.StartTimestamp()))
-							dp.SetTimestamp(offsetFn(dp.Timestamp()))
-							for m := 0; m < dp.Exemplars().Len(); m++ {
-								e := dp.Exemplars().At(m)
-								e.SetTimestamp(offsetFn(e.Timestamp()))
-							}
-						}
+						return removeDataPoints(metric.Histogram().DataPoints(), processDataPoint)
 					case pmetric.MetricTypeEmpty:
 					case pmetric.MetricTypeSum:
-						for l := 0; l < metric.Sum().DataPoints().Len(); l++ {
-							dp := metric.Sum().DataPoints().At(l)
-							dp.SetStartTimestamp(offsetFn(dp.StartTimestamp()))
-							dp.SetTimestamp(offsetFn(dp.Timestamp()))
-							for m := 0; m < dp.Exemplars().Len(); m++ {
-								e := dp.Exemplars().At(m)
-								e.SetTimestamp(offsetFn(e.Timestamp()))
-							}
-						}
+						return removeDataPoints(metric.Sum().DataPoints(), processDataPoint)
 					case pmetric.MetricTypeExponentialHistogram:
-						for l := 0; l < metric.ExponentialHistogram().DataPoints().Len(); l++ {
-							dp := metric.ExponentialHistogram().DataPoints().At(l)
-							dp.SetStartTimestamp(offsetFn(dp.StartTimestamp()))
-							dp.SetTimestamp(offsetFn(dp.Timestamp()))
-							for m := 0; m < dp.Exemplars().Len(); m++ {
-								e := dp.Exemplars().At(m)
-								e.SetTimestamp(offsetFn(e.Timestamp()))
-							}
-						}
+						return removeDataPoints(metric.ExponentialHistogram().DataPoints(), processDataPoint)
 					case pmetric.MetricTypeSummary:
-						for l := 0; l < metric.Summary().DataPoints().Len(); l++ {
-							dp := metric.Summary().DataPoints().At(l)
-							dp.SetStartTimestamp(offsetFn(dp.StartTimestamp()))
-							dp.SetTimestamp(offsetFn(dp.Timestamp()))
-						}
+						return removeDataPoints(metric.Summary().DataPoints(), processDataPoint)
 					default:
-						return pmetric.Metrics{}, fmt.Errorf("unsupported metric type: %v", metric.Type())
+						err = fmt.Errorf("unsupported metric type: %v", metric.Type())
 					}
+					return false
+				})
+				if err != nil {
+					return pmetric.Metrics{}, err
 				}
 			}
 		}
 		return metrics, nil
 	}
 }
+
+// dataPointSlice is implemented by the slices of all data point types.
+type dataPointSlice[T dataPoint] interface {
+	Len() int
+	RemoveIf(func(T) bool)
+}
+
+// removeDataPoints removes the data points for which remove returns true, and returns whether all data points were
+// removed.
+func removeDataPoints[T dataPoint](dps dataPointSlice[T], remove func(dataPoint) bool) bool {
+	if dps.Len() == 0 {
+		return false
+	}
+	dps.RemoveIf(func(dp T) bool { return remove(dp) })
+	return dps.Len() == 0
+}
+
+func offsetDataPoint(dp dataPoint, offsetFn func(pcommon.Timestamp) pcommon.Timestamp) {
+	dp.SetStartTimestamp(offsetFn(dp.StartTimestamp()))
+	dp.SetTimestamp(offsetFn(dp.Timestamp()))
+	if edp, ok := dp.(exemplarsDataPoint); ok {
+		for m := 0; m < edp.Exemplars().Len(); m++ {
+			e := edp.Exemplars().At(m)
+			e.SetTimestamp(offsetFn(e.Timestamp()))
+		}
+	}
+}
+
+// shiftCumulativeDataPoint shifts an out of range cumulative data point. Its start timestamp is shared by all the
+// points of its series, so it is kept unless it would be after the shifted timestamp.
+func shiftCumulativeDataPoint(dp dataPoint, shiftFn func(pcommon.Timestamp) pcommon.Timestamp) {
+	dp.SetTimestamp(shiftFn(dp.Timestamp()))
+	if dp.StartTimestamp() > dp.Timestamp() {
+		dp.SetStartTimestamp(dp.Timestamp())
+	}
+	if edp, ok := dp.(exemplarsDataPoint); ok {
+		for m := 0; m < edp.Exemplars().Len(); m++ {
+			e := edp.Exemplars().At(m)
+			e.SetTimestamp(shiftFn(e.Timestamp()))
+		}
+	}
+}
+
+// isCumulative returns whether the data points of a metric accumulate since their start timestamp.
+func isCumulative(metric pmetric.Metric) bool {
+	switch metric.Type() {
+	case pmetric.MetricTypeSum:
+		return metric.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+	case pmetric.MetricTypeHistogram:
+		return metric.Histogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+	case pmetric.MetricTypeExponentialHistogram:
+		return metric.ExponentialHistogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative
+	case pmetric.MetricTypeSummary:
+		return true
+	default:
+		return false
+	}
+}
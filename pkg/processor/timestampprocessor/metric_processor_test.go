@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -33,7 +34,7 @@ func Test_newMetricAttributesProcessor_Gauge(t *testing.T) {
 	dp := gauge.DataPoints().AppendEmpty()
 	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
 	dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newMetricAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](1*time.Hour), nil, nil)
 	newMetrics, err := proc(context.Background(), metrics)
 	require.NoError(t, err)
 	require.Equal(t, 1, newMetrics.MetricCount())
@@ -50,7 +51,7 @@ func Test_newMetricAttributesProcessor_Sum(t *testing.T) {
 	dp := sum.DataPoints().AppendEmpty()
 	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
 	dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newMetricAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](1*time.Hour), nil, nil)
 	newMetrics, err := proc(context.Background(), metrics)
 	require.NoError(t, err)
 	require.Equal(t, 1, newMetrics.MetricCount())
@@ -67,7 +68,7 @@ func Test_newMetricAttributesProcessor_Histogram(t *testing.T) {
 	dp := sum.DataPoints().AppendEmpty()
 	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
 	dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newMetricAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](1*time.Hour), nil, nil)
 	newMetrics, err := proc(context.Background(), metrics)
 	require.NoError(t, err)
 	require.Equal(t, 1, newMetrics.MetricCount())
@@ -84,7 +85,7 @@ func Test_newMetricAttributesProcessor_ExponentionalHistogram(t *testing.T) {
 	dp := sum.DataPoints().AppendEmpty()
 	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
 	dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newMetricAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](1*time.Hour), nil, nil)
 	newMetrics, err := proc(context.Background(), metrics)
 	require.NoError(t, err)
 	require.Equal(t, 1, newMetrics.MetricCount())
@@ -101,7 +102,7 @@ func Test_newMetricAttributesProcessor_Summary(t *testing.T) {
 	dp := sum.DataPoints().AppendEmpty()
 	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
 	dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newMetricAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](1*time.Hour), nil, nil)
 	newMetrics, err := proc(context.Background(), metrics)
 	require.NoError(t, err)
 	require.Equal(t, 1, newMetrics.MetricCount())
@@ -0,0 +1,151 @@
+// Copyright  Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestampprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	modeClamp   = "clamp"
+	modeReplace = "replace"
+	modeDrop    = "drop"
+
+	scopeName = "github.com/signalfx/splunk-otel-collector/pkg/processor/timestampprocessor"
+)
+
+// outOfRange handles the telemetry with a timestamp outside of the [now-max_age, now+max_future] window. The
+// timestamps of a record are all shifted along with its reference timestamp, so that their spacing is preserved,
+// but for the start timestamps of cumulative data points which would reset their series.
+type outOfRange struct {
+	mode      string
+	maxAge    time.Duration
+	maxFuture time.Duration
+	adjusted  metric.Int64Counter
+	dropped   metric.Int64Counter
+	attrs     metric.AddOption
+}
+
+// newOutOfRange returns the handling of out of range timestamps of a signal, nil when disabled.
+func newOutOfRange(cfg OutOfRange, set component.TelemetrySettings, id component.ID, signal string) (*outOfRange, error) {
+	if cfg.Mode == "" {
+		return nil, nil
+	}
+	meter := set.MeterProvider.Meter(scopeName)
+	adjusted, err := meter.Int64Counter("processor/timestamp/adjusted_items",
+		metric.WithDescription("Number of spans, data points and log records with out of range timestamps which were adjusted"))
+	if err != nil {
+		return nil, err
+	}
+	dropped, err := meter.Int64Counter("processor/timestamp/dropped_items",
+		metric.WithDescription("Number of spans, data points and log records with out of range timestamps which were dropped"))
+	if err != nil {
+		return nil, err
+	}
+	return &outOfRange{
+		mode:      cfg.Mode,
+		maxAge:    cfg.MaxAge,
+		maxFuture: cfg.MaxFuture,
+		adjusted:  adjusted,
+		dropped:   dropped,
+		attrs: metric.WithAttributes(
+			attribute.String("processor", id.String()),
+			attribute.String("signal", signal)),
+	}, nil
+}
+
+// windowCheck checks the timestamps of a batch against the window at the time the batch is processed.
+type windowCheck struct {
+	*outOfRange
+	now      pcommon.Timestamp
+	min      pcommon.Timestamp
+	max      pcommon.Timestamp
+	adjusted int64
+	dropped  int64
+}
+
+// start returns the check of a batch, nil when out of range timestamps are not handled.
+func (o *outOfRange) start() *windowCheck {
+	if o == nil {
+		return nil
+	}
+	now := time.Now()
+	c := &windowCheck{outOfRange: o, now: pcommon.NewTimestampFromTime(now)}
+	if o.maxAge > 0 {
+		c.min = pcommon.NewTimestampFromTime(now.Add(-o.maxAge))
+	}
+	if o.maxFuture > 0 {
+		c.max = pcommon.NewTimestampFromTime(now.Add(o.maxFuture))
+	}
+	return c
+}
+
+// check returns the function shifting the timestamps of a record so that its reference timestamp is in range, nil
+// if it already is, or whether the record must be dropped. receiveTime replaces the reference timestamp in replace
+// mode when set, the current time otherwise.
+func (c *windowCheck) check(ts pcommon.Timestamp, receiveTime pcommon.Timestamp) (func(pcommon.Timestamp) pcommon.Timestamp, bool) {
+	if c == nil || ts == zeroTs {
+		return nil, false
+	}
+	var bound pcommon.Timestamp
+	switch {
+	case c.min != zeroTs && ts < c.min:
+		bound = c.min
+	case c.max != zeroTs && ts > c.max:
+		bound = c.max
+	default:
+		return nil, false
+	}
+	switch c.mode {
+	case modeDrop:
+		c.dropped++
+		return nil, true
+	case modeReplace:
+		bound = c.now
+		if receiveTime != zeroTs {
+			bound = receiveTime
+		}
+	}
+	c.adjusted++
+	return shiftFn(int64(bound) - int64(ts)), false
+}
+
+// record adds the number of records adjusted and dropped in the batch to the counters.
+func (c *windowCheck) record(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	if c.adjusted > 0 {
+		c.outOfRange.adjusted.Add(ctx, c.adjusted, c.attrs)
+	}
+	if c.dropped > 0 {
+		c.outOfRange.dropped.Add(ctx, c.dropped, c.attrs)
+	}
+}
+
+func shiftFn(shift int64) func(pcommon.Timestamp) pcommon.Timestamp {
+	return func(ts pcommon.Timestamp) pcommon.Timestamp {
+		if ts == zeroTs {
+			return ts
+		}
+		return pcommon.Timestamp(int64(ts) + shift)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright  Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestampprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+)
+
+func newTestOutOfRange(t *testing.T, cfg OutOfRange, signal string) (*outOfRange, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	set := componenttest.NewNopTelemetrySettings()
+	set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	o, err := newOutOfRange(cfg, set, component.MustNewID(typeStr), signal)
+	require.NoError(t, err)
+	return o, reader
+}
+
+// counterValues returns the values of the counters by name.
+func counterValues(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	values := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				values[m.Name] += dp.Value
+			}
+		}
+	}
+	return values
+}
+
+func Test_outOfRange_Disabled(t *testing.T) {
+	o, err := newOutOfRange(OutOfRange{}, componenttest.NewNopTelemetrySettings(), component.MustNewID(typeStr), "logs")
+	require.NoError(t, err)
+	require.Nil(t, o)
+	shiftFn, drop := o.start().check(pcommon.Timestamp(1), zeroTs)
+	require.Nil(t, shiftFn)
+	require.False(t, drop)
+}
+
+func Test_outOfRange_ClampSpans(t *testing.T) {
+	o, reader := newTestOutOfRange(t, OutOfRange{Mode: modeClamp, MaxFuture: time.Hour}, "traces")
+	now := time.Now().UTC()
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for _, start := range []time.Time{now.Add(24 * time.Hour), now} {
+		span := spans.AppendEmpty()
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Minute)))
+		span.Events().AppendEmpty().SetTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Second)))
+	}
+
+	newTraces, err := newSpanAttributesProcessor(zap.NewNop(), offsetRules[ottlspan.TransformContext](0), o, nil)(context.Background(), traces)
+	require.NoError(t, err)
+	newSpans := newTraces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, newSpans.Len())
+	// the span is shifted to end at the bound of the window, its events and duration preserved
+	clamped := newSpans.At(0)
+	end := clamped.EndTimestamp().AsTime()
+	require.WithinRange(t, end, now.Add(time.Hour), time.Now().Add(time.Hour))
+	require.Equal(t, end.Add(-time.Minute), clamped.StartTimestamp().AsTime())
+	require.Equal(t, end.Add(-time.Minute+time.Second), clamped.Events().At(0).Timestamp().AsTime())
+	require.Equal(t, now, newSpans.At(1).StartTimestamp().AsTime())
+	require.Equal(t, map[string]int64{"processor/timestamp/adjusted_items": 1}, counterValues(t, reader))
+}
+
+func Test_outOfRange_ReplaceLogs(t *testing.T) {
+	o, reader := newTestOutOfRange(t, OutOfRange{Mode: modeReplace, MaxAge: time.Hour, MaxFuture: time.Hour}, "logs")
+	now := time.Now().UTC()
+	observed := now.Add(-time.Second)
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, ts := range []time.Time{time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), time.Unix(0, 1), now} {
+		lr := records.AppendEmpty()
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+		lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(observed))
+	}
+	// without observed timestamp, the record is received now
+	records.At(1).SetObservedTimestamp(zeroTs)
+
+	newLogs, err := newLogAttributesProcessor(zap.NewNop(), offsetRules[ottllog.TransformContext](0), o, nil, true)(context.Background(), logs)
+	require.NoError(t, err)
+	newRecords := newLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 3, newRecords.Len())
+	require.Equal(t, observed, newRecords.At(0).Timestamp().AsTime())
+	require.WithinRange(t, newRecords.At(1).Timestamp().AsTime(), now, time.Now())
+	require.Equal(t, now, newRecords.At(2).Timestamp().AsTime())
+	require.Equal(t, map[string]int64{"processor/timestamp/adjusted_items": 2}, counterValues(t, reader))
+}
+
+func Test_outOfRange_DropDataPoints(t *testing.T) {
+	o, reader := newTestOutOfRange(t, OutOfRange{Mode: modeDrop, MaxAge: time.Hour}, "metrics")
+	now := time.Now().UTC()
+	metrics := pmetric.NewMetrics()
+	ms := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	sum := ms.AppendEmpty()
+	sum.SetName("partially_stale")
+	sum.SetEmptySum()
+	for _, ts := range []time.Time{now.Add(-48 * time.Hour), now} {
+		sum.Sum().DataPoints().AppendEmpty().SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	}
+	summary := ms.AppendEmpty()
+	summary.SetName("stale")
+	summary.SetEmptySummary().DataPoints().AppendEmpty().SetTimestamp(pcommon.NewTimestampFromTime(now.Add(-2 * time.Hour)))
+	// metrics without data points are kept
+	ms.AppendEmpty().SetName("empty")
+
+	newMetrics, err := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](0), o, nil)(context.Background(), metrics)
+	require.NoError(t, err)
+	newMs := newMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, newMs.Len())
+	require.Equal(t, "partially_stale", newMs.At(0).Name())
+	require.Equal(t, 1, newMs.At(0).Sum().DataPoints().Len())
+	require.Equal(t, now, newMs.At(0).Sum().DataPoints().At(0).Timestamp().AsTime())
+	require.Equal(t, "empty", newMs.At(1).Name())
+	require.Equal(t, map[string]int64{"processor/timestamp/dropped_items": 2}, counterValues(t, reader))
+}
+
+func Test_outOfRange_ClampCumulativeDataPoints(t *testing.T) {
+	o, reader := newTestOutOfRange(t, OutOfRange{Mode: modeClamp, MaxFuture: time.Hour}, "metrics")
+	now := time.Now().UTC()
+	start := now.Add(-24 * time.Hour)
+	metrics := pmetric.NewMetrics()
+	ms := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	for _, temporality := range []pmetric.AggregationTemporality{pmetric.AggregationTemporalityCumulative, pmetric.AggregationTemporalityDelta} {
+		sum := ms.AppendEmpty()
+		sum.SetName(temporality.String())
+		sum.SetEmptySum().SetAggregationTemporality(temporality)
+		for _, ts := range []time.Time{now.Add(48 * time.Hour), now} {
+			dp := sum.Sum().DataPoints().AppendEmpty()
+			dp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+			dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+		}
+	}
+	// a start timestamp after the clamped timestamp is brought back to it
+	histogram := ms.AppendEmpty()
+	histogram.SetName("restarted")
+	histogram.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := histogram.Histogram().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(now.Add(47 * time.Hour)))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(now.Add(48 * time.Hour)))
+
+	newMetrics, err := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](0), o, nil)(context.Background(), metrics)
+	require.NoError(t, err)
+	newMs := newMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+
+	// the cumulative series keeps its start timestamp so that it isn't reset
+	cumulative := newMs.At(0).Sum().DataPoints()
+	clamped := cumulative.At(0).Timestamp().AsTime()
+	require.WithinRange(t, clamped, now.Add(time.Hour), time.Now().Add(time.Hour))
+	require.Equal(t, start, cumulative.At(0).StartTimestamp().AsTime())
+	require.Equal(t, start, cumulative.At(1).StartTimestamp().AsTime())
+
+	// the delta point is shifted as a whole, preserving its interval
+	delta := newMs.At(1).Sum().DataPoints().At(0)
+	require.Equal(t, delta.Timestamp().AsTime().Add(-72*time.Hour), delta.StartTimestamp().AsTime())
+
+	restarted := newMs.At(2).Histogram().DataPoints().At(0)
+	require.Equal(t, restarted.Timestamp(), restarted.StartTimestamp())
+	require.Equal(t, map[string]int64{"processor/timestamp/adjusted_items": 3}, counterValues(t, reader))
+}
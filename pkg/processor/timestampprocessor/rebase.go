@@ -0,0 +1,180 @@
+// Copyright  Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestampprocessor
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var (
+	// The traces, metrics and logs processors of a configuration share their rebaser, so that the telemetry of
+	// all signals is shifted alike.
+	rebaserStoreLock = sync.Mutex{}
+	rebaserStore     = map[*Config]*rebaser{}
+)
+
+// rebaser shifts replayed telemetry so that its anchor timestamp maps to the time the first batch is processed.
+type rebaser struct {
+	anchor pcommon.Timestamp
+	shift  int64
+	// the number of processors using the rebaser, guarded by rebaserStoreLock
+	refs  int
+	known bool
+	mu    sync.Mutex
+}
+
+// getOrCreateRebaser returns the rebaser of a valid configuration, nil when rebasing is disabled. Each processor
+// getting a rebaser must release it on shutdown.
+func getOrCreateRebaser(cfg *Config) *rebaser {
+	if !cfg.Rebase.Enabled {
+		return nil
+	}
+	rebaserStoreLock.Lock()
+	defer rebaserStoreLock.Unlock()
+	r, ok := rebaserStore[cfg]
+	if !ok {
+		r = &rebaser{}
+		if cfg.Rebase.Anchor != "" {
+			anchor, _ := time.Parse(time.RFC3339Nano, cfg.Rebase.Anchor)
+			r.anchor = pcommon.NewTimestampFromTime(anchor)
+		}
+		rebaserStore[cfg] = r
+	}
+	r.refs++
+	return r
+}
+
+// releaseRebaser forgets the rebaser of a configuration once none of its processors use it anymore.
+func releaseRebaser(cfg *Config) {
+	rebaserStoreLock.Lock()
+	defer rebaserStoreLock.Unlock()
+	r, ok := rebaserStore[cfg]
+	if !ok {
+		return
+	}
+	if r.refs--; r.refs <= 0 {
+		delete(rebaserStore, cfg)
+	}
+}
+
+// shiftFn returns the function rebasing the timestamps of a batch, nil if the shift isn't known yet. Without
+// configured anchor, the earliest timestamp of the first batch having one is the anchor. earliest is only called
+// until the shift is known.
+func (r *rebaser) shiftFn(earliest func() pcommon.Timestamp) func(pcommon.Timestamp) pcommon.Timestamp {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.known {
+		anchor := r.anchor
+		if anchor == zeroTs {
+			anchor = earliest()
+		}
+		if anchor == zeroTs {
+			return nil
+		}
+		r.shift = int64(pcommon.NewTimestampFromTime(time.Now())) - int64(anchor)
+		r.known = true
+	}
+	return shiftFn(r.shift)
+}
+
+// keepEarliest keeps the earliest non-zero timestamp in first.
+func keepEarliest(first *pcommon.Timestamp, ts pcommon.Timestamp) {
+	if ts != zeroTs && (*first == zeroTs || ts < *first) {
+		*first = ts
+	}
+}
+
+// The anchor candidates are the start timestamps of spans, the timestamps of data points, and the timestamps of
+// log records, or their observed timestamps when they have none. Data point start timestamps are not candidates
+// as the start of cumulative series may be long before their recording. Observed timestamps are the time records
+// were received, so they aren't rebased.
+
+func earliestSpanTimestamp(traces ptrace.Traces) pcommon.Timestamp {
+	var first pcommon.Timestamp
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				keepEarliest(&first, ss.Spans().At(k).StartTimestamp())
+			}
+		}
+	}
+	return first
+}
+
+func earliestDataPointTimestamp(metrics pmetric.Metrics) pcommon.Timestamp {
+	var first pcommon.Timestamp
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		rs := metrics.ResourceMetrics().At(i)
+		for j := 0; j < rs.ScopeMetrics().Len(); j++ {
+			ss := rs.ScopeMetrics().At(j)
+			for k := 0; k < ss.Metrics().Len(); k++ {
+				metric := ss.Metrics().At(k)
+				switch metric.Type() {
+				case pmetric.MetricTypeGauge:
+					for l := 0; l < metric.Gauge().DataPoints().Len(); l++ {
+						keepEarliest(&first, metric.Gauge().DataPoints().At(l).Timestamp())
+					}
+				case pmetric.MetricTypeSum:
+					for l := 0; l < metric.Sum().DataPoints().Len(); l++ {
+						keepEarliest(&first, metric.Sum().DataPoints().At(l).Timestamp())
+					}
+				case pmetric.MetricTypeHistogram:
+					for l := 0; l < metric.Histogram().DataPoints().Len(); l++ {
+						keepEarliest(&first, metric.Histogram().DataPoints().At(l).Timestamp())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					for l := 0; l < metric.ExponentialHistogram().DataPoints().Len(); l++ {
+						keepEarliest(&first, metric.ExponentialHistogram().DataPoints().At(l).Timestamp())
+					}
+				case pmetric.MetricTypeSummary:
+					for l := 0; l < metric.Summary().DataPoints().Len(); l++ {
+						keepEarliest(&first, metric.Summary().DataPoints().At(l).Timestamp())
+					}
+				}
+			}
+		}
+	}
+	return first
+}
+
+func earliestLogTimestamp(logs plog.Logs) pcommon.Timestamp {
+	var first pcommon.Timestamp
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rs := logs.ResourceLogs().At(i)
+		for j := 0; j < rs.ScopeLogs().Len(); j++ {
+			ss := rs.ScopeLogs().At(j)
+			for k := 0; k < ss.LogRecords().Len(); k++ {
+				log := ss.LogRecords().At(k)
+				if log.Timestamp() != zeroTs {
+					keepEarliest(&first, log.Timestamp())
+				} else {
+					keepEarliest(&first, log.ObservedTimestamp())
+				}
+			}
+		}
+	}
+	return first
+}
@@ -0,0 +1,144 @@
+// Copyright  Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestampprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.uber.org/zap"
+)
+
+var recorded = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func Test_rebaser_Disabled(t *testing.T) {
+	require.Nil(t, getOrCreateRebaser(&Config{Offset: "0h"}))
+}
+
+func Test_rebaser_FirstBatch(t *testing.T) {
+	cfg := &Config{Offset: "0h", Rebase: Rebase{Enabled: true}}
+	r := getOrCreateRebaser(cfg)
+	require.Same(t, r, getOrCreateRebaser(cfg))
+
+	// the shift isn't known until a batch has timestamps
+	_, err := newSpanAttributesProcessor(zap.NewNop(), offsetRules[ottlspan.TransformContext](0), nil, r)(context.Background(), ptrace.NewTraces())
+	require.NoError(t, err)
+	require.False(t, r.known)
+
+	before := time.Now()
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	parent := spans.AppendEmpty()
+	parent.SetStartTimestamp(pcommon.NewTimestampFromTime(recorded.Add(time.Second)))
+	parent.SetEndTimestamp(pcommon.NewTimestampFromTime(recorded.Add(time.Minute)))
+	child := spans.AppendEmpty()
+	child.SetStartTimestamp(pcommon.NewTimestampFromTime(recorded))
+	child.SetEndTimestamp(pcommon.NewTimestampFromTime(recorded.Add(2 * time.Second)))
+	child.Events().AppendEmpty().SetTimestamp(pcommon.NewTimestampFromTime(recorded.Add(time.Second)))
+	child.Links().AppendEmpty().SetSpanID(pcommon.SpanID{1})
+
+	newTraces, err := newSpanAttributesProcessor(zap.NewNop(), offsetRules[ottlspan.TransformContext](0), nil, r)(context.Background(), traces)
+	require.NoError(t, err)
+	after := time.Now()
+	newSpans := newTraces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	// the earliest start timestamp maps to now, the spacing of all timestamps is preserved
+	start := newSpans.At(1).StartTimestamp().AsTime()
+	require.WithinRange(t, start, before, after)
+	require.Equal(t, start.Add(2*time.Second), newSpans.At(1).EndTimestamp().AsTime())
+	require.Equal(t, start.Add(time.Second), newSpans.At(1).Events().At(0).Timestamp().AsTime())
+	require.Equal(t, pcommon.SpanID{1}, newSpans.At(1).Links().At(0).SpanID())
+	require.Equal(t, start.Add(time.Second), newSpans.At(0).StartTimestamp().AsTime())
+	require.Equal(t, start.Add(time.Minute), newSpans.At(0).EndTimestamp().AsTime())
+
+	// the other signals are shifted alike
+	metrics := pmetric.NewMetrics()
+	dp := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(recorded.Add(-time.Hour)))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(recorded.Add(10 * time.Second)))
+	dp.Exemplars().AppendEmpty().SetTimestamp(pcommon.NewTimestampFromTime(recorded.Add(5 * time.Second)))
+	newMetrics, err := newMetricAttributesProcessor(zap.NewNop(), offsetRules[ottldatapoint.TransformContext](0), nil, r)(context.Background(), metrics)
+	require.NoError(t, err)
+	newDp := newMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints().At(0)
+	require.Equal(t, start.Add(-time.Hour), newDp.StartTimestamp().AsTime())
+	require.Equal(t, start.Add(10*time.Second), newDp.Timestamp().AsTime())
+	require.Equal(t, start.Add(5*time.Second), newDp.Exemplars().At(0).Timestamp().AsTime())
+}
+
+func Test_rebaser_Anchor(t *testing.T) {
+	cfg := &Config{Offset: "1h", Rebase: Rebase{Enabled: true, Anchor: recorded.Format(time.RFC3339)}}
+	require.NoError(t, cfg.Validate())
+	r := getOrCreateRebaser(cfg)
+
+	before := time.Now()
+	logs := plog.NewLogs()
+	lr := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(recorded.Add(time.Minute)))
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(recorded.Add(time.Minute + time.Second)))
+	newLogs, err := newLogAttributesProcessor(zap.NewNop(), offsetRules[ottllog.TransformContext](time.Hour), nil, r, true)(context.Background(), logs)
+	require.NoError(t, err)
+	after := time.Now()
+
+	// the offset applies once rebased
+	newRecord := newLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	ts := newRecord.Timestamp().AsTime()
+	require.WithinRange(t, ts, before.Add(time.Hour+time.Minute), after.Add(time.Hour+time.Minute))
+	// the observed timestamp, the time the record was received, is only offset
+	require.Equal(t, recorded.Add(time.Hour+time.Minute+time.Second), newRecord.ObservedTimestamp().AsTime())
+}
+
+func Test_rebaser_ReleasedOnShutdown(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Rebase.Enabled = true
+	set := processortest.NewNopCreateSettings()
+
+	tp, err := factory.CreateTracesProcessor(context.Background(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	mp, err := factory.CreateMetricsProcessor(context.Background(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	lp, err := factory.CreateLogsProcessor(context.Background(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+
+	storeLen := func() int {
+		rebaserStoreLock.Lock()
+		defer rebaserStoreLock.Unlock()
+		return len(rebaserStore)
+	}
+	before := storeLen()
+	require.Contains(t, rebaserStore, cfg)
+	// the rebaser is kept until the last processor of the configuration shuts down
+	require.NoError(t, tp.Shutdown(context.Background()))
+	require.NoError(t, mp.Shutdown(context.Background()))
+	require.Equal(t, before, storeLen())
+	require.NoError(t, lp.Shutdown(context.Background()))
+	require.Equal(t, before-1, storeLen())
+	require.NotContains(t, rebaserStore, cfg)
+}
+
+func TestValidateRebase(t *testing.T) {
+	cfg := &Config{Offset: "0h", Rebase: Rebase{Enabled: true, Anchor: "yesterday"}}
+	require.ErrorContains(t, cfg.Validate(), "invalid rebase anchor format yesterday")
+}
@@ -0,0 +1,130 @@
+// Copyright  Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestampprocessor
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// rules hold the parsed rules of the configuration for the OTTL context K of a signal.
+type rules[K any] struct {
+	rules []rule[K]
+	// the offset applied when no rule matches
+	offset time.Duration
+}
+
+type rule[K any] struct {
+	resourceAttributes map[string]string
+	attributes         map[string]string
+	condition          *ottl.Condition[K]
+	offset             time.Duration
+	offsetAttribute    string
+	offsetUnit         time.Duration
+}
+
+// newRules parses the rules of a valid configuration, the conditions with the parser of the signal.
+func newRules[K any](cfg *Config, parser ottl.Parser[K]) (*rules[K], error) {
+	offset, _ := time.ParseDuration(cfg.Offset)
+	r := &rules[K]{offset: offset}
+	for _, ruleCfg := range cfg.Rules {
+		ru := rule[K]{
+			resourceAttributes: ruleCfg.ResourceAttributes,
+			attributes:         ruleCfg.Attributes,
+			offsetAttribute:    ruleCfg.OffsetAttribute,
+			offsetUnit:         time.Second,
+		}
+		if ruleCfg.Offset != "" {
+			ru.offset, _ = time.ParseDuration(ruleCfg.Offset)
+		}
+		if ruleCfg.OffsetUnit != "" {
+			ru.offsetUnit, _ = time.ParseDuration(ruleCfg.OffsetUnit)
+		}
+		if ruleCfg.Condition != "" {
+			condition, err := parser.ParseCondition(ruleCfg.Condition)
+			if err != nil {
+				return nil, err
+			}
+			ru.condition = condition
+		}
+		r.rules = append(r.rules, ru)
+	}
+	return r, nil
+}
+
+// offsetFn returns the function offsetting the timestamps of a record by the offset of the first rule matching it.
+// tCtx returns the OTTL context of the record, only built if a rule has a condition.
+func (r *rules[K]) offsetFn(ctx context.Context, resource pcommon.Resource, attributes pcommon.Map, tCtx func() K) (func(pcommon.Timestamp) pcommon.Timestamp, error) {
+	for i := range r.rules {
+		ru := &r.rules[i]
+		matched, err := ru.matches(ctx, resource.Attributes(), attributes, tCtx)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return offsetFn(ru.recordOffset(resource.Attributes(), attributes)), nil
+		}
+	}
+	return offsetFn(r.offset), nil
+}
+
+func (ru *rule[K]) matches(ctx context.Context, resourceAttributes pcommon.Map, attributes pcommon.Map, tCtx func() K) (bool, error) {
+	if !matchAttributes(resourceAttributes, ru.resourceAttributes) || !matchAttributes(attributes, ru.attributes) {
+		return false, nil
+	}
+	if ru.condition == nil {
+		return true, nil
+	}
+	return ru.condition.Eval(ctx, tCtx())
+}
+
+func matchAttributes(attributes pcommon.Map, expected map[string]string) bool {
+	for k, v := range expected {
+		actual, ok := attributes.Get(k)
+		if !ok || actual.AsString() != v {
+			return false
+		}
+	}
+	return true
+}
+
+// recordOffset returns the offset held by the offset attribute of the record or of its resource, or the offset of
+// the rule.
+func (ru *rule[K]) recordOffset(resourceAttributes pcommon.Map, attributes pcommon.Map) time.Duration {
+	if ru.offsetAttribute == "" {
+		return ru.offset
+	}
+	value, ok := attributes.Get(ru.offsetAttribute)
+	if !ok {
+		value, ok = resourceAttributes.Get(ru.offsetAttribute)
+	}
+	if !ok {
+		return ru.offset
+	}
+	switch value.Type() {
+	case pcommon.ValueTypeStr:
+		if offset, err := time.ParseDuration(value.Str()); err == nil {
+			return offset
+		}
+	case pcommon.ValueTypeInt:
+		return time.Duration(value.Int()) * ru.offsetUnit
+	case pcommon.ValueTypeDouble:
+		return time.Duration(value.Double() * float64(ru.offsetUnit))
+	}
+	return ru.offset
+}
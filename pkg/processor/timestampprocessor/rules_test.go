@@ -0,0 +1,154 @@
+// Copyright  Splunk, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestampprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// offsetRules returns rules applying the same offset to all telemetry.
+func offsetRules[K any](offset time.Duration) *rules[K] {
+	return &rules[K]{offset: offset}
+}
+
+func Test_rules_Spans(t *testing.T) {
+	cfg := &Config{
+		Offset: "1h",
+		Rules: []Rule{
+			{ResourceAttributes: map[string]string{"host.name": "skewed"}, Offset: "-2h"},
+			{Condition: `name == "drifting"`, OffsetAttribute: "clock.drift", Offset: "3h"},
+		},
+	}
+	parser, err := ottlspan.NewParser(ottlfuncs.StandardConverters[ottlspan.TransformContext](), componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	r, err := newRules(cfg, parser)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	traces := ptrace.NewTraces()
+	for _, host := range []string{"skewed", "other"} {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("host.name", host)
+		spans := rs.ScopeSpans().AppendEmpty().Spans()
+		for _, name := range []string{"drifting", "drifting", "other"} {
+			span := spans.AppendEmpty()
+			span.SetName(name)
+			span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+			span.SetEndTimestamp(pcommon.NewTimestampFromTime(now))
+			span.Events().AppendEmpty().SetTimestamp(pcommon.NewTimestampFromTime(now))
+		}
+		spans.At(0).Attributes().PutInt("clock.drift", -30)
+	}
+
+	newTraces, err := newSpanAttributesProcessor(zap.NewNop(), r, nil, nil)(context.Background(), traces)
+	require.NoError(t, err)
+	expected := [][]time.Duration{
+		// the first rule matches all spans of the skewed host
+		{-2 * time.Hour, -2 * time.Hour, -2 * time.Hour},
+		// the second rule matches the drifting spans, defaulting to its offset without drift attribute
+		{-30 * time.Second, 3 * time.Hour, time.Hour},
+	}
+	for i, offsets := range expected {
+		spans := newTraces.ResourceSpans().At(i).ScopeSpans().At(0).Spans()
+		for j, offset := range offsets {
+			span := spans.At(j)
+			require.Equal(t, now.Add(offset), span.StartTimestamp().AsTime(), "resource %d span %d", i, j)
+			require.Equal(t, now.Add(offset), span.EndTimestamp().AsTime(), "resource %d span %d", i, j)
+			require.Equal(t, now.Add(offset), span.Events().At(0).Timestamp().AsTime(), "resource %d span %d", i, j)
+		}
+	}
+}
+
+func Test_rules_DataPoints(t *testing.T) {
+	cfg := &Config{
+		Offset: "0h",
+		Rules:  []Rule{{Condition: `metric.name == "device.temperature"`, Attributes: map[string]string{"device": "a"}, Offset: "1m"}},
+	}
+	parser, err := ottldatapoint.NewParser(ottlfuncs.StandardConverters[ottldatapoint.TransformContext](), componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	r, err := newRules(cfg, parser)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	metrics := pmetric.NewMetrics()
+	m := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("device.temperature")
+	gauge := m.SetEmptyGauge()
+	for _, device := range []string{"b", "a"} {
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("device", device)
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(now))
+	}
+
+	newMetrics, err := newMetricAttributesProcessor(zap.NewNop(), r, nil, nil)(context.Background(), metrics)
+	require.NoError(t, err)
+	dps := newMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+	require.Equal(t, now, dps.At(0).Timestamp().AsTime())
+	require.Equal(t, now.Add(time.Minute), dps.At(1).Timestamp().AsTime())
+}
+
+func Test_rules_Logs(t *testing.T) {
+	cfg := &Config{
+		Offset: "0h",
+		Rules:  []Rule{{OffsetAttribute: "clock.drift", OffsetUnit: "1ms"}},
+	}
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[ottllog.TransformContext](), componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	r, err := newRules(cfg, parser)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutDouble("clock.drift", 1.5)
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for _, drift := range []any{nil, int64(250), "2s", "invalid"} {
+		lr := records.AppendEmpty()
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(now))
+		if drift != nil {
+			require.NoError(t, lr.Attributes().PutEmpty("clock.drift").FromRaw(drift))
+		}
+	}
+
+	newLogs, err := newLogAttributesProcessor(zap.NewNop(), r, nil, nil, true)(context.Background(), logs)
+	require.NoError(t, err)
+	newRecords := newLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	// the resource attribute applies when the record has none
+	require.Equal(t, now.Add(1500*time.Microsecond), newRecords.At(0).Timestamp().AsTime())
+	require.Equal(t, now.Add(250*time.Millisecond), newRecords.At(1).Timestamp().AsTime())
+	require.Equal(t, now.Add(2*time.Second), newRecords.At(2).Timestamp().AsTime())
+	require.Equal(t, now, newRecords.At(3).Timestamp().AsTime())
+}
+
+func Test_newRules_InvalidCondition(t *testing.T) {
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[ottllog.TransformContext](), componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	_, err = newRules(&Config{Offset: "0h", Rules: []Rule{{Condition: `metric.name == "a"`, Offset: "1h"}}}, parser)
+	require.Error(t, err)
+}
@@ -17,27 +17,51 @@ package timestampprocessor
 import (
 	"context"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/processor/processorhelper"
 	"go.uber.org/zap"
 )
 
-func newSpanAttributesProcessor(_ *zap.Logger, offsetFn func(timestamp pcommon.Timestamp) pcommon.Timestamp) processorhelper.ProcessTracesFunc {
+func newSpanAttributesProcessor(_ *zap.Logger, rules *rules[ottlspan.TransformContext], outOfRange *outOfRange, rebaser *rebaser) processorhelper.ProcessTracesFunc {
 
 	return func(ctx context.Context, traces ptrace.Traces) (ptrace.Traces, error) {
+		rebaseFn := rebaser.shiftFn(func() pcommon.Timestamp { return earliestSpanTimestamp(traces) })
+		check := outOfRange.start()
+		defer check.record(ctx)
+		var err error
 		for i := 0; i < traces.ResourceSpans().Len(); i++ {
 			rs := traces.ResourceSpans().At(i)
 			for j := 0; j < rs.ScopeSpans().Len(); j++ {
 				ss := rs.ScopeSpans().At(j)
-				for k := 0; k < ss.Spans().Len(); k++ {
-					span := ss.Spans().At(k)
-					span.SetStartTimestamp(offsetFn(span.StartTimestamp()))
-					span.SetEndTimestamp(offsetFn(span.EndTimestamp()))
-					for l := 0; l < span.Events().Len(); l++ {
-						e := span.Events().At(l)
-						e.SetTimestamp(offsetFn(e.Timestamp()))
+				ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+					if err != nil {
+						return false
 					}
+					if rebaseFn != nil {
+						offsetSpan(span, rebaseFn)
+					}
+					var offsetFn func(pcommon.Timestamp) pcommon.Timestamp
+					offsetFn, err = rules.offsetFn(ctx, rs.Resource(), span.Attributes(), func() ottlspan.TransformContext {
+						return ottlspan.NewTransformContext(span, ss.Scope(), rs.Resource())
+					})
+					if err != nil {
+						return false
+					}
+					offsetSpan(span, offsetFn)
+					reference := span.EndTimestamp()
+					if reference == zeroTs {
+						reference = span.StartTimestamp()
+					}
+					shiftFn, drop := check.check(reference, zeroTs)
+					if shiftFn != nil {
+						offsetSpan(span, shiftFn)
+					}
+					return drop
+				})
+				if err != nil {
+					return traces, err
 				}
 			}
 		}
@@ -45,3 +69,12 @@ func newSpanAttributesProcessor(_ *zap.Logger, offsetFn func(timestamp pcommon.T
 	}
 
 }
+
+func offsetSpan(span ptrace.Span, offsetFn func(pcommon.Timestamp) pcommon.Timestamp) {
+	span.SetStartTimestamp(offsetFn(span.StartTimestamp()))
+	span.SetEndTimestamp(offsetFn(span.EndTimestamp()))
+	for l := 0; l < span.Events().Len(); l++ {
+		e := span.Events().At(l)
+		e.SetTimestamp(offsetFn(e.Timestamp()))
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -32,7 +33,7 @@ func Test_newSpanAttributesProcessor(t *testing.T) {
 	span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
 	e := span.Events().AppendEmpty()
 	e.SetTimestamp(pcommon.NewTimestampFromTime(now))
-	proc := newSpanAttributesProcessor(zap.NewNop(), offsetFn(1*time.Hour))
+	proc := newSpanAttributesProcessor(zap.NewNop(), offsetRules[ottlspan.TransformContext](1*time.Hour), nil, nil)
 	newTraces, err := proc(context.Background(), traces)
 	require.NoError(t, err)
 	require.Equal(t, 1, newTraces.SpanCount())